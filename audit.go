@@ -0,0 +1,127 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// AuditRecord is the record of an execution that is written by WithAuditWriter. The arguments and the error are
+// redacted, only the keys of the environment are recorded.
+type AuditRecord struct {
+	Time     time.Time     `json:"time"`
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Args     []string      `json:"args"`
+	Dir      string        `json:"dir,omitempty"`
+	EnvKeys  []string      `json:"env_keys"`
+	User     string        `json:"user,omitempty"`
+	PID      int           `json:"pid,omitempty"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// WithAuditWriter writes one JSON record per execution to the writer, one per line, once the command has been waited
+// or could not be started, see AuditRecord. The records are written regardless of the logger, for the compliance
+// pipelines that need an audit stream of the executions. Every next stage of the pipeline inherits the writer.
+//
+// The writes of the command and of its pipeline are serialized, a writer that is shared with other commands must be
+// safe for concurrent use.
+func WithAuditWriter(w io.Writer) Option {
+	return optionFunc(func(c *Cmd) {
+		c.audit = &auditSink{w: w}
+	})
+}
+
+// auditSink writes the audit records.
+type auditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *auditSink) write(rec AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("could not encode audit record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("could not write audit record: %w", err)
+	}
+
+	return nil
+}
+
+// writeAudit writes the audit record of the execution.
+func (c *Cmd) writeAudit(err error) {
+	if c.audit == nil {
+		return
+	}
+
+	if err := c.audit.write(newAuditRecord(c, err)); err != nil {
+		c.logger.Debug(c.ctx, fmt.Sprintf("failed to audit `%s`", c.name), "error", err)
+	}
+}
+
+func newAuditRecord(c *Cmd, err error) AuditRecord {
+	rec := AuditRecord{
+		Time:     time.Now(),
+		Name:     c.name,
+		Path:     c.Path,
+		Args:     c.redact(c.Args...),
+		Dir:      c.Dir,
+		User:     c.loginUser,
+		ExitCode: -1,
+		Duration: c.duration,
+	}
+
+	env := c.environ()
+	rec.EnvKeys = make([]string, 0, len(env))
+
+	for _, kv := range env {
+		if k, _, ok := splitEnv(kv); ok {
+			rec.EnvKeys = append(rec.EnvKeys, k)
+		}
+	}
+
+	if rec.User == "" {
+		rec.User = currentUser()
+	}
+
+	if c.Process != nil {
+		rec.PID = c.Process.Pid
+	}
+
+	if c.ProcessState != nil {
+		rec.ExitCode = c.ProcessState.ExitCode()
+	}
+
+	if err != nil {
+		rec.Error = c.redactString(err.Error())
+	}
+
+	return rec
+}
+
+var (
+	currentUserOnce sync.Once
+	currentUserName string
+)
+
+// currentUser returns the name of the user of the current process, it is looked up once.
+func currentUser() string {
+	currentUserOnce.Do(func() {
+		if u, err := user.Current(); err == nil {
+			currentUserName = u.Username
+		}
+	})
+
+	return currentUserName
+}
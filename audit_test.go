@@ -0,0 +1,116 @@
+package exec_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/bool64/ctxd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func readAuditRecords(t *testing.T, s string) []exec.AuditRecord {
+	t.Helper()
+
+	var records []exec.AuditRecord
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+
+	for scanner.Scan() {
+		var rec exec.AuditRecord
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+
+		records = append(records, rec)
+	}
+
+	return records
+}
+
+func TestWithAuditWriter(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	logger := &ctxd.LoggerMock{}
+
+	u, err := user.Current()
+	require.NoError(t, err)
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", "sleep 0.01; exit 3", "secret"),
+		exec.WithEnv("TOKEN", "s3cr3t"),
+		exec.WithDir(t.TempDir()),
+		exec.RedactArgs("secret"),
+		exec.WithAuditWriter(out),
+		exec.WithLogger(logger),
+	)
+	require.Error(t, err)
+
+	records := readAuditRecords(t, out.String())
+	require.Len(t, records, 1)
+
+	rec := records[0]
+
+	assert.Equal(t, "sh", rec.Name)
+	assert.Equal(t, cmd.Path, rec.Path)
+	assert.Equal(t, []string{cmd.Path, "-c", "sleep 0.01; exit 3", "******"}, rec.Args)
+	assert.Equal(t, cmd.Dir, rec.Dir)
+	assert.Contains(t, rec.EnvKeys, "TOKEN")
+	assert.NotContains(t, out.String(), "s3cr3t")
+	assert.Equal(t, u.Username, rec.User)
+	assert.Equal(t, cmd.Process.Pid, rec.PID)
+	assert.Equal(t, 3, rec.ExitCode)
+	assert.Positive(t, rec.Duration)
+	assert.Equal(t, "exit status 3", rec.Error)
+	assert.False(t, rec.Time.IsZero())
+
+	// The audit stream does not depend on the logger.
+	assert.Len(t, logger.LoggedEntries, 1)
+}
+
+func TestWithAuditWriter_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithAuditWriter(out),
+		exec.Pipe("cat"),
+		exec.WithStdout(newSafeBuffer()),
+	)
+	require.NoError(t, err)
+
+	records := readAuditRecords(t, out.String())
+	require.Len(t, records, 2)
+
+	names := []string{records[0].Name, records[1].Name}
+
+	assert.ElementsMatch(t, []string{"echo", "cat"}, names)
+
+	for _, rec := range records {
+		assert.Zero(t, rec.ExitCode)
+		assert.Empty(t, rec.Error)
+	}
+}
+
+func TestWithAuditWriter_NotStarted(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("sh", exec.WithDir("/does/not/exist"), exec.WithAuditWriter(out))
+	require.Error(t, err)
+
+	records := readAuditRecords(t, out.String())
+	require.Len(t, records, 1)
+
+	assert.Equal(t, -1, records[0].ExitCode)
+	assert.Zero(t, records[0].PID)
+	assert.NotEmpty(t, records[0].Error)
+}
@@ -0,0 +1,100 @@
+package exec
+
+import (
+	"path/filepath"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// Backend runs commands somewhere else than the local host, such as a remote host or a container, through a local
+// client process. Because the client is a local process, its standard streams are bridged by the client itself and the
+// command can be used in a pipeline like any other.
+type Backend interface {
+	// Wrap returns the local client command and its arguments that runs the given command on the backend.
+	Wrap(name string, args []string) (string, []string)
+}
+
+// BackendFunc is a function that implements Backend.
+type BackendFunc func(name string, args []string) (string, []string)
+
+// Wrap calls the function.
+func (f BackendFunc) Wrap(name string, args []string) (string, []string) {
+	return f(name, args)
+}
+
+// SSH returns a backend that runs the commands on the given host with the ssh client. The arguments are quoted for
+// the POSIX shell of the remote host.
+func SSH(host string, sshArgs ...string) Backend {
+	return BackendFunc(func(name string, args []string) (string, []string) {
+		wrapped := make([]string, 0, len(sshArgs)+3)
+		wrapped = append(wrapped, sshArgs...)
+		wrapped = append(wrapped, host, "--", shellquote.Join(append([]string{name}, args...)...))
+
+		return "ssh", wrapped
+	})
+}
+
+// Container returns a backend that runs the commands in a running container with the given container runtime, such as
+// docker or podman. The standard input is kept open, so the command can read from a pipeline.
+func Container(runtime, container string, execArgs ...string) Backend {
+	return BackendFunc(func(name string, args []string) (string, []string) {
+		wrapped := make([]string, 0, len(execArgs)+len(args)+4)
+		wrapped = append(wrapped, "exec", "-i")
+		wrapped = append(wrapped, execArgs...)
+		wrapped = append(wrapped, container, name)
+		wrapped = append(wrapped, args...)
+
+		return runtime, wrapped
+	})
+}
+
+// WithBackend runs the command on the given backend. The command is resolved on the backend, only the client has to
+// be available locally.
+func WithBackend(b Backend) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("backend", "WithBackend")
+
+		c.backend = b
+	})
+}
+
+// PipeOn pipes the output to the next command that runs on the given backend.
+func PipeOn(b Backend, name string, args ...string) Option {
+	return optionFunc(func(c *Cmd) {
+		if c.Next == nil {
+			c.Next = CommandContext(c.ctx, name, WithArgs(args...), WithBackend(b)) //nolint: gosec
+		} else {
+			PipeOn(b, name, args...).applyOption(c.Next)
+		}
+	})
+}
+
+// applyBackend replaces the command with the client command of the backend, only once.
+func applyBackend(c *Cmd) {
+	if c.backend == nil || c.backendApplied {
+		return
+	}
+
+	c.backendApplied = true
+
+	name, args := c.backend.Wrap(c.name, c.Args[1:])
+
+	c.setPath(name)
+	c.Args = append([]string{name}, args...)
+}
+
+// setPath resolves the executable like os/exec.Command does.
+func (c *Cmd) setPath(name string) {
+	c.Path, c.Err = name, nil
+
+	if filepath.Base(name) != name {
+		return
+	}
+
+	lp, err := LookPath(name)
+	if lp != "" {
+		c.Path = lp
+	}
+
+	c.Err = err
+}
@@ -0,0 +1,72 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+// fakeRemote runs the commands with a shell that knows the "remote" environment.
+var fakeRemote = exec.BackendFunc(func(name string, args []string) (string, []string) {
+	return "sh", []string{"-c", "HOST=remote " + shellquote.Join(append([]string{name}, args...)...)}
+})
+
+func TestSSH_Wrap(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("pg_restore",
+		exec.WithArgs("-d", "my db"),
+		exec.WithBackend(exec.SSH("db.example.com", "-p", "2222")),
+	)
+
+	expected := []string{"ssh", "-p", "2222", "db.example.com", "--", "pg_restore -d 'my db'"}
+
+	assert.Equal(t, expected, cmd.Args)
+}
+
+func TestContainer_Wrap(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("psql",
+		exec.WithArgs("-c", "select 1"),
+		exec.WithBackend(exec.Container("podman", "db", "-u", "postgres")),
+	)
+
+	expected := []string{"podman", "exec", "-i", "-u", "postgres", "db", "psql", "-c", "select 1"}
+
+	assert.Equal(t, expected, cmd.Args)
+}
+
+func TestPipeOn_LocalToRemote(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("echo", exec.WithArgs("hello world"),
+		exec.WithStdout(out),
+		exec.PipeOn(fakeRemote, "sh", "-c", `echo "$HOST: $(cat)"`),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "REMOTE: HELLO WORLD", getOutput(out))
+}
+
+func TestWithBackend_RemoteToLocal(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("sh", exec.WithArgs("-c", `echo "$HOST"`),
+		exec.WithBackend(fakeRemote),
+		exec.WithStdout(out),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "REMOTE", getOutput(out))
+}
@@ -0,0 +1,51 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// baggageEnv is the name of the variable of the baggage in the environment of the process.
+const baggageEnv = "BAGGAGE"
+
+// WithBaggage sets the baggage of the context of the execution in the BAGGAGE variable of the environment of the
+// process, so the request-scoped metadata, like a tenant id, flows into the child. The child reads it back with
+// BaggageFromEnv or ContextFromEnv. It is the same as WithPropagator(propagation.Baggage{}).
+func WithBaggage() Option {
+	return WithPropagator(propagation.Baggage{})
+}
+
+// BaggageFromEnv parses the BAGGAGE variable of the environment of the current process, it is empty when the variable
+// is not set.
+func BaggageFromEnv() (baggage.Baggage, error) {
+	b, err := baggage.Parse(os.Getenv(baggageEnv))
+	if err != nil {
+		return baggage.Baggage{}, fmt.Errorf("could not parse %s: %w", baggageEnv, err)
+	}
+
+	return b, nil
+}
+
+// ContextFromEnv returns a copy of the context with the trace context and the baggage that the parent has set in the
+// environment of the current process with WithTraceParent and WithBaggage. The propagators replace the default ones,
+// propagation.TraceContext and propagation.Baggage.
+func ContextFromEnv(ctx context.Context, propagators ...propagation.TextMapPropagator) context.Context {
+	if len(propagators) == 0 {
+		propagators = []propagation.TextMapPropagator{propagation.TraceContext{}, propagation.Baggage{}}
+	}
+
+	carrier := envCarrier{}
+
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			carrier[k] = v
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...).Extract(ctx, carrier)
+}
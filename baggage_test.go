@@ -0,0 +1,90 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithBaggage(t *testing.T) {
+	t.Parallel()
+
+	tenant, err := baggage.NewMember("tenant", "acme")
+	require.NoError(t, err)
+
+	b, err := baggage.New(tenant)
+	require.NoError(t, err)
+
+	out := newSafeBuffer()
+
+	_, err = exec.RunWithContext(baggage.ContextWithBaggage(context.Background(), b), "echo",
+		exec.WithBaggage(),
+		exec.Pipe("sh", "-c", `cat; echo "$BAGGAGE"`),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tenant=acme", getOutput(out))
+}
+
+func TestWithBaggage_Empty(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", `echo "${BAGGAGE-unset}"`),
+		exec.WithBaggage(),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "unset", getOutput(out))
+}
+
+func TestBaggageFromEnv(t *testing.T) {
+	t.Setenv("BAGGAGE", "tenant=acme,region=eu")
+
+	b, err := exec.BaggageFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, "acme", b.Member("tenant").Value())
+	assert.Equal(t, "eu", b.Member("region").Value())
+}
+
+func TestBaggageFromEnv_Invalid(t *testing.T) {
+	t.Setenv("BAGGAGE", "tenant")
+
+	b, err := exec.BaggageFromEnv()
+
+	require.ErrorContains(t, err, "could not parse BAGGAGE")
+	assert.Zero(t, b.Len())
+}
+
+func TestContextFromEnv(t *testing.T) {
+	tracer := sdktrace.NewTracerProvider().Tracer("")
+	_, parent := tracer.Start(context.Background(), "parent")
+
+	defer parent.End()
+
+	sc := parent.SpanContext()
+
+	t.Setenv("TRACEPARENT", "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01")
+	t.Setenv("BAGGAGE", "tenant=acme")
+
+	ctx := exec.ContextFromEnv(context.Background())
+
+	remote := trace.SpanContextFromContext(ctx)
+
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, sc.TraceID(), remote.TraceID())
+	assert.Equal(t, sc.SpanID(), remote.SpanID())
+	assert.Equal(t, "acme", baggage.FromContext(ctx).Member("tenant").Value())
+}
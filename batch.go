@@ -0,0 +1,78 @@
+package exec
+
+// BatchOption is an option to configure a batch of executions.
+type BatchOption interface {
+	applyBatchOption(c *batchConfig)
+}
+
+type batchConfig struct {
+	failFast bool
+
+	delimiter  byte
+	maxArgs    int
+	argMax     int
+	runIfEmpty bool
+}
+
+type batchOptionFunc func(c *batchConfig)
+
+func (f batchOptionFunc) applyBatchOption(c *batchConfig) {
+	f(c)
+}
+
+func newBatchConfig(opts ...BatchOption) *batchConfig {
+	c := &batchConfig{
+		delimiter: '\n',
+		argMax:    defaultArgMax,
+	}
+
+	for _, opt := range opts {
+		opt.applyBatchOption(c)
+	}
+
+	return c
+}
+
+// FailFast stops the batch at the first failure. The commands that are running are cancelled and the ones that have not
+// been started are skipped.
+func FailFast() BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		c.failFast = true
+	})
+}
+
+// ContinueOnError runs every command of the batch regardless of the failures. This is the default behavior.
+func ContinueOnError() BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		c.failFast = false
+	})
+}
+
+// WithNullDelimiter makes Xargs split the items by NUL characters instead of new lines, like `xargs -0`.
+func WithNullDelimiter() BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		c.delimiter = 0
+	})
+}
+
+// WithMaxArgs limits the number of items passed to one command of Xargs, like `xargs -n`.
+func WithMaxArgs(n int) BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		c.maxArgs = n
+	})
+}
+
+// WithRunIfEmpty makes Xargs run the command once without any item when the input is empty, like xargs without `-r`.
+// By default, nothing is run for an empty input, like `xargs -r`.
+func WithRunIfEmpty() BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		c.runIfEmpty = true
+	})
+}
+
+// WithArgMax sets the maximum size in bytes of the arguments and the environment of one command of Xargs.
+func WithArgMax(n int) BatchOption {
+	return batchOptionFunc(func(c *batchConfig) {
+		c.argMax = n
+	})
+}
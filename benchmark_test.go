@@ -0,0 +1,84 @@
+package exec_test
+
+import (
+	"context"
+	osexec "os/exec"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"go.nhat.io/exec"
+)
+
+func BenchmarkCommand(b *testing.B) {
+	b.Run("os/exec", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = osexec.CommandContext(context.Background(), "true")
+		}
+	})
+
+	b.Run("exec", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = exec.CommandContext(context.Background(), "true")
+		}
+	})
+}
+
+func BenchmarkCommand_WithEnv(b *testing.B) {
+	b.Run("environ", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = exec.CommandContext(context.Background(), "true", exec.WithEnv("FOO", "bar"))
+		}
+	})
+
+	b.Run("snapshot", func(b *testing.B) {
+		b.ReportAllocs()
+
+		exec.SetEnvSnapshot(true)
+		defer exec.SetEnvSnapshot(false)
+
+		for i := 0; i < b.N; i++ {
+			_ = exec.CommandContext(context.Background(), "true", exec.WithEnv("FOO", "bar"))
+		}
+	})
+}
+
+func BenchmarkRun(b *testing.B) {
+	b.Run("os/exec", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if err := osexec.CommandContext(context.Background(), "true").Run(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("exec", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := exec.RunWithContext(context.Background(), "true"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("exec with tracer", func(b *testing.B) {
+		b.ReportAllocs()
+
+		tracer := trace.NewNoopTracerProvider().Tracer("")
+
+		for i := 0; i < b.N; i++ {
+			if _, err := exec.RunWithContext(context.Background(), "true", exec.WithTracer(tracer)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
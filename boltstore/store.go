@@ -0,0 +1,105 @@
+// Package boltstore provides a bbolt implementation of exec.ResultStore.
+package boltstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"go.nhat.io/exec"
+)
+
+// DefaultBucket is the bucket that stores the records.
+const DefaultBucket = "exec_results"
+
+// Store persists the results of the executions in a bbolt database.
+type Store struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+var _ exec.ResultStore = (*Store)(nil)
+
+// New creates a new Store that keeps the records in the given bucket of the database, the bucket is created if it does
+// not exist.
+func New(db *bolt.DB, bucket string) (*Store, error) {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+
+	s := &Store{db: db, bucket: []byte(bucket)}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.bucket)
+
+		return err //nolint: wrapcheck
+	}); err != nil {
+		return nil, fmt.Errorf("could not create bucket: %w", err)
+	}
+
+	return s, nil
+}
+
+// Save persists the record with the next sequence of the bucket as the ID.
+func (s *Store) Save(_ context.Context, r exec.ResultRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error { //nolint: wrapcheck
+		b := tx.Bucket(s.bucket)
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return fmt.Errorf("could not generate id: %w", err)
+		}
+
+		r.ID = id
+
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("could not encode record: %w", err)
+		}
+
+		return b.Put(key(id), data) //nolint: wrapcheck
+	})
+}
+
+// Query returns the records matching the query, the most recent first.
+func (s *Store) Query(_ context.Context, q exec.ResultQuery) ([]exec.ResultRecord, error) {
+	var result []exec.ResultRecord
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).ForEach(func(_, v []byte) error {
+			var r exec.ResultRecord
+
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("could not decode record: %w", err)
+			}
+
+			if q.Match(r) {
+				result = append(result, r)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	exec.SortResultRecords(result)
+
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+
+	return result, nil
+}
+
+func key(id uint64) []byte {
+	b := make([]byte, 8)
+
+	binary.BigEndian.PutUint64(b, id)
+
+	return b
+}
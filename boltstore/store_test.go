@@ -0,0 +1,26 @@
+package boltstore_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"go.nhat.io/exec/boltstore"
+	exectest "go.nhat.io/exec/test"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "results.db"), 0o600, nil)
+	require.NoError(t, err)
+
+	defer db.Close() //nolint: errcheck
+
+	s, err := boltstore.New(db, "")
+	require.NoError(t, err)
+
+	exectest.ResultStore(t, s)
+}
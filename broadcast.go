@@ -0,0 +1,141 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Broadcast feeds the same standard input to the commands, which run concurrently, and waits for all of them. The
+// input is read once and written to every command through an OS pipe, a command that exits without reading all of it
+// is dropped and the others keep receiving it, like `tee -p`. The slowest command sets the pace.
+//
+// The results have the same order as the commands. Every failure, including the one to read the input, is aggregated
+// in a MultiError. The commands must not have a standard input nor have been started.
+func Broadcast(stdin io.Reader, cmds ...*Cmd) ([]Result, error) {
+	var (
+		results = make([]Result, len(cmds))
+		writers = make([]*os.File, 0, len(cmds))
+		started = make([]int, 0, len(cmds))
+	)
+
+	for i, cmd := range cmds {
+		w, err := startBroadcast(cmd)
+		if err != nil {
+			results[i] = newResult(cmd, err)
+
+			continue
+		}
+
+		writers = append(writers, w)
+		started = append(started, i)
+	}
+
+	var wg sync.WaitGroup
+
+	for _, i := range started {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			results[i] = newResult(cmds[i], cmds[i].Wait())
+		}(i)
+	}
+
+	readErr := broadcast(stdin, writers)
+
+	wg.Wait()
+
+	err := collectErrors(results)
+	if readErr == nil {
+		return results, err
+	}
+
+	errs, _ := err.(MultiError) //nolint: errorlint
+
+	return results, append(errs, fmt.Errorf("could not read stdin: %w", readErr))
+}
+
+// startBroadcast starts the command with an OS pipe as its standard input and returns the write end.
+func startBroadcast(cmd *Cmd) (*os.File, error) {
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+
+	if cmd.Stdin != nil {
+		option := cmd.claims["standard input"]
+		if option == "" {
+			option = "Stdin"
+		}
+
+		return nil, fmt.Errorf("%w: %s and Broadcast both set the standard input", ErrOptionConflict, option)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not create stdin pipe: %w", err)
+	}
+
+	cmd.Stdin = r
+
+	err = cmd.Start()
+
+	_ = r.Close() //nolint: errcheck
+
+	if err != nil {
+		_ = w.Close() //nolint: errcheck
+
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// broadcast copies the input to the writers until the input is exhausted or no writer is left, then closes them.
+func broadcast(r io.Reader, writers []*os.File) error {
+	alive := append(broadcastWriter(nil), writers...)
+
+	defer func() {
+		for _, w := range writers {
+			_ = w.Close() //nolint: errcheck
+		}
+	}()
+
+	if len(alive) == 0 {
+		return nil
+	}
+
+	_, err := copyBuffer(&alive, r)
+	if err == errNoReader { //nolint: errorlint
+		return nil
+	}
+
+	return err
+}
+
+// errNoReader stops the broadcast when every command has stopped reading.
+var errNoReader = errors.New("exec: no reader left")
+
+// broadcastWriter writes to the commands that are still reading.
+type broadcastWriter []*os.File
+
+func (b *broadcastWriter) Write(p []byte) (int, error) {
+	alive := (*b)[:0]
+
+	for _, w := range *b {
+		if _, err := w.Write(p); err == nil {
+			alive = append(alive, w)
+		}
+	}
+
+	*b = alive
+
+	if len(alive) == 0 {
+		return 0, errNoReader
+	}
+
+	return len(p), nil
+}
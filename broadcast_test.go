@@ -0,0 +1,94 @@
+package exec_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestBroadcast(t *testing.T) {
+	t.Parallel()
+
+	input := bytes.Repeat([]byte("hello world\n"), 100_000)
+
+	count := newSafeBuffer()
+	first := newSafeBuffer()
+	lines := newSafeBuffer()
+
+	results, err := exec.Broadcast(bytes.NewReader(input),
+		exec.Command("wc", exec.WithArgs("-c"), exec.WithStdout(count)),
+		// Exits before reading the whole input, the others keep receiving it.
+		exec.Command("head", exec.WithArgs("-n", "1"), exec.WithStdout(first)),
+		exec.Command("grep", exec.WithArgs("-c", "hello"), exec.WithStdout(lines)),
+	)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	for _, r := range results {
+		assert.NoError(t, r.Err)
+		assert.Equal(t, 0, r.ExitCode)
+	}
+
+	assert.Equal(t, "1200000", getOutput(count))
+	assert.Equal(t, "hello world", getOutput(first))
+	assert.Equal(t, "100000", getOutput(lines))
+}
+
+func TestBroadcast_Failures(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	results, err := exec.Broadcast(strings.NewReader("hello world"),
+		exec.Command("cat", exec.WithStdout(out)),
+		exec.Command("cat", exec.WithStdin(strings.NewReader("hi"))),
+		exec.Command("sh", exec.WithArgs("-c", "cat > /dev/null; exit 2")),
+		exec.Command("not_found"),
+	)
+	require.Error(t, err)
+	require.Len(t, results, 4)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "hello world", getOutput(out))
+
+	require.ErrorIs(t, results[1].Err, exec.ErrOptionConflict)
+	assert.EqualError(t, results[1].Err, "exec: conflicting options: WithStdin and Broadcast both set the standard input")
+
+	assert.ErrorIs(t, results[2].Err, exec.ExitCodeError(2))
+	assert.ErrorIs(t, results[3].Err, exec.ErrNotFound)
+
+	assert.ErrorIs(t, err, exec.ExitCodeError(2))
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+}
+
+func TestBroadcast_ReadError(t *testing.T) {
+	t.Parallel()
+
+	errRead := errors.New("read error")
+	out := newSafeBuffer()
+
+	results, err := exec.Broadcast(io.MultiReader(strings.NewReader("hello"), errReader{errRead}),
+		exec.Command("cat", exec.WithStdout(out)),
+	)
+
+	require.ErrorIs(t, err, errRead)
+	assert.EqualError(t, err, "could not read stdin: read error")
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "hello", getOutput(out))
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,138 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBudgetExhausted indicates that the time budget of an operation has been used up.
+var ErrBudgetExhausted = errors.New("exec: time budget exhausted")
+
+// Budget is a time budget shared by all the executions of one logical operation, such as the attempts of a retry or
+// the commands of a sequence. Every execution consumes the time it runs, a command is not started once the budget is
+// exhausted and is killed when the budget runs out while it is running.
+type Budget struct {
+	mu    sync.Mutex
+	total time.Duration
+	used  time.Duration
+}
+
+// NewBudget creates a new time budget.
+func NewBudget(d time.Duration) *Budget {
+	return &Budget{total: d}
+}
+
+// Remaining returns the remaining time of the budget.
+func (b *Budget) Remaining() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.total - b.used
+}
+
+// Used returns the time consumed so far.
+func (b *Budget) Used() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.used
+}
+
+func (b *Budget) consume(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.used += d
+}
+
+type budgetWatch struct {
+	timer    *time.Timer
+	exceeded atomic.Bool
+}
+
+func (c *Cmd) checkBudget() error {
+	if c.budget == nil {
+		return nil
+	}
+
+	if c.budget.Remaining() <= 0 {
+		return fmt.Errorf("%w after %s", ErrBudgetExhausted, c.budget.Used())
+	}
+
+	return nil
+}
+
+// watchBudget kills the process when the budget runs out. Each stage of a pipeline watches the budget on its own and
+// only the head of the pipeline consumes it, because the stages run at the same time.
+func (c *Cmd) watchBudget() {
+	if c.budget == nil {
+		return
+	}
+
+	w := &budgetWatch{}
+	p := c.Process
+
+	w.timer = time.AfterFunc(c.budget.Remaining(), func() {
+		w.exceeded.Store(true)
+
+		_ = c.killProcess(p) //nolint: errcheck
+	})
+
+	c.budgetWatch = w
+}
+
+func (c *Cmd) releaseBudget(err error) error {
+	if c.budgetWatch == nil {
+		return err
+	}
+
+	c.budgetWatch.timer.Stop()
+
+	if c.prev == nil {
+		c.budget.consume(c.duration)
+	}
+
+	// The command may have exited by itself before it was killed.
+	if err != nil && c.budgetWatch.exceeded.Load() {
+		return &BudgetError{Err: err}
+	}
+
+	return err
+}
+
+// BudgetError is the error of a command that has been killed because its time budget ran out. It matches
+// ErrBudgetExhausted with errors.Is.
+type BudgetError struct {
+	// Err is the error of the killed command.
+	Err error
+}
+
+// Error returns the error of the command.
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrBudgetExhausted, e.Err)
+}
+
+// Unwrap returns the error of the command.
+func (e *BudgetError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrBudgetExhausted.
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExhausted //nolint: errorlint
+}
+
+// WithBudget limits the total time of the command and all the stages of its pipeline.
+func WithBudget(d time.Duration) Option {
+	return WithSharedBudget(NewBudget(d))
+}
+
+// WithSharedBudget makes the command consume the given budget, the same budget can be shared by several commands.
+func WithSharedBudget(b *Budget) Option {
+	return optionFunc(func(c *Cmd) {
+		c.budget = b
+	})
+}
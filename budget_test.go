@@ -0,0 +1,69 @@
+package exec_test
+
+import (
+	osexec "os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithBudget_Exceeded(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	_, err := exec.Run("sleep", exec.WithArgs("10"), exec.WithBudget(100*time.Millisecond))
+
+	assert.ErrorIs(t, err, exec.ErrBudgetExhausted)
+	assert.EqualError(t, err, "exec: time budget exhausted: signal: killed")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var budgetErr *exec.BudgetError
+
+	require.ErrorAs(t, err, &budgetErr)
+
+	var exitErr *osexec.ExitError
+
+	// The error of the killed command is kept.
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, -1, exitErr.ExitCode())
+}
+
+func TestWithBudget_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	_, err := exec.Run("echo", exec.WithArgs("hello"),
+		exec.WithBudget(100*time.Millisecond),
+		exec.Pipe("sh", "-c", "exec sleep 10"),
+	)
+
+	assert.ErrorIs(t, err, exec.ErrBudgetExhausted)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWithSharedBudget(t *testing.T) {
+	t.Parallel()
+
+	b := exec.NewBudget(300 * time.Millisecond)
+
+	_, err := exec.Run("sleep", exec.WithArgs("0.2"), exec.WithSharedBudget(b))
+	require.NoError(t, err)
+
+	assert.GreaterOrEqual(t, b.Used(), 200*time.Millisecond)
+
+	_, err = exec.Run("sleep", exec.WithArgs("10"), exec.WithSharedBudget(b))
+
+	assert.ErrorIs(t, err, exec.ErrBudgetExhausted)
+	assert.LessOrEqual(t, b.Remaining(), time.Duration(0))
+
+	_, err = exec.Run("echo", exec.WithArgs("hello"), exec.WithSharedBudget(b))
+
+	assert.ErrorIs(t, err, exec.ErrBudgetExhausted)
+	assert.Contains(t, err.Error(), "exec: time budget exhausted after")
+}
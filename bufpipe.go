@@ -0,0 +1,170 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// WithPipeBuffer sets the size of the buffer of the pipes between the stages of the pipeline, so a bursty producer is
+// not throttled by a slow consumer until the buffer is full. On Linux, the size of the OS pipe is changed, or a buffered
+// copier is used if the size can not be set, for example above /proc/sys/fs/pipe-max-size. On the other platforms, a
+// buffered copier is inserted between the stages.
+//
+// Every next stage inherits the size, a stage can set its own with PipeWith.
+func WithPipeBuffer(size int) Option {
+	return optionFunc(func(c *Cmd) {
+		if size < 0 {
+			c.invalid("WithPipeBuffer has a negative size %d", size)
+
+			return
+		}
+
+		c.pipeBuffer = size
+	})
+}
+
+// bufferedPipe is an in-memory pipe with a buffer of a fixed size. The writes only block when the buffer is full.
+type bufferedPipe struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+	size int
+
+	rErr error
+	wErr error
+}
+
+func newBufferedPipe(size int) (*bufferedPipeReader, *bufferedPipeWriter) {
+	p := &bufferedPipe{size: size}
+	p.cond = sync.NewCond(&p.mu)
+
+	return &bufferedPipeReader{p}, &bufferedPipeWriter{p}
+}
+
+func (p *bufferedPipe) read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.buf.Len() == 0 && p.wErr == nil && p.rErr == nil {
+		p.cond.Wait()
+	}
+
+	if p.rErr != nil {
+		return 0, io.ErrClosedPipe
+	}
+
+	if p.buf.Len() == 0 {
+		return 0, p.wErr
+	}
+
+	n, _ := p.buf.Read(b) //nolint: errcheck
+
+	p.cond.Broadcast()
+
+	return n, nil
+}
+
+func (p *bufferedPipe) write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var written int
+
+	for len(b) > 0 {
+		for p.buf.Len() >= p.size && p.rErr == nil && p.wErr == nil {
+			p.cond.Wait()
+		}
+
+		if p.rErr != nil {
+			return written, p.rErr
+		}
+
+		if p.wErr != nil {
+			return written, io.ErrClosedPipe
+		}
+
+		n := p.size - p.buf.Len()
+		if n > len(b) {
+			n = len(b)
+		}
+
+		p.buf.Write(b[:n])
+		written += n
+		b = b[n:]
+
+		p.cond.Broadcast()
+	}
+
+	return written, nil
+}
+
+func (p *bufferedPipe) closeRead(err error) {
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rErr == nil {
+		p.rErr = err
+	}
+
+	p.cond.Broadcast()
+}
+
+func (p *bufferedPipe) closeWrite(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.wErr == nil {
+		p.wErr = err
+	}
+
+	p.cond.Broadcast()
+}
+
+// bufferedPipeReader is the read end of a bufferedPipe, it behaves like an io.PipeReader.
+type bufferedPipeReader struct {
+	p *bufferedPipe
+}
+
+func (r *bufferedPipeReader) Read(b []byte) (int, error) {
+	return r.p.read(b)
+}
+
+func (r *bufferedPipeReader) Close() error {
+	return r.CloseWithError(nil)
+}
+
+// CloseWithError closes the reader, the writes return the error, or io.ErrClosedPipe if it is nil.
+func (r *bufferedPipeReader) CloseWithError(err error) error {
+	r.p.closeRead(err)
+
+	return nil
+}
+
+// bufferedPipeWriter is the write end of a bufferedPipe, it behaves like an io.PipeWriter.
+type bufferedPipeWriter struct {
+	p *bufferedPipe
+}
+
+func (w *bufferedPipeWriter) Write(b []byte) (int, error) {
+	return w.p.write(b)
+}
+
+func (w *bufferedPipeWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError closes the writer, the reads return the error once the buffer is drained, or io.EOF if it is nil.
+func (w *bufferedPipeWriter) CloseWithError(err error) error {
+	w.p.closeWrite(err)
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithPipeBuffer(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "printf",
+		exec.WithArgs("a\nb\nc\n"),
+		exec.WithPipeBuffer(1),
+		exec.PipeWith("grep", exec.WithArgs("-v", "b"), exec.WithPipeBuffer(0)),
+		exec.Pipe("tr", "a-z", "A-Z"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "A\nC", out)
+}
+
+func TestWithPipeBuffer_Negative(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.WithPipeBuffer(-1), exec.Pipe("cat"))
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+}
@@ -0,0 +1,73 @@
+package exec
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithFailFast stops the next stages of the pipeline as soon as a stage fails, instead of waiting for them to consume
+// the rest of their input, which may never end if something else still holds the pipe open. The contexts of the next
+// stages are cancelled, so they are killed and Wait returns promptly. By default, like in a shell, the next stages run
+// until they exit on their own.
+//
+// Every next stage inherits the option.
+func WithFailFast() Option {
+	return optionFunc(func(c *Cmd) {
+		c.failFast = true
+	})
+}
+
+// nextContext returns the context of the next stage of the pipeline, it is cancelled when the command fails with
+// WithFailFast.
+func (c *Cmd) nextContext(ctx context.Context) context.Context {
+	if !c.failFast || c.Next == nil {
+		return ctx
+	}
+
+	ctx, c.cancelNext = context.WithCancel(ctx)
+
+	return ctx
+}
+
+// cancelDownstream cancels the context of the next stages of the pipeline.
+func (c *Cmd) cancelDownstream() {
+	if c.cancelNext != nil {
+		c.cancelNext()
+	}
+}
+
+// watchContext kills the process when the context is done. Every stage of a pipeline watches the context, so they are
+// all terminated promptly, even those that are not bound to it by os/exec, like the commands adopted by FromCmd.
+func (c *Cmd) watchContext() {
+	ctxDone := c.ctx.Done()
+	if ctxDone == nil {
+		return
+	}
+
+	p, exited := c.Process, c.done
+
+	go func() {
+		select {
+		case <-ctxDone:
+			_ = c.killProcess(p) //nolint: errcheck
+
+		case <-exited:
+		}
+	}()
+}
+
+// recordCancelCause records on the span why the context of the command is done, if it is.
+func (c *Cmd) recordCancelCause(span trace.Span) {
+	if cause := c.ctx.Err(); cause != nil {
+		span.SetAttributes(attribute.String("exec.cancel_cause", cause.Error()))
+	}
+}
+
+// abortPipeline stops the command when the next stage could not be started, nothing would consume its output.
+func (c *Cmd) abortPipeline() {
+	_ = c.Next.stdinPipe.Close() //nolint: errcheck
+
+	_ = c.kill() //nolint: errcheck
+}
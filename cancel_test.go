@@ -0,0 +1,95 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestRun_Pipe_CancelStopsEveryStage(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	// The stages do not read their standard input, they would not exit when the first one is killed.
+	_, err := exec.RunWithContext(ctx, "sleep",
+		exec.WithArgs("10"),
+		exec.Pipe("sleep", "10"),
+		exec.Pipe("sleep", "10"),
+		exec.WithTracer(tracer),
+	)
+	require.Error(t, err)
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// The stages and the pipeline.
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+
+	for _, s := range spans {
+		var cause string
+
+		for _, attr := range s.Attributes() {
+			if attr.Key == "exec.cancel_cause" {
+				cause = attr.Value.AsString()
+			}
+		}
+
+		assert.Equal(t, context.DeadlineExceeded.Error(), cause)
+	}
+}
+
+func TestRun_CancelledBeforeStart(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := exec.RunWithContext(ctx, "echo", exec.WithArgs("hello"), exec.Pipe("cat"))
+
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWithFailFast(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	// The background process holds the pipe open, the next stage would wait for it without the option.
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "sleep 10 & exit 3"),
+		exec.WithFailFast(),
+		exec.Pipe("cat"),
+		exec.Pipe("cat"),
+	)
+
+	require.ErrorIs(t, err, exec.ExitCodeError(3))
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWithFailFast_Success(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "echo",
+		exec.WithArgs("hello"),
+		exec.WithFailFast(),
+		exec.Pipe("cat"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", out)
+}
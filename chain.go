@@ -0,0 +1,70 @@
+package exec
+
+import "context"
+
+// And runs another command after the command when it succeeds, like `&&` in a shell. The commands are chained in the
+// order of the options with Or, the error of the chain is the one of the last command that ran:
+//
+//	_, err := exec.Run("migrate",
+//		exec.And("seed"),
+//		exec.Or("rollback"),
+//	)
+//
+// runs seed if migrate succeeds, and rollback if migrate or seed fails, like `migrate && seed || rollback`.
+//
+// The chained commands are created with the context of the command when they run, they have their own span and inherit
+// the tracer, the logger and the redactor of the command. Only Run and the functions using it run the chain, after the
+// retries of the command, and not when the command can not be created. See Chained to inspect them.
+func And(name string, opts ...Option) Option {
+	return optionFunc(func(c *Cmd) {
+		c.chain = append(c.chain, chainStep{ctx: c.ctx, and: true, name: name, opts: opts})
+	})
+}
+
+// Or runs another command after the command when it fails, like `||` in a shell. The error of the command is replaced
+// by the one of the other command, nil if it succeeds. See And.
+func Or(name string, opts ...Option) Option {
+	return optionFunc(func(c *Cmd) {
+		c.chain = append(c.chain, chainStep{ctx: c.ctx, name: name, opts: opts})
+	})
+}
+
+// chainStep is a command chained with And or Or.
+type chainStep struct {
+	ctx  context.Context //nolint: containedctx
+	and  bool
+	name string
+	opts []Option
+}
+
+// Chained returns the commands chained with And or Or that have run, in order.
+func (c *Cmd) Chained() []*Cmd {
+	return c.chained
+}
+
+func (c *Cmd) runChain(err error) error {
+	if c.Err != nil {
+		return err
+	}
+
+	for _, s := range c.chain {
+		if (err == nil) != s.and {
+			continue
+		}
+
+		opts := make([]Option, 0, len(s.opts)+1)
+		opts = append(opts, optionFunc(func(next *Cmd) {
+			next.tracer = c.tracer
+			next.logger = c.logger
+			next.redact = c.redact
+		}))
+		opts = append(opts, s.opts...)
+
+		cmd := CommandContext(s.ctx, s.name, opts...)
+		c.chained = append(c.chained, cmd)
+
+		err = cmd.Run()
+	}
+
+	return err
+}
@@ -0,0 +1,119 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestAnd_Or(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		migrate       string
+		seed          string
+		expectedOut   string
+		expectedError error
+	}{
+		{
+			scenario:    "success",
+			migrate:     "exit 0",
+			seed:        "exit 0",
+			expectedOut: "migrate\nseed",
+		},
+		{
+			scenario:    "migrate fails",
+			migrate:     "exit 1",
+			seed:        "exit 0",
+			expectedOut: "migrate\nrollback",
+		},
+		{
+			scenario:    "seed fails",
+			migrate:     "exit 0",
+			seed:        "exit 2",
+			expectedOut: "migrate\nseed\nrollback",
+		},
+		{
+			scenario:      "rollback fails",
+			migrate:       "exit 1",
+			seed:          "exit 0",
+			expectedOut:   "migrate\nrollback",
+			expectedError: exec.ExitCodeError(3),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			out := newSafeBuffer()
+
+			rollback := "echo rollback"
+			if tc.expectedError != nil {
+				rollback += "; exit 3"
+			}
+
+			_, err := exec.Run("sh",
+				exec.WithArgs("-c", "echo migrate; "+tc.migrate),
+				exec.WithStdout(out),
+				exec.And("sh", exec.WithArgs("-c", "echo seed; "+tc.seed), exec.WithStdout(out)),
+				exec.Or("sh", exec.WithArgs("-c", rollback), exec.WithStdout(out)),
+			)
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expectedError)
+			}
+
+			assert.Equal(t, tc.expectedOut, getOutput(out))
+		})
+	}
+}
+
+func TestAnd_Skipped(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("false", exec.And("echo"))
+
+	require.ErrorIs(t, cmd.Run(), exec.ExitCodeError(1))
+
+	assert.Empty(t, cmd.Chained())
+}
+
+func TestOr_Chained(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	cmd := exec.Command("false",
+		exec.WithTracer(tracer),
+		exec.Or("true"),
+	)
+
+	require.NoError(t, cmd.Run())
+
+	require.Len(t, cmd.Chained(), 1)
+
+	assert.NotNil(t, cmd.Chained()[0].ProcessState)
+	assert.Len(t, recorder.Ended(), 2)
+}
+
+func TestOr_NotFound(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("not_found", exec.Or("true"))
+
+	require.ErrorIs(t, cmd.Run(), exec.ErrNotFound)
+
+	assert.Empty(t, cmd.Chained())
+}
@@ -0,0 +1,380 @@
+package exec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Checkpoint records the completed stages of a multi-stage run in a state file, so a failed run can be resumed from the
+// first incomplete stage. It implements OnceStore, the stage names are the keys.
+type Checkpoint struct {
+	mu    sync.Mutex
+	path  string
+	state checkpointState
+}
+
+type checkpointState struct {
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Completed   []string  `json:"completed"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var _ OnceStore = (*Checkpoint)(nil)
+
+// OpenCheckpoint loads the state file at the given path, the state is empty if the file does not exist.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: filepath.Clean(path)}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read checkpoint: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &c.state); err != nil {
+		return nil, fmt.Errorf("could not decode checkpoint: %w", err)
+	}
+
+	return c, nil
+}
+
+// Completed returns the names of the completed stages, in the order of completion.
+func (c *Checkpoint) Completed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.state.Completed...)
+}
+
+// IsDone reports whether the stage has completed.
+func (c *Checkpoint) IsDone(_ context.Context, stage string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.isDoneLocked(stage), nil
+}
+
+func (c *Checkpoint) isDoneLocked(stage string) bool {
+	for _, s := range c.state.Completed {
+		if s == stage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarkDone records the completion of the stage and saves the state file.
+func (c *Checkpoint) MarkDone(_ context.Context, stage string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isDoneLocked(stage) {
+		return nil
+	}
+
+	c.state.Completed = append(c.state.Completed, stage)
+	c.state.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(c.state)
+	if err != nil {
+		return fmt.Errorf("could not encode checkpoint: %w", err)
+	}
+
+	return writeFileAtomic(c.path, data)
+}
+
+func (c *Checkpoint) fingerprint() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state.Fingerprint
+}
+
+// setFingerprint records the pipeline that the completed stages belong to, it is saved with the next completed stage.
+func (c *Checkpoint) setFingerprint(fingerprint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state.Fingerprint = fingerprint
+}
+
+// Reset forgets the completed stages and removes the state file.
+func (c *Checkpoint) Reset() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = checkpointState{}
+
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// Stage is a named step of a checkpointed run.
+type Stage struct {
+	Name string
+	Spec Spec
+}
+
+// RunCheckpointed runs the stages one after another and stops at the first failure. The stages recorded as completed
+// in the checkpoint are skipped, so running the same stages again resumes a failed run. Once all the stages succeed,
+// the checkpoint is reset.
+func RunCheckpointed(ctx context.Context, cp *Checkpoint, stages ...Stage) ([]Result, error) {
+	results := make([]Result, 0, len(stages))
+
+	for _, s := range stages {
+		opts := append(s.Spec.Options[:len(s.Spec.Options):len(s.Spec.Options)], Once(s.Name, cp))
+
+		r := runSpec(ctx, Spec{Name: s.Spec.Name, Options: opts})
+
+		results = append(results, r)
+
+		if r.Err != nil {
+			return results, fmt.Errorf("stage %s: %w", s.Name, r.Err)
+		}
+	}
+
+	return results, cp.Reset()
+}
+
+// checkpointFile is the name of the state file of a pipeline run with WithCheckpointing.
+const checkpointFile = "checkpoint.json"
+
+// WithCheckpointing saves the output of every stage of the pipeline, but the last one, in the directory while the
+// pipeline runs, so Run can resume a failed run from the stage that failed instead of running the expensive stages
+// again. The stages that have completed, along with all the stages before them, are skipped and the next stage reads
+// the saved output of the last completed one:
+//
+//	cmd := exec.Command("pg_dump", exec.WithArgs("db"),
+//		exec.Pipe("gzip"),
+//		exec.Pipe("aws", "s3", "cp", "-", "s3://bucket/db.gz"),
+//		exec.WithCheckpointing("/var/lib/backup/checkpoint"),
+//	)
+//
+// Once the pipeline succeeds, the saved outputs and the state are removed. The state records the commands and the
+// arguments of the stages, a pipeline that has changed since the previous run ignores it and runs every stage. Only Run
+// resumes the pipeline, Start runs every stage.
+func WithCheckpointing(dir string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.checkpointDir = dir
+	})
+}
+
+// runCheckpointed runs the pipeline from the first stage that has not completed in a previous run. The checkpoint is
+// ignored if it was written by a different pipeline.
+func (c *Cmd) runCheckpointed() error {
+	if err := os.MkdirAll(c.checkpointDir, 0o755); err != nil { //nolint: gosec
+		return fmt.Errorf("could not create checkpoint directory: %w", err)
+	}
+
+	cp, err := OpenCheckpoint(filepath.Join(c.checkpointDir, checkpointFile))
+	if err != nil {
+		return err
+	}
+
+	stages := c.Pipeline()
+	fingerprint := pipelineFingerprint(stages)
+
+	if cp.fingerprint() != fingerprint {
+		if err := cp.Reset(); err != nil {
+			return err
+		}
+
+		cp.setFingerprint(fingerprint)
+	}
+
+	resume := 0
+
+	for resume < len(stages)-1 {
+		done, err := cp.IsDone(c.ctx, stageCheckpointKey(resume))
+		if err != nil || !done {
+			break
+		}
+
+		resume++
+	}
+
+	if resume > 0 {
+		if err := resumeStage(stages, resume, c.checkpointOutput(resume-1)); err != nil {
+			return err
+		}
+	}
+
+	for i := resume; i < len(stages)-1; i++ {
+		stages[i].addHook(c.checkpointHook(cp, i))
+	}
+
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	if err := c.Wait(); err != nil {
+		return err
+	}
+
+	for i := range stages[:len(stages)-1] {
+		_ = os.Remove(c.checkpointOutput(i)) //nolint: errcheck
+	}
+
+	return cp.Reset()
+}
+
+// pipelineFingerprint identifies the stages of the pipeline by their commands and their arguments, so a checkpoint is
+// only resumed by the pipeline that wrote it.
+func pipelineFingerprint(stages []*Cmd) string {
+	h := sha256.New()
+
+	for _, s := range stages {
+		_ = json.NewEncoder(h).Encode(append([]string{s.Path}, s.Args...)) //nolint: errcheck
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// resumeStage marks the stages before the resumed one as completed in a previous run, the resumed one reads the saved
+// output instead.
+func resumeStage(stages []*Cmd, resume int, output string) error {
+	f, err := os.Open(filepath.Clean(output))
+	if err != nil {
+		return fmt.Errorf("could not open checkpoint output: %w", err)
+	}
+
+	for _, s := range stages[:resume] {
+		s.checkpointed = true
+	}
+
+	next := stages[resume]
+
+	next.Stdin = f
+	next.stdinPipe = f
+
+	next.addHook(hook{
+		afterExit: func(_ *Cmd, err error) error {
+			_ = f.Close() //nolint: errcheck
+
+			return err
+		},
+	})
+
+	return nil
+}
+
+// startCheckpointed skips a stage that has completed in a previous run, without starting a process, and starts the next
+// one.
+func (c *Cmd) startCheckpointed(ctx context.Context, span trace.Span) error {
+	c.ctx = ctx
+	c.span = span
+
+	c.setState(StageSkipped, nil)
+
+	span.SetAttributes(attribute.Bool("exec.skipped", true))
+	span.AddEvent("skipped", trace.WithAttributes(attribute.String("exec.checkpoint", c.checkpointDir)))
+	span.End()
+
+	c.Next.ctx = c.nextContext(c.stageContext(ctx))
+	c.nextErr = c.Next.Start()
+
+	return nil
+}
+
+// waitCheckpointed waits for the stages after a skipped one.
+func (c *Cmd) waitCheckpointed() error {
+	defer close(c.done)
+
+	if c.nextErr != nil {
+		return c.nextErr
+	}
+
+	return c.pipelineError(nil, c.Next.Wait())
+}
+
+// checkpointHook saves the output of the stage and marks it as completed once it and every stage before it have
+// succeeded.
+func (c *Cmd) checkpointHook(cp *Checkpoint, i int) hook {
+	var f *os.File
+
+	return hook{
+		beforeStart: func(s *Cmd) error {
+			var err error
+
+			if f, err = os.Create(c.checkpointOutput(i)); err != nil {
+				return fmt.Errorf("could not create checkpoint output: %w", err)
+			}
+
+			s.Stdout = &checkpointWriter{next: s.Stdout, file: f}
+
+			return nil
+		},
+		afterExit: func(s *Cmd, err error) error {
+			if f == nil {
+				return err
+			}
+
+			if cErr := f.Close(); err == nil && cErr != nil {
+				return fmt.Errorf("could not save checkpoint output: %w", cErr)
+			}
+
+			if err != nil || !prevStagesSucceeded(s) {
+				return err
+			}
+
+			return cp.MarkDone(s.ctx, stageCheckpointKey(i))
+		},
+	}
+}
+
+// checkpointWriter saves the output of a stage while it goes to the next one. The whole output is saved even if the
+// next stage stops reading, so the stage does not need to run again.
+type checkpointWriter struct {
+	next   io.Writer
+	file   io.Writer
+	closed bool
+}
+
+func (w *checkpointWriter) Write(p []byte) (int, error) {
+	if !w.closed {
+		if _, err := w.next.Write(p); err != nil {
+			w.closed = true
+		}
+	}
+
+	return w.file.Write(p) //nolint: wrapcheck
+}
+
+// prevStagesSucceeded reports whether all the stages before the command have succeeded or were skipped, so its output
+// is complete.
+func prevStagesSucceeded(c *Cmd) bool {
+	for p := c.prev; p != nil; p = p.prev {
+		if s := p.State(); s != StageExited && s != StageSkipped {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Cmd) checkpointOutput(i int) string {
+	return filepath.Join(c.checkpointDir, fmt.Sprintf("stage-%d.out", i))
+}
+
+func stageCheckpointKey(i int) string {
+	return fmt.Sprintf("stage-%d", i)
+}
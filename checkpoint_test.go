@@ -0,0 +1,181 @@
+package exec_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestRunCheckpointed_Resume(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	state := filepath.Join(dir, "state.json")
+	flag := filepath.Join(dir, "fixed")
+	out := newSafeBuffer()
+
+	stages := []exec.Stage{
+		{Name: "dump", Spec: exec.Spec{Name: "echo", Options: []exec.Option{exec.WithArgs("dump"), exec.WithStdout(out)}}},
+		{Name: "transform", Spec: exec.Spec{Name: "sh", Options: []exec.Option{
+			exec.WithArgs("-c", `test -f "$0" && echo transform`, flag),
+			exec.WithStdout(out),
+		}}},
+		{Name: "upload", Spec: exec.Spec{Name: "echo", Options: []exec.Option{exec.WithArgs("upload"), exec.WithStdout(out)}}},
+	}
+
+	cp, err := exec.OpenCheckpoint(state)
+	require.NoError(t, err)
+
+	results, err := exec.RunCheckpointed(context.Background(), cp, stages...)
+
+	assert.EqualError(t, err, "stage transform: exit status 1")
+	assert.Len(t, results, 2)
+	assert.Equal(t, "dump", getOutput(out))
+
+	// Reload the state as a new process would do.
+	cp, err = exec.OpenCheckpoint(state)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"dump"}, cp.Completed())
+
+	require.NoError(t, os.WriteFile(flag, nil, 0o600))
+
+	results, err = exec.RunCheckpointed(context.Background(), cp, stages...)
+	require.NoError(t, err)
+
+	assert.True(t, results[0].Cmd.Skipped())
+	assert.False(t, results[1].Cmd.Skipped())
+	assert.Equal(t, "dump\ntransform\nupload", getOutput(out))
+	assert.Empty(t, cp.Completed())
+	assert.NoFileExists(t, state)
+}
+
+func TestOpenCheckpoint_InvalidState(t *testing.T) {
+	t.Parallel()
+
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	require.NoError(t, os.WriteFile(state, []byte("{\n"), 0o600))
+
+	_, err := exec.OpenCheckpoint(state)
+
+	assert.ErrorContains(t, err, "could not decode checkpoint")
+}
+
+func TestWithCheckpointing_Resume(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	flag := filepath.Join(dir, "fixed")
+	counter := filepath.Join(dir, "dumps")
+
+	var waited []error
+
+	newCmd := func(out *safeBuffer) *exec.Cmd {
+		return exec.Command("sh",
+			exec.WithArgs("-c", `echo run >> "$0"; printf 'a\nb\n'`, counter),
+			exec.Pipe("tr", "a-z", "A-Z"),
+			exec.Pipe("sh", "-c", `test -f "$0" && cat`, flag),
+			exec.WithStdout(out),
+			exec.WithCheckpointing(filepath.Join(dir, "checkpoint")),
+			exec.WithAfterWait(func(_ context.Context, _ *exec.Cmd, err error) {
+				waited = append(waited, err)
+			}),
+		)
+	}
+
+	out := newSafeBuffer()
+
+	require.ErrorIs(t, newCmd(out).Run(), exec.ExitCodeError(1))
+	assert.Empty(t, getOutput(out))
+
+	require.NoError(t, os.WriteFile(flag, nil, 0o600))
+
+	cmd := newCmd(out)
+
+	require.NoError(t, cmd.Run())
+
+	assert.Equal(t, "A\nB", getOutput(out))
+
+	stages := cmd.Pipeline()
+
+	assert.Equal(t, exec.StageSkipped, stages[0].State())
+	assert.Equal(t, exec.StageSkipped, stages[1].State())
+	assert.Equal(t, exec.StageExited, stages[2].State())
+
+	// The resumed run is waited like any other.
+	require.Len(t, waited, 2)
+	assert.Error(t, waited[0])
+	assert.NoError(t, waited[1])
+	assert.NoError(t, cmd.Result().Err)
+
+	// The expensive stage has only run once.
+	dumps, err := os.ReadFile(counter) //nolint: gosec
+	require.NoError(t, err)
+
+	assert.Equal(t, "run\n", string(dumps))
+	assert.NoFileExists(t, filepath.Join(dir, "checkpoint", "checkpoint.json"))
+	assert.NoFileExists(t, filepath.Join(dir, "checkpoint", "stage-0.out"))
+}
+
+func TestWithCheckpointing_PipelineChanged(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	flag := filepath.Join(dir, "fixed")
+
+	newCmd := func(out *safeBuffer, transform string) *exec.Cmd {
+		return exec.Command("printf",
+			exec.WithArgs(`a\nb\n`),
+			exec.Pipe("tr", transform, "A-Z"),
+			exec.Pipe("sh", "-c", `test -f "$0" && cat`, flag),
+			exec.WithStdout(out),
+			exec.WithCheckpointing(filepath.Join(dir, "checkpoint")),
+		)
+	}
+
+	out := newSafeBuffer()
+
+	require.ErrorIs(t, newCmd(out, "a-z").Run(), exec.ExitCodeError(1))
+
+	require.NoError(t, os.WriteFile(flag, nil, 0o600))
+
+	// The output saved by the previous pipeline is stale, every stage runs again.
+	cmd := newCmd(out, "a")
+
+	require.NoError(t, cmd.Run())
+
+	assert.Equal(t, "A\nb", getOutput(out))
+
+	for _, s := range cmd.Pipeline() {
+		assert.Equal(t, exec.StageExited, s.State())
+	}
+}
+
+func TestWithCheckpointing_UpstreamFails(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo partial; exit 2"),
+		exec.Pipe("cat"),
+		exec.Pipe("cat"),
+		exec.WithStdout(newSafeBuffer()),
+		exec.WithCheckpointing(dir),
+	)
+
+	require.ErrorIs(t, err, exec.ExitCodeError(2))
+
+	cp, err := exec.OpenCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	require.NoError(t, err)
+
+	// The output of the next stages is incomplete, they have to run again.
+	assert.Empty(t, cp.Completed())
+}
@@ -0,0 +1,51 @@
+package exec
+
+import (
+	"crypto"
+	"hash"
+	"io"
+)
+
+// outputChecksum is the digest of the standard output of a pipeline, it is shared by the first stage, which reports it,
+// and the last stage, which writes the output.
+type outputChecksum struct {
+	hash hash.Hash
+}
+
+// WithOutputChecksum computes the digest of the standard output of the command, or of the last command of the pipeline,
+// while it is written to the configured writer. The hash function must be linked into the binary, by importing its
+// package, such as crypto/sha256. The digest is available with Cmd.OutputChecksum and on the Result.
+func WithOutputChecksum(h crypto.Hash) Option {
+	return optionFunc(func(c *Cmd) {
+		if !h.Available() {
+			c.invalid("WithOutputChecksum requires an available hash function, %s is not linked", h)
+
+			return
+		}
+
+		c.checksum = &outputChecksum{hash: h.New()}
+	})
+}
+
+// OutputChecksum returns the digest of the standard output computed by WithOutputChecksum, it is nil without the
+// option. It is final once the command has been waited.
+func (c *Cmd) OutputChecksum() []byte {
+	if c.checksum == nil {
+		return nil
+	}
+
+	return c.checksum.hash.Sum(nil)
+}
+
+// wrapChecksum writes the standard output of the last stage of the pipeline through the hash.
+func (c *Cmd) wrapChecksum() {
+	if c.checksum == nil || c.Next != nil {
+		return
+	}
+
+	if c.Stdout == nil {
+		c.Stdout = c.checksum.hash
+	} else {
+		c.Stdout = io.MultiWriter(c.Stdout, c.checksum.hash)
+	}
+}
@@ -0,0 +1,67 @@
+package exec_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithOutputChecksum(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	cmd, err := exec.Run("echo",
+		exec.WithArgs("hello world"),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+		exec.WithStdout(out),
+		exec.WithOutputChecksum(crypto.SHA256),
+	)
+	require.NoError(t, err)
+
+	expected := sha256.Sum256([]byte("HELLO WORLD\n"))
+
+	assert.Equal(t, "HELLO WORLD", getOutput(out))
+	assert.Equal(t, expected[:], cmd.OutputChecksum())
+}
+
+func TestWithOutputChecksum_Result(t *testing.T) {
+	t.Parallel()
+
+	results, err := exec.Map(context.Background(), []string{"hello"}, func(input string) exec.Spec {
+		return exec.Spec{
+			Name:    "echo",
+			Options: []exec.Option{exec.WithArgs("-n", input), exec.WithOutputChecksum(crypto.SHA256)},
+		}
+	}, 1)
+	require.NoError(t, err)
+
+	expected := sha256.Sum256([]byte("hello"))
+
+	assert.Equal(t, hex.EncodeToString(expected[:]), hex.EncodeToString(results[0].Checksum))
+}
+
+func TestWithOutputChecksum_NotAvailable(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("echo", exec.WithOutputChecksum(crypto.MD4))
+
+	require.ErrorIs(t, cmd.Err, exec.ErrOptionConflict)
+	assert.EqualError(t, cmd.Err, "exec: conflicting options: WithOutputChecksum requires an available hash function, MD4 is not linked")
+}
+
+func TestCmd_OutputChecksum_NoOption(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("true")
+	require.NoError(t, err)
+
+	assert.Nil(t, cmd.OutputChecksum())
+}
@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultCopyBufferSize = 32 * 1024
+
+var (
+	copyBufferSize atomic.Int64
+	copyBuffers    sync.Pool
+)
+
+func init() { //nolint: gochecknoinits
+	copyBufferSize.Store(defaultCopyBufferSize)
+}
+
+// SetCopyBufferSize sets the size of the buffers used to copy the streams of the commands, 32 KiB by default. The
+// buffers are shared by all the commands, so the streams do not allocate a buffer per copy.
+func SetCopyBufferSize(n int) {
+	if n <= 0 {
+		n = defaultCopyBufferSize
+	}
+
+	copyBufferSize.Store(int64(n))
+}
+
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	size := int(copyBufferSize.Load())
+
+	buf, ok := copyBuffers.Get().(*[]byte)
+	if !ok || len(*buf) != size {
+		b := make([]byte, size)
+		buf = &b
+	}
+
+	defer copyBuffers.Put(buf)
+
+	// The copy does not use the WriterTo and ReaderFrom methods of src and dst, unlike io.CopyBuffer.
+	var written int64
+
+	for {
+		nr, rErr := src.Read(*buf)
+
+		if nr > 0 {
+			nw, wErr := dst.Write((*buf)[:nr])
+			written += int64(nw)
+
+			if wErr != nil {
+				return written, wErr //nolint: wrapcheck
+			}
+
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+
+		if rErr == io.EOF { //nolint: errorlint
+			return written, nil
+		}
+
+		if rErr != nil {
+			return written, rErr //nolint: wrapcheck
+		}
+	}
+}
+
+// pooledWriter copies from the readers with a buffer of the pool. It also hides the ReadFrom method of the writer, so
+// the stages of a pipeline that share a writer do not touch it when they write nothing.
+type pooledWriter struct {
+	io.Writer
+}
+
+func (w pooledWriter) ReadFrom(r io.Reader) (int64, error) {
+	return copyBuffer(w.Writer, r)
+}
+
+// pooledReader copies to the writers with a buffer of the pool.
+type pooledReader struct {
+	io.Reader
+}
+
+func (r pooledReader) WriteTo(w io.Writer) (int64, error) {
+	return copyBuffer(w, r.Reader)
+}
+
+// poolCopies makes os/exec copy the streams of the command with the buffers of the pool. The files are used by the
+// process directly, and the streams that copy without a buffer are left as is.
+func (c *Cmd) poolCopies() {
+	inPipeline := c.prev != nil || c.Next != nil
+
+	c.Cmd.Stdout = poolWriter(c.Cmd.Stdout, inPipeline)
+	c.Cmd.Stderr = poolWriter(c.Cmd.Stderr, inPipeline)
+
+	switch c.Cmd.Stdin.(type) {
+	case nil, *os.File, io.WriterTo:
+	default:
+		c.Cmd.Stdin = pooledReader{c.Cmd.Stdin}
+	}
+}
+
+func poolWriter(w io.Writer, shared bool) io.Writer {
+	switch w.(type) {
+	case nil, *os.File, pooledWriter:
+		return w
+
+	case io.ReaderFrom:
+		if !shared {
+			return w
+		}
+	}
+
+	return pooledWriter{w}
+}
@@ -0,0 +1,50 @@
+package exec_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestSetCopyBufferSize(t *testing.T) { //nolint: paralleltest
+	exec.SetCopyBufferSize(7)
+
+	defer exec.SetCopyBufferSize(0)
+
+	input := strings.Repeat("hello world\n", 1000)
+	stdout := new(bytes.Buffer)
+	stderr := newSafeBuffer()
+
+	_, err := exec.Run("cat",
+		exec.WithStdin(iotest.HalfReader(strings.NewReader(input))),
+		exec.Pipe("tee", "/dev/stderr"),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+		exec.WithStdout(stdout),
+		exec.WithStderr(stderr),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, strings.ToUpper(input), stdout.String())
+	assert.Equal(t, input, stderr.String())
+}
+
+func BenchmarkRun_Pipe(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, err := exec.Run("head", exec.WithArgs("-c", "1048576", "/dev/zero"),
+			exec.Pipe("cat"),
+			exec.WithStdout(io.MultiWriter(io.Discard)),
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
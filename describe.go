@@ -0,0 +1,113 @@
+package exec
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CommandDescription is a structured and redacted view of a command and its pipeline, to explain what would be run,
+// such as for a --explain flag or an approval workflow. The values of the environment are never described.
+type CommandDescription struct {
+	// Command is the redacted command line of the pipeline, see Cmd.String.
+	Command string `json:"command"`
+	// Stages are the stages of the pipeline, from the first one to the last one.
+	Stages []StageDescription `json:"stages"`
+}
+
+// StageDescription describes a stage of a pipeline.
+type StageDescription struct {
+	// Name is the name of the command, as given to Command.
+	Name string `json:"name"`
+	// Stage is the name of the stage set by WithStageName, empty if the stage is not named.
+	Stage string `json:"stage,omitempty"`
+	// Path is the resolved path of the executable, the client of the backend if any.
+	Path string `json:"path"`
+	// Args are the redacted arguments, without the name of the command.
+	Args []string `json:"args"`
+	// Dir is the working directory, empty for the one of the current process.
+	Dir string `json:"dir,omitempty"`
+	// EnvKeys are the sorted names of the environment variables of the command.
+	EnvKeys []string `json:"env_keys"`
+	// Backend is the name of the client that runs the command on a backend, empty if it runs locally.
+	Backend string `json:"backend,omitempty"`
+	// Timeout is the timeout of the command, 0 if there is none.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Budget is the remaining budget shared by the pipeline, 0 if there is none.
+	Budget time.Duration `json:"budget,omitempty"`
+	// SuccessExitCodes are the exit codes considered as a success when set by WithSuccessExitCodes.
+	SuccessExitCodes []int `json:"success_exit_codes,omitempty"`
+	// State is the lifecycle state of the stage.
+	State string `json:"state"`
+	// Error is the redacted error of the command, when it can not be run.
+	Error string `json:"error,omitempty"`
+}
+
+// Describe returns a structured and redacted description of the pipeline of the command, whichever stage it is called
+// on. The arguments of every stage are redacted by the redactors of all the stages, like String does.
+func (c *Cmd) Describe() CommandDescription {
+	stages := c.Pipeline()
+	d := CommandDescription{
+		Command: stages[0].String(),
+		Stages:  make([]StageDescription, 0, len(stages)),
+	}
+
+	redact := stages[0].redact
+
+	for i, s := range stages {
+		if i > 0 {
+			prev, stage := redact, s
+
+			redact = func(args ...string) []string {
+				return prev(stage.redact(args...)...)
+			}
+		}
+
+		d.Stages = append(d.Stages, s.describeStage(redact))
+	}
+
+	return d
+}
+
+func (c *Cmd) describeStage(redact argsRedactor) StageDescription {
+	args := redact(c.Args...)
+
+	d := StageDescription{
+		Name:             c.name,
+		Stage:            c.stageName,
+		Path:             c.Path,
+		Args:             args[1:],
+		Dir:              c.Dir,
+		EnvKeys:          c.envKeys(),
+		Timeout:          c.timeout,
+		SuccessExitCodes: c.successCodes,
+		State:            c.State().String(),
+	}
+
+	if c.backend != nil {
+		d.Backend = filepath.Base(args[0])
+	}
+
+	if c.budget != nil {
+		d.Budget = c.budget.Remaining()
+	}
+
+	if c.Err != nil {
+		d.Error = c.redactString(c.Err.Error())
+	}
+
+	return d
+}
+
+func (c *Cmd) envKeys() []string {
+	env := c.EnvMap()
+	keys := make([]string, 0, len(env))
+
+	for key := range env {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,78 @@
+package exec_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_Describe(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("echo",
+		exec.WithArgs("--token", "secret"),
+		exec.RedactArgs("secret"),
+		exec.WithEnv("SECRET_TOKEN", "secret"),
+		exec.WithTimeout(time.Minute),
+		exec.WithSuccessExitCodes(0, 1),
+		exec.Pipe("grep", "secret"),
+	)
+
+	d := cmd.Next.Describe()
+
+	assert.Equal(t, cmd.String(), d.Command)
+	require.Len(t, d.Stages, 2)
+
+	head, next := d.Stages[0], d.Stages[1]
+
+	assert.Equal(t, "echo", head.Name)
+	assert.Equal(t, cmd.Path, head.Path)
+	assert.Equal(t, []string{"--token", "******"}, head.Args)
+	assert.Contains(t, head.EnvKeys, "SECRET_TOKEN")
+	assert.Equal(t, time.Minute, head.Timeout)
+	assert.Equal(t, []int{0, 1}, head.SuccessExitCodes)
+	assert.Equal(t, "pending", head.State)
+	assert.Empty(t, head.Backend)
+
+	assert.Equal(t, "grep", next.Name)
+	assert.Equal(t, []string{"******"}, next.Args)
+	assert.Contains(t, next.EnvKeys, "SECRET_TOKEN")
+	assert.Equal(t, time.Minute, next.Timeout)
+
+	out, err := json.Marshal(d)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(out), "secret\"")
+	assert.Contains(t, string(out), `"SECRET_TOKEN"`)
+}
+
+func TestCmd_Describe_Backend(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("uptime", exec.WithBackend(exec.SSH("example.com")), exec.WithBudget(time.Hour))
+
+	d := cmd.Describe()
+	require.Len(t, d.Stages, 1)
+
+	assert.Equal(t, "uptime", d.Stages[0].Name)
+	assert.Equal(t, "ssh", d.Stages[0].Backend)
+	assert.Equal(t, []string{"example.com", "--", "uptime"}, d.Stages[0].Args)
+	assert.InDelta(t, float64(time.Hour), float64(d.Stages[0].Budget), float64(time.Second))
+}
+
+func TestCmd_Describe_Error(t *testing.T) {
+	t.Parallel()
+
+	d := exec.Command("not_found").Describe()
+
+	require.Len(t, d.Stages, 1)
+
+	assert.Equal(t, "not_found", filepath.Base(d.Stages[0].Path))
+	assert.Contains(t, d.Stages[0].Error, "executable file not found")
+}
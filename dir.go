@@ -0,0 +1,94 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WithDirCreate sets the working directory of the command and creates it with its parents before the command starts,
+// if it does not exist. The directories are created with perm, before umask.
+//
+// See RemoveDirOnFailure to remove the created directories when the command fails.
+func WithDirCreate(path string, perm os.FileMode) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("working directory", "WithDirCreate")
+
+		c.Dir = path
+		c.dirCreate = &dirCreate{path: path, perm: perm}
+
+		c.addHook(hook{
+			beforeStart: func(c *Cmd) error {
+				return c.dirCreate.create()
+			},
+			afterExit: func(c *Cmd, err error) error {
+				if err == nil || !c.removeDirOnFailure {
+					return err
+				}
+
+				return c.dirCreate.remove(err)
+			},
+		})
+	})
+}
+
+// RemoveDirOnFailure removes the working directory created by WithDirCreate when the command fails. Only the
+// directories that did not exist before the command are removed, with all their content.
+func RemoveDirOnFailure() Option {
+	return optionFunc(func(c *Cmd) {
+		c.removeDirOnFailure = true
+	})
+}
+
+type dirCreate struct {
+	path string
+	perm os.FileMode
+
+	// created is the top-most directory that has been created.
+	created string
+}
+
+func (d *dirCreate) create() error {
+	d.created = ""
+
+	missing := ""
+
+	for dir := filepath.Clean(d.path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not create working directory: %w", err)
+		}
+
+		missing = dir
+
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	if missing == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(d.path, d.perm); err != nil {
+		return fmt.Errorf("could not create working directory: %w", err)
+	}
+
+	d.created = missing
+
+	return nil
+}
+
+func (d *dirCreate) remove(err error) error {
+	if d.created == "" {
+		return err
+	}
+
+	if rmErr := os.RemoveAll(d.created); rmErr != nil {
+		return MultiError{err, fmt.Errorf("could not remove working directory: %w", rmErr)}
+	}
+
+	return err
+}
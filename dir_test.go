@@ -0,0 +1,81 @@
+package exec_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithDirCreate(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "build", "output")
+
+	_, err := exec.Run("touch", exec.WithArgs("artifact"), exec.WithDirCreate(dir, 0o755))
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(dir, "artifact"))
+
+	// The directory exists now.
+	_, err = exec.Run("touch", exec.WithArgs("another"), exec.WithDirCreate(dir, 0o755))
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(dir, "another"))
+}
+
+func TestWithDirCreate_RemoveDirOnFailure(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "build", "output")
+
+	_, err := exec.Run("sh", exec.WithArgs("-c", "touch artifact; exit 1"),
+		exec.RemoveDirOnFailure(),
+		exec.WithDirCreate(dir, 0o755),
+	)
+
+	assert.ErrorIs(t, err, exec.ExitCodeError(1))
+	assert.NoDirExists(t, filepath.Join(root, "build"))
+	assert.DirExists(t, root)
+}
+
+func TestWithDirCreate_RemoveDirOnFailure_ExistingDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keep"), nil, 0o600))
+
+	_, err := exec.Run("sh", exec.WithArgs("-c", "exit 1"),
+		exec.WithDirCreate(dir, 0o755),
+		exec.RemoveDirOnFailure(),
+	)
+
+	assert.Error(t, err)
+	assert.FileExists(t, filepath.Join(dir, "keep"))
+}
+
+func TestWithDirCreate_Error(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "file")
+
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	_, err := exec.Run("true", exec.WithDirCreate(filepath.Join(file, "dir"), 0o755))
+
+	assert.ErrorContains(t, err, "could not create working directory:")
+}
+
+func TestRemoveDirOnFailure_WithoutDirCreate(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("true", exec.RemoveDirOnFailure())
+
+	assert.EqualError(t, cmd.Err, "exec: conflicting options: RemoveDirOnFailure requires WithDirCreate")
+}
@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// WithDryRun resolves the command and builds its environment and its pipeline but never spawns the processes. Start
+// logs the command that would be executed, at the info level, and ends a span with the exec.dry_run attribute for
+// every stage. The stages are in the StageSkipped state and Wait returns nil, unless a stage can not be run, for
+// example when it is not found.
+//
+// The hooks, the notifiers and the result store are not called.
+func WithDryRun() Option {
+	return optionFunc(func(c *Cmd) {
+		c.dryRun = true
+	})
+}
+
+// startDryRun reports what the command would execute, then does the same for the next stage.
+func (c *Cmd) startDryRun() error {
+	c.span.SetAttributes(attribute.Bool("exec.dry_run", true))
+
+	if err := c.Err; err != nil {
+		c.setState(StageFailed, err)
+
+		c.span.RecordError(err)
+		c.span.SetStatus(codes.Error, err.Error())
+		c.span.End()
+
+		return err
+	}
+
+	c.skipped = true
+	c.setState(StageSkipped, nil)
+
+	c.logger.Info(c.ctx, fmt.Sprintf("would execute `%s`", filepath.Base(c.Path)), c.logFields(
+		"exec.command", c.describe(c.redact),
+		"exec.dir", c.Dir,
+	)...)
+
+	c.span.End()
+
+	if c.Next != nil {
+		return c.Next.Start()
+	}
+
+	return nil
+}
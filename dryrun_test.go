@@ -0,0 +1,96 @@
+package exec_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bool64/ctxd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestRun_DryRun(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+	logger := &ctxd.LoggerMock{}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	created := filepath.Join(dir, "created")
+
+	cmd, err := exec.Run("touch",
+		exec.WithArgs(file),
+		exec.WithDirCreate(created, 0o755),
+		exec.Pipe("cat"),
+		exec.WithDryRun(),
+		exec.WithTracer(tracer),
+		exec.WithLogger(logger),
+	)
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, file)
+	assert.NoDirExists(t, created)
+
+	assert.Nil(t, cmd.Process)
+	assert.Equal(t, exec.StageSkipped, cmd.State())
+	assert.Equal(t, exec.StageSkipped, cmd.Next.State())
+
+	assert.Contains(t, logger.String(), "would execute `touch`")
+	assert.Contains(t, logger.String(), "would execute `cat`")
+
+	spans := stageSpans(recorder.Ended())
+	require.Len(t, spans, 2)
+
+	for _, s := range spans {
+		var dryRun bool
+
+		for _, attr := range s.Attributes() {
+			if attr.Key == "exec.dry_run" {
+				dryRun = attr.Value.AsBool()
+			}
+		}
+
+		assert.True(t, dryRun, s.Name())
+	}
+}
+
+func TestRun_DryRun_NotFound(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("random-name-that-does-not-exist", exec.WithDryRun())
+	err := cmd.Start()
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.Nil(t, cmd.Process)
+	assert.Equal(t, exec.StageFailed, cmd.State())
+}
+
+func TestRun_DryRun_DoesNotWrite(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "out")
+
+	f, err := os.Create(filepath.Clean(out))
+	require.NoError(t, err)
+
+	defer f.Close() //nolint: errcheck
+
+	_, err = exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithStdout(f),
+		exec.WithDryRun(),
+	)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Clean(out))
+	require.NoError(t, err)
+
+	assert.Empty(t, content)
+}
@@ -0,0 +1,203 @@
+package exec
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// EnvMap returns the environment of the command as a map. When a variable is set several times, the last value wins
+// like it does for the process.
+func (c *Cmd) EnvMap() map[string]string {
+	environ := c.environ()
+	env := make(map[string]string, len(environ))
+
+	for _, kv := range environ {
+		if key, value, ok := splitEnv(kv); ok {
+			env[key] = value
+		}
+	}
+
+	return env
+}
+
+// SetEnvMap replaces the environment of the command with the variables of the map, sorted by name.
+func (c *Cmd) SetEnvMap(env map[string]string) {
+	keys := make([]string, 0, len(env))
+
+	for key := range env {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	c.Env = make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		c.Env = append(c.Env, key+"="+env[key])
+	}
+}
+
+// splitEnv splits a "KEY=VALUE" entry. The key may start with "=", like the per-drive directories on Windows.
+func splitEnv(kv string) (string, string, bool) {
+	if kv == "" {
+		return "", "", false
+	}
+
+	i := strings.IndexByte(kv[1:], '=')
+	if i < 0 {
+		return "", "", false
+	}
+
+	return kv[:i+1], kv[i+2:], true
+}
+
+// environ returns the environment of the command, the base environment if it is not set.
+func (c *Cmd) environ() []string {
+	if c.Env == nil {
+		return baseEnv()
+	}
+
+	return c.Env
+}
+
+// setEnv adds the variables to the environment of the command, it starts from the base environment if it is not set
+// yet.
+func (c *Cmd) setEnv(kv ...string) {
+	if c.Env == nil {
+		c.Env = baseEnv()
+	}
+
+	c.Env = append(c.Env, kv...)
+}
+
+// WithCleanEnv starts the command and the stages of its pipeline with an empty environment instead of the one of the
+// current process, for sandboxed or reproducible runs. The executable is still looked up in the PATH of the current
+// process, but the command does not get it. The variables set by the previous options are discarded, the options that
+// set variables must come after it. The trace id and the span id are still set, see WithoutTraceEnv.
+func WithCleanEnv() Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("base environment", "WithCleanEnv")
+
+		c.Env = []string{}
+	})
+}
+
+// WithInheritEnv starts the command and the stages of its pipeline with only the given variables of the environment of
+// the current process, the ones that are not set are skipped. See WithCleanEnv.
+//
+//	exec.Command("make", exec.WithInheritEnv("PATH", "HOME"), exec.WithEnv("CI", "1"))
+func WithInheritEnv(keys ...string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("base environment", "WithInheritEnv")
+
+		c.Env = []string{}
+
+		for _, kv := range baseEnv() {
+			if key, _, ok := splitEnv(kv); ok && containsString(keys, key) {
+				c.Env = append(c.Env, kv)
+			}
+		}
+	})
+}
+
+// WithExpandEnv expands the $VAR and ${VAR} references in the arguments of the command and of the stages of its
+// pipeline with their own environment, like a shell would, when they start. The variables that are not set expand to
+// an empty string, and $$ expands to $. The name of the command is not expanded.
+//
+// The span of the command records the arguments before the expansion.
+func WithExpandEnv() Option {
+	return optionFunc(func(c *Cmd) {
+		c.expandEnv = true
+	})
+}
+
+func (c *Cmd) expandArgs() {
+	if !c.expandEnv {
+		return
+	}
+
+	env := c.EnvMap()
+	expand := func(key string) string {
+		if key == "$" {
+			return "$"
+		}
+
+		return env[key]
+	}
+
+	for i := 1; i < len(c.Args); i++ {
+		c.Args[i] = os.Expand(c.Args[i], expand)
+	}
+}
+
+var envSnapshot atomic.Pointer[[]string]
+
+// SetEnvSnapshot makes the commands start from a snapshot of the environment of the current process instead of reading
+// it for every command, which saves copying it when many commands are run. The snapshot is taken when it is enabled
+// and shared by the commands, a command that changes its environment gets its own copy.
+//
+// The changes made to the environment of the current process, such as os.Setenv, are not seen by the commands until
+// RefreshEnvSnapshot is called.
+func SetEnvSnapshot(enabled bool) {
+	if !enabled {
+		envSnapshot.Store(nil)
+
+		return
+	}
+
+	env := os.Environ()
+
+	envSnapshot.Store(&env)
+}
+
+// RefreshEnvSnapshot takes a new snapshot of the environment of the current process, for the commands created from now
+// on. It is a no-op if SetEnvSnapshot is not enabled.
+func RefreshEnvSnapshot() {
+	env := os.Environ()
+
+	for {
+		old := envSnapshot.Load()
+		if old == nil || envSnapshot.CompareAndSwap(old, &env) {
+			return
+		}
+	}
+}
+
+// baseEnv returns the environment the commands start from. The snapshot is returned with its capacity capped, so
+// appending to it copies it.
+func baseEnv() []string {
+	if env := envSnapshot.Load(); env != nil {
+		return (*env)[:len(*env):len(*env)]
+	}
+
+	return os.Environ()
+}
+
+// unsetEnv removes the variables from the environment of the command, it starts from the base environment if it is not
+// set yet. The environment is copied, so a shared snapshot is left untouched.
+func (c *Cmd) unsetEnv(keys ...string) {
+	environ := c.environ()
+	env := make([]string, 0, len(environ))
+
+	for _, kv := range environ {
+		if key, _, ok := splitEnv(kv); ok && containsString(keys, key) {
+			continue
+		}
+
+		env = append(env, kv)
+	}
+
+	c.Env = env
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
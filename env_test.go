@@ -0,0 +1,157 @@
+package exec_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_EnvMap(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("env", exec.WithEnv("FOO", "bar"), exec.WithEnv("FOO", "baz=qux"))
+	cmd.Env = append(cmd.Env, "INVALID", "=C:=C:\\", "EMPTY=")
+
+	env := cmd.EnvMap()
+
+	assert.Equal(t, "baz=qux", env["FOO"])
+	assert.Equal(t, "C:\\", env["=C:"])
+	assert.Equal(t, "", env["EMPTY"])
+	assert.NotContains(t, env, "INVALID")
+}
+
+func TestCmd_SetEnvMap(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	cmd := exec.Command("env", exec.WithEnv("FOO", "bar"), exec.WithStdout(out))
+
+	cmd.SetEnvMap(map[string]string{"FOO": "baz", "HELLO": "world"})
+
+	assert.Equal(t, []string{"FOO=baz", "HELLO=world"}, cmd.Env)
+
+	require.NoError(t, cmd.Run())
+
+	assert.Contains(t, getOutput(out), "FOO=baz")
+	assert.Contains(t, getOutput(out), "HELLO=world")
+}
+
+func TestSetEnvSnapshot(t *testing.T) { //nolint: paralleltest
+	t.Setenv("EXEC_SNAPSHOT", "before")
+
+	exec.SetEnvSnapshot(true)
+
+	defer exec.SetEnvSnapshot(false)
+
+	t.Setenv("EXEC_SNAPSHOT", "after")
+
+	run := func(opts ...exec.Option) string {
+		t.Helper()
+
+		out, err := exec.RunOutput(context.Background(), "sh", append(opts, exec.WithArgs("-c", `echo "$EXEC_SNAPSHOT $EXTRA"`))...)
+		require.NoError(t, err)
+
+		return out
+	}
+
+	assert.Equal(t, "before", run())
+	assert.Equal(t, "before extra", run(exec.WithEnv("EXTRA", "extra")))
+
+	// A command that changes its environment does not change the snapshot.
+	assert.Equal(t, "before", run())
+
+	exec.RefreshEnvSnapshot()
+
+	assert.Equal(t, "after", run())
+
+	exec.SetEnvSnapshot(false)
+	t.Setenv("EXEC_SNAPSHOT", "live")
+
+	assert.Equal(t, "live", run())
+}
+
+func TestWithCleanEnv(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "env",
+		exec.WithCleanEnv(),
+		exec.WithoutTraceEnv(),
+		exec.WithEnv("FOO", "bar"),
+		exec.Pipe("sort"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "FOO=bar", out)
+}
+
+func TestWithInheritEnv(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "env",
+		exec.WithInheritEnv("PATH", "EXEC_DOES_NOT_EXIST"),
+		exec.WithoutTraceEnv(),
+		exec.WithEnv("FOO", "bar"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "PATH="+os.Getenv("PATH")+"\nFOO=bar", out)
+}
+
+func TestWithInheritEnv_Conflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("env", exec.WithCleanEnv(), exec.WithInheritEnv("PATH"))
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+	assert.ErrorContains(t, err, "WithCleanEnv and WithInheritEnv both set the base environment")
+}
+
+func TestWithExpandEnv(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "echo",
+		exec.WithArgs("$GREETING, ${NAME}!", "$UNSET_EXEC_VARIABLE", "$$5"),
+		exec.WithExpandEnv(),
+		exec.WithEnv("GREETING", "hello"),
+		exec.WithEnv("NAME", "world"),
+		exec.Pipe("sed", "s/$NAME/you/"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello, you!  $5", out)
+}
+
+func TestWithExpandEnv_Disabled(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "echo",
+		exec.WithArgs("$GREETING"),
+		exec.WithEnv("GREETING", "hello"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "$GREETING", out)
+}
+
+func TestCommand_Env(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("env")
+
+	// Like os/exec, a nil environment is the one of the current process, it is only copied when the command starts.
+	assert.Nil(t, cmd.Env)
+
+	cmd.Env = append(os.Environ(), "EXEC_TEST_ENV=appended")
+
+	out := newSafeBuffer()
+	cmd.Stdout = out
+
+	require.NoError(t, cmd.Run())
+
+	assert.Contains(t, getOutput(out), "EXEC_TEST_ENV=appended")
+}
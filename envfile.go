@@ -0,0 +1,183 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrInvalidEnvFile indicates that an env file can not be parsed.
+var ErrInvalidEnvFile = errors.New("exec: invalid env file")
+
+// WithEnvFile appends the variables of a dotenv file to the environment of the command and of the stages of its
+// pipeline. The file is read when the option is applied, the command fails to start if it can not be read or parsed.
+//
+// Every line is a KEY=VALUE assignment, optionally prefixed by export, and the empty lines and the lines starting with
+// # are ignored. An unquoted value is trimmed and ends at a # preceded by a space. A value in single quotes is kept as
+// is, a value in double quotes may span several lines and supports the \n, \r, \t, \", \\ and \$ escapes. The values
+// are not expanded.
+func WithEnvFile(path string) Option {
+	return optionFunc(func(c *Cmd) {
+		env, err := readEnvFile(path)
+		if err != nil {
+			c.invalidErr(err)
+
+			return
+		}
+
+		c.setEnv(env...)
+	})
+}
+
+func readEnvFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path) //nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("could not read env file: %w", err)
+	}
+
+	env, err := parseEnvFile(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %s", ErrInvalidEnvFile, path, err.Error())
+	}
+
+	return env, nil
+}
+
+// parseEnvFile parses the content of a dotenv file into KEY=VALUE entries, in order.
+func parseEnvFile(data string) ([]string, error) {
+	var env []string
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		lineNo := i + 1
+
+		if rest := strings.TrimPrefix(line, "export"); rest != line && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimSpace(rest)
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if key = strings.TrimSpace(key); !ok || !isEnvKey(key) {
+			return nil, fmt.Errorf("line %d: invalid assignment", lineNo) //nolint: goerr113
+		}
+
+		value, next, err := parseEnvValue(strings.TrimSpace(value), lines, i)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		i = next
+		env = append(env, key+"="+value)
+	}
+
+	return env, nil
+}
+
+// parseEnvValue parses the value that starts on the line i, and returns the index of the line it ends on.
+func parseEnvValue(value string, lines []string, i int) (string, int, error) {
+	if value == "" {
+		return "", i, nil
+	}
+
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", i, errors.New("unterminated single quote") //nolint: goerr113
+		}
+
+		return value[1 : end+1], i, checkEnvTrailer(value[end+2:])
+
+	case '"':
+		return parseDoubleQuotedEnvValue(value[1:], lines, i)
+	}
+
+	if j := strings.Index(value, " #"); j >= 0 {
+		value = value[:j]
+	}
+
+	if j := strings.Index(value, "\t#"); j >= 0 {
+		value = value[:j]
+	}
+
+	return strings.TrimSpace(value), i, nil
+}
+
+func parseDoubleQuotedEnvValue(value string, lines []string, i int) (string, int, error) {
+	var b strings.Builder
+
+	for {
+		for j := 0; j < len(value); j++ {
+			switch ch := value[j]; ch {
+			case '"':
+				return b.String(), i, checkEnvTrailer(value[j+1:])
+
+			case '\\':
+				if j+1 == len(value) {
+					b.WriteByte(ch)
+
+					continue
+				}
+
+				j++
+
+				switch value[j] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				case '"', '\\', '$':
+					b.WriteByte(value[j])
+				default:
+					b.WriteByte('\\')
+					b.WriteByte(value[j])
+				}
+
+			default:
+				b.WriteByte(ch)
+			}
+		}
+
+		if i++; i >= len(lines) {
+			return "", i, errors.New("unterminated double quote") //nolint: goerr113
+		}
+
+		b.WriteByte('\n')
+
+		value = lines[i]
+	}
+}
+
+// checkEnvTrailer only allows a comment after a quoted value.
+func checkEnvTrailer(s string) error {
+	if s = strings.TrimSpace(s); s != "" && s[0] != '#' {
+		return errors.New("unexpected characters after the closing quote") //nolint: goerr113
+	}
+
+	return nil
+}
+
+func isEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '.'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,157 @@
+package exec_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestWithEnvFile(t *testing.T) {
+	t.Parallel()
+
+	path := writeEnvFile(t, `# comment
+PLAIN=value
+export EXPORTED = spaced value # comment
+SINGLE='it is $literal \n'
+DOUBLE="line\tone\n\"two\" \$HOME"
+MULTI="first
+second" # comment
+EMPTY=
+HASH=a#b
+`)
+
+	cmd := exec.Command("env", exec.WithCleanEnv(), exec.WithEnvFile(path))
+	require.NoError(t, cmd.Err)
+
+	assert.Equal(t, []string{
+		"PLAIN=value",
+		"EXPORTED=spaced value",
+		`SINGLE=it is $literal \n`,
+		"DOUBLE=line\tone\n\"two\" $HOME",
+		"MULTI=first\nsecond",
+		"EMPTY=",
+		"HASH=a#b",
+	}, cmd.Env)
+}
+
+func TestWithEnvFile_Pipe(t *testing.T) {
+	t.Parallel()
+
+	path := writeEnvFile(t, "GREETING=hello\n")
+
+	out, err := exec.RunOutput(context.Background(), "sh",
+		exec.WithArgs("-c", "echo $GREETING"),
+		exec.Pipe("sh", "-c", "cat; echo $GREETING"),
+		exec.WithEnvFile(path),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello\nhello", out)
+}
+
+func TestWithEnvFile_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		content       string
+		expectedError string
+	}{
+		{
+			scenario:      "invalid key",
+			content:       "\n1KEY=value\n",
+			expectedError: "line 2: invalid assignment",
+		},
+		{
+			scenario:      "missing assignment",
+			content:       "KEY\n",
+			expectedError: "line 1: invalid assignment",
+		},
+		{
+			scenario:      "unterminated single quote",
+			content:       "KEY='value\n",
+			expectedError: "line 1: unterminated single quote",
+		},
+		{
+			scenario:      "unterminated double quote",
+			content:       "KEY=\"value\nOTHER=1\n",
+			expectedError: "line 1: unterminated double quote",
+		},
+		{
+			scenario:      "trailing characters",
+			content:       "KEY='value' other\n",
+			expectedError: "line 1: unexpected characters after the closing quote",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeEnvFile(t, tc.content)
+
+			_, err := exec.Run("env", exec.WithEnvFile(path))
+
+			require.ErrorIs(t, err, exec.ErrInvalidEnvFile)
+			assert.EqualError(t, err, "exec: invalid env file "+path+": "+tc.expectedError)
+		})
+	}
+}
+
+func TestWithEnvFile_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("env", exec.WithEnvFile(filepath.Join(t.TempDir(), ".env")))
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWithEnvFile_NotFound_WithBackend(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	testCases := []struct {
+		scenario string
+		options  []exec.Option
+	}{
+		{
+			scenario: "command",
+			options:  []exec.Option{exec.WithEnvFile(path), exec.WithBackend(fakeRemote)},
+		},
+		{
+			scenario: "stage",
+			options:  []exec.Option{exec.PipeWith("cat", exec.WithEnvFile(path), exec.WithBackend(fakeRemote))},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := exec.Run("env", tc.options...)
+
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// MultiError is a list of errors that occurred during a batch of executions.
+type MultiError []error
+
+// Error returns the error messages joined by a new line.
+func (e MultiError) Error() string {
+	msgs := make([]string, 0, len(e))
+
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors.
+func (e MultiError) Unwrap() []error {
+	return e
+}
+
+// Is reports whether any of the errors matches the target.
+func (e MultiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error that matches the target.
+func (e MultiError) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e MultiError) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// ExitCodeError is the error of a command that exited with the given code. It is meant to be matched with errors.Is:
+//
+//	if errors.Is(err, exec.ExitCodeError(2)) {
+//		// ...
+//	}
+type ExitCodeError int
+
+// Error returns the same message as os/exec.ExitError.
+func (e ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// ExitCode returns the exit code carried by the error, and whether there is one. A process that has been terminated by
+// a signal has no exit code.
+func ExitCode(err error) (int, bool) {
+	var exitErr *exec.ExitError
+
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+
+		return code, code >= 0
+	}
+
+	var codeErr ExitCodeError
+
+	if errors.As(err, &codeErr) {
+		return int(codeErr), true
+	}
+
+	return -1, false
+}
+
+// exitError is an *exec.ExitError that matches the ExitCodeError of its code.
+type exitError struct {
+	*exec.ExitError
+}
+
+func (e exitError) Unwrap() error {
+	return e.ExitError
+}
+
+func (e exitError) Is(target error) bool {
+	code, ok := target.(ExitCodeError)
+
+	return ok && int(code) == e.ExitCode()
+}
+
+func wrapExitError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok { //nolint: errorlint
+		return exitError{exitErr}
+	}
+
+	return err
+}
@@ -0,0 +1,85 @@
+package exec_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.nhat.io/exec"
+)
+
+func TestExitCodeError(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("sh", exec.WithArgs("-c", "exit 2"))
+
+	assert.EqualError(t, err, "exit status 2")
+	assert.ErrorIs(t, err, exec.ExitCodeError(2))
+	assert.NotErrorIs(t, err, exec.ExitCodeError(1))
+
+	code, ok := exec.ExitCode(err)
+
+	assert.True(t, ok)
+	assert.Equal(t, 2, code)
+}
+
+func TestExitCodeError_Pipe(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.Pipe("sh", "-c", "exit 3"), exec.WithErrorStderr(10))
+
+	assert.ErrorIs(t, err, exec.ExitCodeError(3))
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario     string
+		err          error
+		expectedCode int
+		expectedOK   bool
+	}{
+		{
+			scenario:     "nil",
+			expectedCode: -1,
+		},
+		{
+			scenario:     "not an exit error",
+			err:          errors.New("boom"), //nolint: goerr113
+			expectedCode: -1,
+		},
+		{
+			scenario:     "exit code error",
+			err:          fmt.Errorf("wrapped: %w", exec.ExitCodeError(4)),
+			expectedCode: 4,
+			expectedOK:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			code, ok := exec.ExitCode(tc.err)
+
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedOK, ok)
+		})
+	}
+}
+
+func TestExitCode_Signaled(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("sh", exec.WithArgs("-c", "kill -9 $$"))
+
+	code, ok := exec.ExitCode(err)
+
+	assert.False(t, ok)
+	assert.Equal(t, -1, code)
+}
@@ -2,7 +2,9 @@ package exec_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	osexec "os/exec"
 	"strings"
 
 	"go.nhat.io/exec"
@@ -71,3 +73,22 @@ func ExampleRun_stdin() {
 	// hello world
 	//
 }
+
+func ExampleExitCode() {
+	_, err := exec.Run("sh", exec.WithArgs("-c", "exit 2"))
+
+	if errors.Is(err, exec.ExitCodeError(2)) {
+		fmt.Println("exit code 2")
+	}
+
+	var exitErr *osexec.ExitError
+
+	// The error wraps the *exec.ExitError, it is not one.
+	if errors.As(err, &exitErr) {
+		fmt.Println(exitErr.ExitCode())
+	}
+
+	// Output:
+	// exit code 2
+	// 2
+}
@@ -7,16 +7,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/bool64/ctxd"
 	"github.com/kballard/go-shellquote"
 	"go.nhat.io/redact"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -27,6 +31,10 @@ var ErrNotFound = exec.ErrNotFound
 // If file contains a slash, it is tried directly and the PATH is not consulted.
 // Otherwise, on success, the result is an absolute path.
 func LookPath(file string) (string, error) {
+	if cache := lookPathCache.Load(); cache != nil {
+		return cache.LookPath(file)
+	}
+
 	return exec.LookPath(file) //nolint: wrapcheck
 }
 
@@ -36,33 +44,149 @@ type Cmd struct {
 	Next *Cmd
 
 	ctx    context.Context //nolint: containedctx
+	span   trace.Span
 	stdErr *bytes.Buffer
 	closer io.Closer
+	done   chan struct{}
 	tracer trace.Tracer
 	logger ctxd.Logger
 
-	redact argsRedactor
+	registry *Registry
+
+	prev        *Cmd
+	budget      *Budget
+	budgetWatch *budgetWatch
+
+	timeout      time.Duration
+	timeoutWatch *timeoutWatch
+
+	once         *onceGuard
+	skipped      bool
+	checkpointed bool
+	dryRun       bool
+
+	expandEnv bool
+
+	name           string
+	adopted        bool
+	backend        Backend
+	backendApplied bool
+	loginUser      string
+	pty            *ptyTerminal
+
+	killProcessTree bool
+	processTree     *processTree
+	paused          atomic.Bool
+
+	sharedSysProcAttr *syscall.SysProcAttr
+
+	retry     *RetryPolicy
+	retrySpec *retrySpec
+	attempts  []*Cmd
+	attempt   int
+
+	resultStore ResultStore
+	audit       *auditSink
+	notifiers   []Notifier
+
+	hooks       []hook
+	afterWait   []func(ctx context.Context, c *Cmd, err error)
+	customizers []func(cmd *exec.Cmd)
+	claims      map[string]string
+	conflicts   []string
+	optionErr   error
+
+	startedAt time.Time
+	duration  time.Duration
+	waitErr   error
+
+	errorStderr   int
+	captureStderr bool
+	outputLogging *LogLevel
+	logLevel      LogLevel
+	logSuccess    bool
+	successCodes  []int
+	decoder       Decoder
+
+	outputs  memFS
+	checksum *outputChecksum
+
+	outputFiles  []*outputFile
+	fileRotation *fileRotation
+
+	dirCreate          *dirCreate
+	removeDirOnFailure bool
+
+	stdinPipe     io.Closer
+	pipeTransport string
+	pipeStderr    bool
+	pipefail      *bool
+	nextErr       error
+	stageOpts     []Option
+	stageName     string
+	pipelineErr   bool
+	pipeFuncs     *pipeFuncs
+	pipeBuffer    int
+	pipeRateLimit int
+	failFast      bool
+	cancelNext    context.CancelFunc
+	checkpointDir string
+	metrics       *execMetrics
+	spanName      string
+	spanAttrs     []attribute.KeyValue
+	traceEnv      *traceEnv
+	propagators   []propagation.TextMapPropagator
+	pipelineSpan  trace.Span
+	outCounters   *outputCounters
+	traceSampling *traceSampling
+
+	chain   []chainStep
+	chained []*Cmd
+
+	stage stageState
+
+	redact        argsRedactor
+	secrets       []string
+	secretEnvKeys []string
+
+	redactPatterns []RedactionPattern
 }
 
 // String returns a human-readable description of c. It is intended only for debugging.
 // In particular, it is not suitable for use as input to a shell.
 //
+// The arguments are redacted, in every command of the pipeline.
+//
 // The output of String may vary across Go releases.
 func (c *Cmd) String() string {
+	return c.describe(c.redact)
+}
+
+// describe writes the command with the given redactor, the redactors of the next commands are applied on top of it so a
+// secret of the pipeline is never printed.
+func (c *Cmd) describe(redact argsRedactor) string {
 	b := new(strings.Builder)
+	args := redact(c.Args...)
 
 	if c.Err != nil {
-		b.WriteString(c.Args[0])
+		b.WriteString(args[0])
 	} else {
 		b.WriteString(c.Path)
 	}
 
 	b.WriteByte(' ')
-	b.WriteString(shellquote.Join(c.Args[1:]...))
+	b.WriteString(shellquote.Join(args[1:]...))
 
-	if c.Next != nil {
-		b.WriteString(" | ")
-		b.WriteString(c.Next.String())
+	if next := c.Next; next != nil {
+		if c.pipeStderr {
+			b.WriteString(" |& ")
+		} else {
+			b.WriteString(" | ")
+		}
+
+		b.WriteString(next.describe(func(args ...string) []string {
+			return redact(next.redact(args...)...)
+		}))
 	}
 
 	return b.String()
@@ -70,7 +194,8 @@ func (c *Cmd) String() string {
 
 // Start starts the specified command but does not wait for it to complete.
 //
-// If Start returns successfully, the c.Process field will be set.
+// If Start returns successfully, the c.Process field will be set. The next stages of the pipeline are started as well,
+// if one of them can not be started, the pipeline is stopped and Wait returns the error.
 //
 // After a successful call to Start the Wait method must be called in order to release associated system resources.
 func (c *Cmd) Start() error {
@@ -78,38 +203,135 @@ func (c *Cmd) Start() error {
 		return errors.New("exec: already started") //nolint: goerr113
 	}
 
-	ctx, span := c.tracer.Start(c.ctx, "exec:run",
-		trace.WithAttributes(
-			attribute.StringSlice("exec.args", c.redact(c.Args...)),
-		),
-	)
+	c.startPipelineSpan()
 
-	sc := span.SpanContext()
+	ctx, span := c.startSpan()
+	sc := trace.SpanContextFromContext(ctx)
 
-	if c.Cmd.Stderr == nil {
-		c.Cmd.Stderr = c.stdErr
-	} else {
-		c.Cmd.Stderr = io.MultiWriter(c.stdErr, c.Cmd.Stderr)
+	if skip, err := c.isOnceDone(ctx); err != nil || skip {
+		if err != nil {
+			c.setState(StageFailed, err)
+
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			c.skipped = true
+			c.setState(StageSkipped, nil)
+
+			span.SetAttributes(attribute.Bool("exec.skipped", true))
+			span.AddEvent("skipped", trace.WithAttributes(attribute.String("exec.once_key", c.once.key)))
+		}
+
+		span.End()
+		c.endPipelineSpan(err)
+
+		return err
+	}
+
+	if c.checkpointed {
+		return c.startCheckpointed(ctx, span)
+	}
+
+	if !c.dryRun {
+		c.openStagePipe()
 	}
 
+	c.wireStderr()
+	c.wireOutputLogging()
+	c.wrapChecksum()
+	c.poolCopies()
+
 	c.ctx = ctx
-	c.Cmd.Env = append(c.Cmd.Env,
-		fmt.Sprintf("TRACE_ID=%s", sc.TraceID().String()),
-		fmt.Sprintf("SPAN_ID=%s", sc.SpanID().String()),
-	)
+	c.span = span
+
+	c.injectTraceEnv(sc)
+	c.injectPropagation(ctx)
+
+	if c.Env == nil && envSnapshot.Load() != nil {
+		c.Env = baseEnv()
+	}
+
+	c.expandArgs()
 
 	if c.Next != nil {
-		c.Next.ctx = ctx
+		c.Next.ctx = c.nextContext(c.stageContext(ctx))
 	}
 
-	if err := c.Cmd.Start(); err != nil {
+	if c.dryRun {
+		err := c.startDryRun()
+		c.endPipelineSpan(err)
+
+		return err
+	}
+
+	c.startedAt = time.Now()
+
+	if err := c.startProcess(); err != nil {
+		err = c.stageError(err)
+
+		c.closeStagePipe()
+		c.cancelDownstream()
+		c.setState(StageFailed, err)
+		c.recordExited()
+		c.recordCancelCause(span)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		span.End()
 
+		err = c.runAfterPipeline(err)
+		c.waitErr = err
+
+		c.runAfterWait(err)
+		c.saveResult(err)
+		c.writeAudit(err)
+		c.notify(EventFailure, err)
+		c.endPipelineSpan(err)
+
+		return err
+	}
+
+	c.setState(StageRunning, nil)
+	c.recordStarted()
+	c.recordProcessStarted()
+
+	if c.registry != nil {
+		c.registry.add(c)
+	}
+
+	c.notify(EventStart, nil)
+	c.startNext()
+
+	return nil
+}
+
+func (c *Cmd) startProcess() error {
+	if err := c.ctx.Err(); err != nil {
 		return err //nolint: wrapcheck
 	}
 
+	if err := c.checkBudget(); err != nil {
+		return err
+	}
+
+	if err := c.runBeforeStart(); err != nil {
+		return err
+	}
+
+	c.prepareProcessTree()
+	c.watchOutput()
+
+	if err := c.spawn(); err != nil {
+		c.releaseProcessTree()
+
+		return c.runAfterExit(c.hooks, err)
+	}
+
+	c.attachProcessTree()
+	c.watchBudget()
+	c.watchTimeout()
+	c.watchContext()
+	c.runAfterStart()
+
 	return nil
 }
 
@@ -123,15 +345,23 @@ func (c *Cmd) Start() error {
 // status.
 //
 // If the command fails to run or doesn't complete successfully, the
-// error is of type *ExitError. Other error types may be
-// returned for I/O problems.
+// error wraps an *ExitError and matches ExitCodeError with errors.Is.
+// The error is not an *ExitError itself, unlike the one of os/exec, it
+// must be retrieved with errors.As instead of a type assertion.
+// Other error types may be returned for I/O problems.
 //
 // If any of c.Stdin, c.Stdout or c.Stderr are not an *os.File, Wait also waits
 // for the respective I/O loop copying to or from the process to complete.
 //
 // Wait releases any resources associated with the Cmd.
 func (c *Cmd) Wait() (err error) {
-	if c.Process == nil {
+	if c.skipped {
+		c.cancelDownstream()
+
+		return nil
+	}
+
+	if c.Process == nil && !c.checkpointed {
 		return errors.New("exec: not started") //nolint: goerr113
 	}
 
@@ -139,52 +369,92 @@ func (c *Cmd) Wait() (err error) {
 		return errors.New("exec: Wait was already called") //nolint: goerr113
 	}
 
-	span := trace.SpanFromContext(c.ctx)
 	defer func() {
-		span.SetAttributes(
-			attribute.Int("exec.exit_code", c.ProcessState.ExitCode()),
-		)
+		err = c.runAfterPipeline(err)
+		c.waitErr = err
+
+		c.runAfterWait(err)
+		c.saveResult(err)
+		c.writeAudit(err)
+		c.notifyResult(err)
+		c.endPipelineSpan(err)
+	}()
+
+	// The span of a stage of a pipeline ends when its process exits, with its own error.
+	if !c.inPipeline() {
+		defer func() {
+			c.endSpan(c.span, err)
+		}()
+	}
 
+	defer func() {
 		if err == nil {
-			span.SetStatus(codes.Ok, "")
-		} else {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
+			err = c.markOnceDone(c.ctx)
 		}
-
-		span.End()
 	}()
 
-	if c.Next != nil {
-		if err = c.Next.Start(); err != nil {
-			span.End()
+	defer c.cancelDownstream()
 
-			return err
-		}
+	if c.checkpointed {
+		return c.waitCheckpointed()
+	}
 
+	if c.Next != nil && c.nextErr == nil {
+		// The next stage is waited even if this one fails, it is killed with the rest of the pipeline when the context
+		// is done.
 		defer func() {
-			if err == nil {
-				err = c.Next.Wait()
-			} else {
-				nextSpan := trace.SpanFromContext(c.Next.ctx)
-
-				nextSpan.SetStatus(codes.Error, fmt.Sprintf("`%s` exited with code %d", c.Cmd.String(), c.ProcessState.ExitCode()))
-				nextSpan.End()
-			}
+			err = c.pipelineError(err, c.Next.Wait())
 		}()
 	}
 
 	defer c.closer.Close() //nolint: errcheck, gosec
 
-	if err = c.Cmd.Wait(); err != nil {
-		out := strings.Trim(c.stdErr.String(), "\r\n ")
+	err = c.checkExitCode(c.Cmd.Wait())
+	c.duration = time.Since(c.startedAt)
+	c.recordProcessExited()
+	err = c.releaseBudget(err)
+	err = c.releaseTimeout(err)
+	c.releaseProcessTree()
+	err = c.runAfterExit(c.hooks, err)
+	err = c.appendStderr(err)
+	err = c.stageError(err)
+
+	c.finish(err)
+	c.recordExited()
+
+	if c.inPipeline() {
+		c.endSpan(c.span, err)
+	}
+
+	if err != nil {
+		c.cancelDownstream()
+	}
 
-		c.logger.Debug(c.ctx, fmt.Sprintf("failed to execute `%s`", filepath.Base(c.Path)),
+	if c.nextErr != nil {
+		err = c.nextErr
+	}
+
+	close(c.done)
+
+	if c.registry != nil {
+		c.registry.remove(c)
+	}
+
+	if err != nil {
+		out := c.redactString(strings.Trim(c.CapturedStderr(), "\r\n "))
+
+		c.logFailure(c.ctx, fmt.Sprintf("failed to execute `%s`", filepath.Base(c.Path)), c.logFields(
 			"error", err,
 			"exec.exit_code", c.ProcessState.ExitCode(),
 			"exec.command", c.redact(c.Cmd.String()),
 			"exec.output", out,
-		)
+		)...)
+	} else if c.logSuccess {
+		c.logger.Info(c.ctx, fmt.Sprintf("executed `%s`", filepath.Base(c.Path)), c.logFields(
+			"exec.exit_code", c.ProcessState.ExitCode(),
+			"exec.command", c.redact(c.Cmd.String()),
+			"exec.duration", c.duration,
+		)...)
 	}
 
 	return err
@@ -195,19 +465,26 @@ func (c *Cmd) Wait() (err error) {
 // The returned error is nil if the command runs, has no problems copying stdin, stdout, and stderr, and exits with a
 // zero exit status.
 //
-// If the command starts but does not complete successfully, the error is of type *ExitError. Other error types may be
-// returned for other situations.
+// If the command starts but does not complete successfully, the error wraps an *ExitError and matches ExitCodeError with
+// errors.Is. The error is not an *ExitError itself, it must be retrieved with errors.As instead of a type assertion.
+// Other error types may be returned for other situations.
 //
 // If the calling goroutine has locked the operating system thread
 // with runtime.LockOSThread and modified any inheritable OS-level
 // thread state (for example, Linux or Plan 9 name spaces), the new
 // process will inherit the caller's thread state.
 func (c *Cmd) Run() error {
-	if err := c.Start(); err != nil {
-		return err
+	if c.checkpointDir != "" {
+		return c.runChain(c.pipelineFailure(c.runCheckpointed()))
+	}
+
+	if c.retry != nil && c.retrySpec != nil {
+		c.waitErr = c.pipelineFailure(c.runWithRetry())
+
+		return c.runChain(c.waitErr)
 	}
 
-	return c.Wait()
+	return c.runChain(c.pipelineFailure(c.runOnce()))
 }
 
 // Command returns the Cmd struct to execute the named program with the given arguments.
@@ -221,29 +498,90 @@ func Command(name string, opts ...Option) *Cmd {
 //
 // See os/exec.CommandContext for more information.
 func CommandContext(ctx context.Context, name string, opts ...Option) *Cmd {
+	c := newCmd(ctx, newStdCmd(ctx, filepath.Clean(name)), name, opts...)
+
+	if c.retry != nil {
+		c.retrySpec = &retrySpec{ctx: ctx, name: name, opts: opts}
+	}
+
+	return c
+}
+
+// FromCmd adopts a standard Cmd that has not been started yet. Its path, arguments, directory, environment, system
+// attributes and streams are kept as is, the options are applied on top.
+//
+// The standard Cmd must not be used directly after that. Its context and its Cancel function can not be copied, so the
+// process is only spawned once: the transient failures, such as ETXTBSY, that the commands created by Command retry are
+// returned as is.
+func FromCmd(std *exec.Cmd, opts ...Option) *Cmd {
+	name := std.Path
+
+	if len(std.Args) > 0 {
+		name = std.Args[0]
+	}
+
+	c := newCmd(context.Background(), std, name, opts...)
+	c.adopted = true
+
+	if std.Process != nil && c.Err == nil {
+		c.Err = errors.New("exec: already started") //nolint: goerr113
+	}
+
+	if c.retry != nil && c.Err == nil {
+		c.Err = fmt.Errorf("%w: WithRetry can not be used with FromCmd", ErrOptionConflict)
+	}
+
+	return c
+}
+
+func newCmd(ctx context.Context, std *exec.Cmd, name string, opts ...Option) *Cmd {
+	c, err := configureCmd(ctx, std, name, opts...)
+	if err != nil {
+		c.Err = err
+
+		return c
+	}
+
+	c.Err = setupCmd(c)
+
+	return c
+}
+
+// configureCmd creates the command and applies the options, without setting up its pipeline. It returns the error of
+// the options that can not be used together.
+func configureCmd(ctx context.Context, std *exec.Cmd, name string, opts ...Option) (*Cmd, error) {
 	c := &Cmd{
-		Cmd: exec.CommandContext(ctx, filepath.Clean(name)), //nolint: gosec
+		Cmd: std,
 
 		ctx:    ctx,
-		stdErr: new(bytes.Buffer),
-		tracer: trace.NewNoopTracerProvider().Tracer(""),
+		name:   name,
 		logger: ctxd.NoOpLogger{},
-		closer: io.NopCloser(nil),
+		closer: noopCloser,
+		done:   make(chan struct{}),
+
+		timeout: DefaultTimeout(),
 
 		redact: func(args ...string) []string {
 			return args
 		},
 	}
 
-	c.Cmd.Env = os.Environ()
+	if registerAll.Load() {
+		c.registry = DefaultRegistry
+	}
 
 	for _, opt := range opts {
 		opt.applyOption(c)
 	}
 
-	c.Err = setupCmd(c)
+	c.redactSecrets()
+	c.redactWithPatterns()
 
-	return c
+	for _, customize := range c.customizers {
+		customize(c.Cmd)
+	}
+
+	return c, c.validate()
 }
 
 // Run runs the command.
@@ -259,7 +597,7 @@ func Run(name string, opts ...Option) (*Cmd, error) {
 func RunWithContext(ctx context.Context, name string, opts ...Option) (_ *Cmd, err error) {
 	cmd := CommandContext(ctx, name, opts...)
 	if cmd.Err != nil {
-		cmd.logger.Debug(ctx, cmd.Err.Error())
+		cmd.logFailure(ctx, cmd.Err.Error())
 
 		return cmd, cmd.Err
 	}
@@ -267,29 +605,111 @@ func RunWithContext(ctx context.Context, name string, opts ...Option) (_ *Cmd, e
 	return cmd, cmd.Run()
 }
 
+// RunOutput runs the command with the given context and returns its standard output, without the leading and trailing
+// white spaces. If the command is a pipeline, the output is the one of the last command.
+//
+// If the standard output is set with WithStdout, it still receives the output.
+func RunOutput(ctx context.Context, name string, opts ...Option) (string, error) {
+	out := new(bytes.Buffer)
+
+	_, err := RunWithContext(ctx, name, append(opts[:len(opts):len(opts)], teeStdout(out))...)
+
+	return strings.TrimSpace(out.String()), err
+}
+
+func teeStdout(buf *bytes.Buffer) Option {
+	return optionFunc(func(c *Cmd) {
+		if c.Stdout == nil {
+			c.Stdout = buf
+		} else {
+			c.Stdout = io.MultiWriter(c.Stdout, buf)
+		}
+
+		// Only the output of the last attempt is kept.
+		c.addHook(hook{
+			beforeStart: func(*Cmd) error {
+				buf.Reset()
+
+				return nil
+			},
+		})
+	})
+}
+
 func setupCmd(cmd *Cmd) error {
+	for s := cmd; s.Next != nil; s = s.Next {
+		if s.Next.Err == nil {
+			inheritStage(s, s.Next)
+		}
+	}
+
+	return setupStage(cmd)
+}
+
+// inheritStage copies the settings of the command to the next stage of the pipeline, before the options of the stage
+// are applied.
+func inheritStage(cmd, next *Cmd) {
+	next.Stdout = cmd.Stdout
+	next.Stderr = cmd.Stderr
+
+	if next.Dir == "" {
+		next.Dir = cmd.Dir
+	}
+
+	next.Env = cmd.Env[:len(cmd.Env):len(cmd.Env)]
+
+	if next.SysProcAttr == nil {
+		next.sharedSysProcAttr = cmd.sharedSysProcAttr
+		next.SysProcAttr = copySysProcAttr(cmd.sharedSysProcAttr)
+	}
+
+	next.tracer = cmd.tracer
+	next.metrics = cmd.metrics
+	next.spanAttrs = cmd.spanAttrs
+	next.traceEnv = cmd.traceEnv
+	next.propagators = cmd.propagators
+	next.traceSampling = cmd.traceSampling
+	next.logger = cmd.logger
+	next.redact = cmd.redact
+	next.errorStderr = cmd.errorStderr
+	next.captureStderr = cmd.captureStderr
+	next.outputLogging = cmd.outputLogging
+	next.logLevel = cmd.logLevel
+	next.logSuccess = cmd.logSuccess
+	next.audit = cmd.audit
+	next.pipefail = cmd.pipefail
+	next.pipeBuffer = cmd.pipeBuffer
+	next.failFast = cmd.failFast
+	next.registry = cmd.registry
+	next.budget = cmd.budget
+	next.timeout = cmd.timeout
+	next.prev = cmd
+	next.checksum = cmd.checksum
+	next.dryRun = cmd.dryRun
+	next.expandEnv = cmd.expandEnv
+	next.killProcessTree = cmd.killProcessTree
+}
+
+func setupStage(cmd *Cmd) error {
+	optErr := cmd.applyStageOptions()
+
+	applyBackend(cmd)
+	applyLoginShell(cmd)
+	applyPTY(cmd)
+
+	// The backend resolves the command again, the options that can not be applied must still fail it.
+	if optErr != nil {
+		cmd.Err = optErr
+	}
+
 	if cmd.Err != nil {
-		cmd.logger.Debug(cmd.ctx, fmt.Sprintf("%s not found", filepath.Base(cmd.Path)))
+		cmd.logFailure(cmd.ctx, fmt.Sprintf("%s not found", filepath.Base(cmd.Path)), cmd.logFields()...)
 
-		return cmd.Err
+		return cmd.stageError(cmd.Err)
 	}
 
 	if cmd.Next != nil {
-		if cmd.Next.Err == nil {
-			pIn, pOut := io.Pipe()
-
-			cmd.Next.Stdout = cmd.Stdout
-			cmd.Next.Stdin = pIn
-			cmd.Next.Stderr = cmd.Stderr
-			cmd.Next.Env = cmd.Env
-			cmd.Next.tracer = cmd.tracer
-			cmd.Next.logger = cmd.logger
-
-			cmd.Stdout = pOut
-			cmd.closer = pOut
-		}
-
-		return setupCmd(cmd.Next)
+		return setupStage(cmd.Next)
 	}
 
 	return nil
@@ -317,6 +737,33 @@ func Pipe(name string, args ...string) Option {
 	})
 }
 
+// PipeWith pipes the output to the next command, configured with its own options. The options only apply to the new
+// stage and take precedence over the ones it inherits from the previous stage, so a stage of the pipeline can get its
+// own environment or standard error:
+//
+//	_, err := exec.Run("cat",
+//		exec.WithArgs("access.log"),
+//		exec.PipeWith("grep", exec.WithArgs(" 500 "), exec.WithEnv("LC_ALL", "C"), exec.WithStderr(os.Stderr)),
+//		exec.Pipe("wc", "-l"),
+//	)
+func PipeWith(name string, opts ...Option) Option {
+	return pipeStage(name, opts)
+}
+
+// PipeStderr pipes the standard output and the standard error of the last command into the standard input of a new
+// command added to the pipeline, like `2>&1 |` in a shell. The standard error is still captured and reported in the
+// errors of the command it comes from.
+func PipeStderr(name string, args ...string) Option {
+	return optionFunc(func(c *Cmd) {
+		if c.Next == nil {
+			c.Next = CommandContext(c.ctx, name, WithArgs(args...)) //nolint: gosec
+			c.pipeStderr = true
+		} else {
+			PipeStderr(name, args...).applyOption(c.Next)
+		}
+	})
+}
+
 // WithArgs sets the arguments.
 func WithArgs(args ...string) Option {
 	return optionFunc(func(c *Cmd) {
@@ -331,10 +778,38 @@ func AppendArgs(args ...string) Option {
 	})
 }
 
+// WithDir sets the working directory of the command and, by default, of the stages of its pipeline.
+func WithDir(path string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("working directory", "WithDir")
+
+		c.Dir = path
+	})
+}
+
+// WithSysProcAttr sets the OS-specific attributes of the command and of the stages of its pipeline, such as the
+// credentials, the process group or the Windows creation flags. Every stage gets its own copy of attr.
+func WithSysProcAttr(attr *syscall.SysProcAttr) Option {
+	return optionFunc(func(c *Cmd) {
+		c.sharedSysProcAttr = attr
+		c.SysProcAttr = copySysProcAttr(attr)
+	})
+}
+
+func copySysProcAttr(attr *syscall.SysProcAttr) *syscall.SysProcAttr {
+	if attr == nil {
+		return nil
+	}
+
+	cp := *attr
+
+	return &cp
+}
+
 // WithEnv sets the environment variable.
 func WithEnv(key, value string) Option {
 	return optionFunc(func(c *Cmd) {
-		c.Env = append(c.Env, fmt.Sprintf("%s=%s", key, value))
+		c.setEnv(fmt.Sprintf("%s=%s", key, value))
 	})
 }
 
@@ -342,7 +817,7 @@ func WithEnv(key, value string) Option {
 func WithEnvs(envs map[string]string) Option {
 	return optionFunc(func(c *Cmd) {
 		for key, value := range envs {
-			c.Env = append(c.Env, fmt.Sprintf("%s=%s", key, value))
+			c.setEnv(fmt.Sprintf("%s=%s", key, value))
 		}
 	})
 }
@@ -350,6 +825,8 @@ func WithEnvs(envs map[string]string) Option {
 // WithStdin sets the standard input.
 func WithStdin(in io.Reader) Option {
 	return optionFunc(func(c *Cmd) {
+		c.claim("standard input", "WithStdin")
+
 		c.Stdin = in
 	})
 }
@@ -357,6 +834,8 @@ func WithStdin(in io.Reader) Option {
 // WithStdout sets the standard output.
 func WithStdout(out io.Writer) Option {
 	return optionFunc(func(c *Cmd) {
+		c.claim("standard output", "WithStdout")
+
 		c.Stdout = out
 	})
 }
@@ -364,11 +843,14 @@ func WithStdout(out io.Writer) Option {
 // WithStderr sets the standard error.
 func WithStderr(err io.Writer) Option {
 	return optionFunc(func(c *Cmd) {
+		c.claim("standard error", "WithStderr")
+
 		c.Stderr = err
 	})
 }
 
-// WithTracer sets the tracer.
+// WithTracer sets the tracer. A pipeline has its own exec:pipeline span, the span of every stage is a child of it that
+// ends when the process of the stage exits.
 func WithTracer(tracer trace.Tracer) Option {
 	return optionFunc(func(c *Cmd) {
 		c.tracer = tracer
@@ -395,3 +877,78 @@ func WithLogger(logger ctxd.Logger) Option {
 }
 
 type argsRedactor func(args ...string) []string
+
+// WithCmdCustomizer customizes the underlying exec.Cmd after all the other options are applied and before the command
+// is validated. It is meant for the fields that are not covered by the options, the pipeline, the standard error and
+// the environment are still set up by the package afterwards.
+func WithCmdCustomizer(customize func(cmd *exec.Cmd)) Option {
+	return optionFunc(func(c *Cmd) {
+		c.customizers = append(c.customizers, customize)
+	})
+}
+
+// WithErrorStderr appends the end of the captured standard error, up to maxBytes, to the error returned when the command
+// fails, such as "exit status 128: fatal: repository not found". The standard error is redacted like the arguments.
+// The error still wraps the original one.
+func WithErrorStderr(maxBytes int) Option {
+	return optionFunc(func(c *Cmd) {
+		c.errorStderr = maxBytes
+	})
+}
+
+func (c *Cmd) appendStderr(err error) error {
+	if err == nil || c.errorStderr <= 0 {
+		return err
+	}
+
+	if out := c.stderrTail(c.errorStderr); out != "" {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+
+	return err
+}
+
+// stderrTail returns the end of the captured standard error, up to maxBytes, redacted.
+func (c *Cmd) stderrTail(maxBytes int) string {
+	out := strings.TrimSpace(c.CapturedStderr())
+
+	if len(out) > maxBytes {
+		i := len(out) - maxBytes
+
+		for i < len(out) && !utf8.RuneStart(out[i]) {
+			i++
+		}
+
+		out = "..." + out[i:]
+	}
+
+	return c.redactString(out)
+}
+
+// WithSuccessExitCodes treats the given exit codes as a success, in addition to 0. For example, grep exits with 1 when
+// nothing matches. In a pipeline, it is only about the command it is set on.
+func WithSuccessExitCodes(codes ...int) Option {
+	return optionFunc(func(c *Cmd) {
+		c.successCodes = append(c.successCodes, codes...)
+	})
+}
+
+func (c *Cmd) checkExitCode(err error) error {
+	if err == nil || len(c.successCodes) == 0 {
+		return wrapExitError(err)
+	}
+
+	if code, ok := ExitCode(err); ok {
+		for _, success := range c.successCodes {
+			if code == success {
+				return nil
+			}
+		}
+	}
+
+	return wrapExitError(err)
+}
+
+func (c *Cmd) redactString(s string) string {
+	return c.redact(s)[0]
+}
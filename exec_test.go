@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	osexec "os/exec"
 	"strings"
 	"sync"
 	"testing"
@@ -88,6 +89,83 @@ func TestCmd_String_Pipe(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestPipeWith(t *testing.T) {
+	t.Parallel()
+
+	stderr := newSafeBuffer()
+
+	out, err := exec.RunOutput(context.Background(), "sh",
+		exec.WithArgs("-c", "echo $SHARED ${OWN:-unset}"),
+		exec.WithEnv("SHARED", "yes"),
+		exec.PipeWith("sh",
+			exec.WithArgs("-c", "cat; echo $SHARED ${OWN:-unset}; echo oops >&2"),
+			exec.WithEnv("OWN", "middle"),
+			exec.WithStderr(stderr),
+		),
+		exec.Pipe("sh", "-c", "cat; echo $SHARED ${OWN:-unset}"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "yes unset\nyes middle\nyes unset", out)
+	assert.Equal(t, "oops", getOutput(stderr))
+}
+
+func TestPipeStderr(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "sh",
+		exec.WithArgs("-c", "echo out; echo error: disk full >&2"),
+		exec.PipeStderr("grep", "error"),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ERROR: DISK FULL", out)
+}
+
+func TestPipeStderr_Captured(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo oops >&2; exit 2"),
+		exec.PipeStderr("cat"),
+		exec.WithErrorStderr(100),
+	)
+
+	require.EqualError(t, err, "exit status 2: oops")
+	assert.Equal(t, "oops\n", cmd.CapturedStderr())
+}
+
+func TestCmd_String_PipeStderr(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("/bin/sh", exec.WithArgs("-c", "ls"), exec.PipeStderr("/bin/cat"))
+
+	assert.Equal(t, "/bin/sh -c ls |& /bin/cat ", cmd.String())
+}
+
+func TestCmd_String_Redacted(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("not_found",
+		exec.WithArgs("--token", "secret"),
+		exec.RedactArgs("secret"),
+		exec.Pipe("grep", "secret"),
+	)
+
+	assert.NotContains(t, cmd.String(), "secret")
+	assert.True(t, strings.HasPrefix(cmd.String(), `not_found --token \*\*\*\*\*\* | `))
+
+	cmd = exec.Command("echo",
+		exec.WithArgs("--token", "secret"),
+		exec.RedactArgs("secret"),
+		exec.Pipe("grep", "secret"),
+	)
+
+	assert.NotContains(t, cmd.String(), "secret")
+	assert.Equal(t, []string{cmd.Next.Path, "secret"}, cmd.Next.Args)
+}
+
 func TestCmd_Start_AlreadyStarted(t *testing.T) {
 	t.Parallel()
 
@@ -338,6 +416,204 @@ func TestRun_Success_Pipe(t *testing.T) {
 	assert.Equal(t, "B", getOutput(cmdOut))
 }
 
+func TestRunOutput(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "echo", exec.WithArgs("  hello world  "))
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", out)
+}
+
+func TestRunOutput_Pipe(t *testing.T) {
+	t.Parallel()
+
+	stdout := newSafeBuffer()
+
+	out, err := exec.RunOutput(context.Background(), "echo", exec.WithArgs("hello world"),
+		exec.WithStdout(stdout),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO WORLD", out)
+	assert.Equal(t, "HELLO WORLD", getOutput(stdout))
+}
+
+func TestRunOutput_Error(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "sh", exec.WithArgs("-c", "echo partial; exit 1"))
+
+	assert.EqualError(t, err, `exit status 1`)
+	assert.Equal(t, "partial", out)
+}
+
+func TestFromCmd(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	out := newSafeBuffer()
+
+	std := osexec.Command("sh", "-c", `echo "$(pwd) $GREETING"`)
+	std.Dir = dir
+	std.Env = []string{"GREETING=hello"}
+	std.Stdout = out
+
+	cmd := exec.FromCmd(std, exec.Pipe("tr", "[:lower:]", "[:upper:]"))
+
+	require.NoError(t, cmd.Err)
+	require.NoError(t, cmd.Run())
+
+	assert.Equal(t, strings.ToUpper(dir+" hello"), getOutput(out))
+}
+
+func TestFromCmd_AlreadyStarted(t *testing.T) {
+	t.Parallel()
+
+	std := osexec.Command("echo")
+
+	require.NoError(t, std.Run())
+
+	err := exec.FromCmd(std).Err
+
+	assert.EqualError(t, err, "exec: already started")
+}
+
+func TestFromCmd_LookPathError(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.FromCmd(osexec.Command("not_found"))
+
+	assert.ErrorIs(t, cmd.Err, osexec.ErrNotFound)
+}
+
+func TestWithCmdCustomizer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	out := newSafeBuffer()
+
+	var args []string
+
+	_, err := exec.Run("pwd",
+		exec.WithCmdCustomizer(func(cmd *osexec.Cmd) {
+			args = cmd.Args
+			cmd.Dir = dir
+		}),
+		exec.WithArgs("-L"),
+		exec.WithStdout(out),
+	)
+
+	require.NoError(t, err)
+
+	assert.Equal(t, dir, getOutput(out))
+	assert.Equal(t, []string{"-L"}, args[1:])
+}
+
+func TestWithDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	out := newSafeBuffer()
+
+	// The next command runs in the same directory.
+	_, err := exec.Run("pwd",
+		exec.WithArgs("-L"),
+		exec.Pipe("sh", "-c", "cat; pwd -L"),
+		exec.WithDir(dir),
+		exec.WithStdout(out),
+	)
+
+	require.NoError(t, err)
+
+	assert.Equal(t, dir+"\n"+dir, getOutput(out))
+}
+
+func TestWithDir_Conflict(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("pwd", exec.WithDir(t.TempDir()), exec.WithDirCreate(t.TempDir(), 0o755))
+
+	assert.EqualError(t, cmd.Err, "exec: conflicting options: WithDir and WithDirCreate both set the working directory")
+}
+
+func TestWithErrorStderr(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		maxBytes int
+		script   string
+		expected string
+	}{
+		{
+			scenario: "disabled",
+			script:   "echo 'fatal: repository not found' >&2; exit 128",
+			expected: "exit status 128",
+		},
+		{
+			scenario: "short stderr",
+			maxBytes: 100,
+			script:   "echo 'fatal: repository not found' >&2; exit 128",
+			expected: "exit status 128: fatal: repository not found",
+		},
+		{
+			scenario: "truncated stderr",
+			maxBytes: 10,
+			script:   "echo 'warning: something'; echo 'fatal: not found' >&2; exit 1",
+			expected: "exit status 1: ... not found",
+		},
+		{
+			scenario: "empty stderr",
+			maxBytes: 10,
+			script:   "exit 1",
+			expected: "exit status 1",
+		},
+		{
+			scenario: "redacted",
+			maxBytes: 100,
+			script:   "echo 'invalid token secret' >&2; exit 1",
+			expected: "exit status 1: invalid token ******",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := exec.Run("sh", exec.WithArgs("-c", tc.script),
+				exec.WithStdout(io.Discard),
+				exec.WithErrorStderr(tc.maxBytes),
+				exec.RedactArgs("secret"),
+			)
+
+			assert.EqualError(t, err, tc.expected)
+
+			var exitErr *osexec.ExitError
+
+			assert.ErrorAs(t, err, &exitErr)
+		})
+	}
+}
+
+func TestWithSuccessExitCodes(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("grep", exec.WithArgs("not_found"),
+		exec.WithStdin(strings.NewReader("hello world")),
+		exec.WithSuccessExitCodes(1),
+	)
+
+	require.NoError(t, err)
+
+	_, err = exec.Run("sh", exec.WithArgs("-c", "exit 2"), exec.WithSuccessExitCodes(1, 3))
+
+	assert.ErrorIs(t, err, exec.ExitCodeError(2))
+}
+
 func Test_AppendArgs(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package exec_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithSysProcAttr(t *testing.T) {
+	t.Parallel()
+
+	attr := &syscall.SysProcAttr{Setpgid: true}
+
+	cmd := exec.Command("echo",
+		exec.WithArgs("hello world"),
+		exec.Pipe("cat"),
+		exec.WithSysProcAttr(attr),
+	)
+
+	stages := cmd.Pipeline()
+	require.Len(t, stages, 2)
+
+	for _, s := range stages {
+		require.NotNil(t, s.SysProcAttr)
+
+		assert.True(t, s.SysProcAttr.Setpgid)
+		assert.NotSame(t, attr, s.SysProcAttr)
+	}
+
+	assert.NotSame(t, stages[0].SysProcAttr, stages[1].SysProcAttr)
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello world\n", string(out))
+}
@@ -0,0 +1,11 @@
+package exec
+
+import "time"
+
+// ThrottleDelay exposes throttleDelay to the tests.
+var ThrottleDelay = throttleDelay
+
+// Backoff exposes the backoff of the policy to the tests.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	return p.backoff(attempt)
+}
@@ -0,0 +1,101 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/bool64/ctxd"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// noopCloser is the closer of the commands that do not pipe their output to another command.
+var noopCloser = io.NopCloser(nil)
+
+// startSpan starts the span of the execution. Without a tracer, the span is a no-op that is not attached to the
+// context, so the overhead is negligible and the span of the caller, if any, is left untouched.
+func (c *Cmd) startSpan() (context.Context, trace.Span) {
+	if c.tracer == nil {
+		return c.ctx, trace.SpanFromContext(context.Background())
+	}
+
+	ctx, span := c.startTracerSpan(c.ctx, c.spanNameOf(),
+		trace.WithAttributes(
+			attribute.StringSlice("exec.args", c.redact(c.Args...)),
+		),
+	)
+
+	if c.stageName != "" {
+		span.SetAttributes(attribute.String("exec.stage", c.stageName))
+	}
+
+	if c.inPipeline() {
+		span.SetAttributes(attribute.Int("exec.stage.index", c.stageIndex()))
+	}
+
+	if c.timeout > 0 {
+		span.SetAttributes(attribute.String("exec.timeout", c.timeout.String()))
+	}
+
+	if c.attempt > 0 {
+		span.SetAttributes(attribute.Int("exec.attempt", c.attempt))
+	}
+
+	if c.pipeTransport != "" {
+		span.SetAttributes(attribute.String("exec.pipe.transport", c.stdinTransport()))
+	}
+
+	if span.IsRecording() {
+		span.SetAttributes(c.processAttributes()...)
+	}
+
+	span.SetAttributes(c.spanAttrs...)
+
+	return ctx, span
+}
+
+// wireStderr captures the standard error when something reads it: the logger, the errors or the results. Otherwise,
+// the standard error is left as is, so os/exec does not need a pipe and a goroutine to copy it.
+func (c *Cmd) wireStderr() {
+	if !c.needsStderr() {
+		return
+	}
+
+	c.stdErr = new(bytes.Buffer)
+
+	if c.Cmd.Stderr == nil {
+		c.Cmd.Stderr = c.stdErr
+	} else {
+		c.Cmd.Stderr = io.MultiWriter(c.stdErr, c.Cmd.Stderr)
+	}
+}
+
+func (c *Cmd) needsStderr() bool {
+	if c.captureStderr || c.errorStderr > 0 {
+		return true
+	}
+
+	_, noop := c.logger.(ctxd.NoOpLogger)
+
+	return !noop
+}
+
+// CapturedStderr returns the standard error captured by the command, it is empty when the standard error is not
+// captured. It is captured with WithStderrCapture, WithErrorStderr, which also adds its end to the errors, or when a
+// logger is set. The standard error is complete once the command has been waited.
+func (c *Cmd) CapturedStderr() string {
+	if c.stdErr == nil {
+		return ""
+	}
+
+	return c.stdErr.String()
+}
+
+// WithStderrCapture captures the standard error of the command and of the stages of its pipeline in memory, for
+// CapturedStderr and the results, even when nothing else reads it. The standard error still receives it.
+func WithStderrCapture() Option {
+	return optionFunc(func(c *Cmd) {
+		c.captureStderr = true
+	})
+}
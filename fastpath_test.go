@@ -0,0 +1,118 @@
+package exec_test
+
+import (
+	"context"
+	osexec "os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestCommand_FastPathAllocations(t *testing.T) { //nolint: paralleltest
+	ctx := context.Background()
+
+	raw := testing.AllocsPerRun(100, func() {
+		_ = osexec.CommandContext(ctx, "true")
+	})
+
+	actual := testing.AllocsPerRun(100, func() {
+		_ = exec.CommandContext(ctx, "true")
+	})
+
+	// The Cmd itself and its done channel.
+	assert.LessOrEqual(t, actual, raw+2)
+}
+
+func TestRun_FastPath_TraceEnv(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		options  []exec.Option
+		expected string
+	}{
+		{
+			scenario: "without a trace",
+			expected: "00000000000000000000000000000000 0000000000000000",
+		},
+		{
+			scenario: "without trace env",
+			options:  []exec.Option{exec.WithoutTraceEnv()},
+			expected: "unset unset",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			out := newSafeBuffer()
+
+			opts := append([]exec.Option{
+				exec.WithArgs("-c", `echo "${TRACE_ID-unset} ${SPAN_ID-unset}"`),
+				exec.WithStdout(out),
+			}, tc.options...)
+
+			_, err := exec.Run("sh", opts...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, getOutput(out))
+		})
+	}
+}
+
+func TestRun_FastPath_ParentSpan(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	out := newSafeBuffer()
+
+	// Without a tracer, the command does not create a span but still propagates the trace of the caller.
+	_, err := exec.RunWithContext(ctx, "sh", exec.WithArgs("-c", `echo "$TRACE_ID"`), exec.WithStdout(out))
+	require.NoError(t, err)
+
+	assert.Equal(t, parent.SpanContext().TraceID().String(), getOutput(out))
+	assert.Empty(t, recorder.Ended())
+
+	parent.End()
+
+	assert.Len(t, recorder.Ended(), 1)
+}
+
+func TestCmd_CapturedStderr(t *testing.T) {
+	t.Parallel()
+
+	stderr := newSafeBuffer()
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo first >&2"),
+		exec.Pipe("sh", "-c", "cat; echo second >&2"),
+		exec.WithStderr(stderr),
+		exec.WithStderrCapture(),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "first\n", cmd.CapturedStderr())
+	assert.Equal(t, "second\n", cmd.Next.CapturedStderr())
+	assert.Contains(t, getOutput(stderr), "first")
+}
+
+func TestCmd_CapturedStderr_NotCaptured(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("sh", exec.WithArgs("-c", "echo oops >&2"))
+	require.NoError(t, err)
+
+	assert.Empty(t, cmd.CapturedStderr())
+}
@@ -0,0 +1,118 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// ErrInvalidFormat indicates that a command format does not match its arguments.
+var ErrInvalidFormat = errors.New("exec: invalid command format")
+
+// placeholder marks the position of a verb in the format while the words are split. The NUL character can not appear
+// in a command line, so it can not collide with the format.
+const placeholder = "\x00"
+
+// Splitf splits the format into the words of a command line, like a POSIX shell would, and substitutes the verbs with
+// the formatted arguments. The arguments are never interpreted by a shell: a verb always ends up inside the word it is
+// written in, whatever the argument contains.
+//
+//	exec.Splitf("git clone %s %s", "https://example.com/repo.git", "my dir")
+//	// []string{"git", "clone", "https://example.com/repo.git", "my dir"}
+//
+// The verbs are the ones of the fmt package, without explicit argument indexes.
+func Splitf(format string, args ...any) ([]string, error) {
+	template, verbs, err := parseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(verbs) != len(args) {
+		return nil, fmt.Errorf("%w: %d verbs for %d arguments", ErrInvalidFormat, len(verbs), len(args))
+	}
+
+	words, err := shellquote.Split(template)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, err.Error())
+	}
+
+	if len(words) == 0 {
+		return nil, fmt.Errorf("%w: no command", ErrInvalidFormat)
+	}
+
+	next := 0
+
+	for i, w := range words {
+		parts := strings.Split(w, placeholder)
+
+		b := new(strings.Builder)
+		b.WriteString(parts[0])
+
+		for _, p := range parts[1:] {
+			fmt.Fprintf(b, verbs[next], args[next])
+			b.WriteString(p)
+
+			next++
+		}
+
+		words[i] = b.String()
+	}
+
+	return words, nil
+}
+
+// parseFormat replaces the verbs of the format with placeholders and returns them.
+func parseFormat(format string) (string, []string, error) {
+	var (
+		b     strings.Builder
+		verbs []string
+	)
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+
+			continue
+		}
+
+		j := i + 1
+
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+
+		if j >= len(format) {
+			return "", nil, fmt.Errorf("%w: incomplete verb at %d", ErrInvalidFormat, i)
+		}
+
+		switch format[j] {
+		case '%':
+			b.WriteByte('%')
+
+		case '[', '*':
+			return "", nil, fmt.Errorf("%w: unsupported verb at %d", ErrInvalidFormat, i)
+
+		default:
+			verbs = append(verbs, format[i:j+1])
+
+			b.WriteString(placeholder)
+		}
+
+		i = j
+	}
+
+	return b.String(), verbs, nil
+}
+
+// Runf runs the command line built by Splitf.
+func Runf(ctx context.Context, format string, args ...any) (*Cmd, error) {
+	words, err := Splitf(format, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return RunWithContext(ctx, words[0], WithArgs(words[1:]...))
+}
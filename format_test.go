@@ -0,0 +1,95 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestSplitf(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		format        string
+		args          []any
+		expected      []string
+		expectedError string
+	}{
+		{
+			scenario: "discrete arguments",
+			format:   "git clone %s %s",
+			args:     []any{"https://example.com/repo.git", "my dir; rm -rf /"},
+			expected: []string{"git", "clone", "https://example.com/repo.git", "my dir; rm -rf /"},
+		},
+		{
+			scenario: "verb inside a word",
+			format:   "docker run --name=%s -p %d:%d image",
+			args:     []any{"web $(whoami)", 8080, 80},
+			expected: []string{"docker", "run", "--name=web $(whoami)", "-p", "8080:80", "image"},
+		},
+		{
+			scenario: "quoted literal and percent",
+			format:   `printf '%%s and %s' %q`,
+			args:     []any{"it's", "x"},
+			expected: []string{"printf", "%s and it's", `"x"`},
+		},
+		{
+			scenario: "empty argument is kept",
+			format:   "echo %s done",
+			args:     []any{""},
+			expected: []string{"echo", "", "done"},
+		},
+		{
+			scenario:      "missing argument",
+			format:        "echo %s %s",
+			args:          []any{"a"},
+			expectedError: "exec: invalid command format: 2 verbs for 1 arguments",
+		},
+		{
+			scenario:      "explicit index",
+			format:        "echo %[1]s",
+			args:          []any{"a"},
+			expectedError: "exec: invalid command format: unsupported verb at 5",
+		},
+		{
+			scenario:      "no command",
+			format:        "  ",
+			expectedError: "exec: invalid command format: no command",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := exec.Splitf(tc.format, tc.args...)
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRunf(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Runf(context.Background(), "sh -c %s %s", `test "$0" = "a b; c"`, "a b; c")
+	require.NoError(t, err)
+
+	assert.True(t, cmd.ProcessState.Success())
+
+	_, err = exec.Runf(context.Background(), "echo %s")
+
+	assert.ErrorIs(t, err, exec.ErrInvalidFormat)
+}
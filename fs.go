@@ -0,0 +1,290 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithStdinFS reads the standard input of the command from the file of the given name in fsys. The file is opened
+// when the command starts and closed when it exits.
+func WithStdinFS(fsys fs.FS, name string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard input", "WithStdinFS")
+
+		var f fs.File
+
+		c.addHook(hook{
+			beforeStart: func(c *Cmd) error {
+				var err error
+
+				if f, err = fsys.Open(name); err != nil {
+					return fmt.Errorf("could not open stdin: %w", err)
+				}
+
+				c.Stdin = f
+
+				return nil
+			},
+			afterExit: func(_ *Cmd, err error) error {
+				_ = f.Close() //nolint: errcheck
+
+				return err
+			},
+		})
+	})
+}
+
+// WithOutputFiles collects the files matching the patterns, relative to the working directory, into memory when the
+// command exits, even if it fails. The patterns have the syntax of path.Match, a matching directory is collected with
+// all its files. The files are available with Cmd.OutputFiles and on the Result.
+func WithOutputFiles(patterns ...string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.addHook(hook{
+			afterExit: func(c *Cmd, err error) error {
+				outputs, cErr := collectOutputFiles(c.Dir, patterns)
+
+				c.outputs = outputs
+
+				if err == nil && cErr != nil {
+					return cErr
+				}
+
+				return err
+			},
+		})
+	})
+}
+
+// OutputFiles returns the files collected by WithOutputFiles, it is nil if the command has not exited yet.
+func (c *Cmd) OutputFiles() fs.FS {
+	if c.outputs == nil {
+		return nil
+	}
+
+	return c.outputs
+}
+
+func collectOutputFiles(dir string, patterns []string) (memFS, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	root := os.DirFS(dir)
+	outputs := memFS{}
+
+	collect := func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(root, name)
+		if err != nil {
+			return err //nolint: wrapcheck
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err //nolint: wrapcheck
+		}
+
+		outputs[name] = &memFile{data: data, mode: info.Mode(), modTime: info.ModTime()}
+
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(root, path.Clean(pattern))
+		if err != nil {
+			return outputs, fmt.Errorf("could not collect output files: %w", err)
+		}
+
+		for _, name := range matches {
+			if err := fs.WalkDir(root, name, collect); err != nil {
+				return outputs, fmt.Errorf("could not collect output files: %w", err)
+			}
+		}
+	}
+
+	return outputs, nil
+}
+
+// memFS is a read-only file system in memory, the files are keyed by their path. The directories are implied by the
+// paths of the files they contain.
+type memFS map[string]*memFile
+
+// memFile is a file of a memFS.
+type memFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+var _ fs.ReadFileFS = memFS(nil)
+
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if f, ok := m[name]; ok {
+		info := memFileInfo{name: path.Base(name), size: int64(len(f.data)), mode: f.mode, modTime: f.modTime}
+
+		return &openMemFile{info: info, Reader: bytes.NewReader(f.data)}, nil
+	}
+
+	entries := m.readDir(name)
+	if entries == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &openMemDir{info: memDirInfo(name), entries: entries}, nil
+}
+
+func (m memFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	f, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return append([]byte(nil), f.data...), nil
+}
+
+// readDir returns the entries of the directory, sorted by name, or nil if it does not exist. The root always exists.
+func (m memFS) readDir(dir string) []fs.DirEntry {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+
+	seen := make(map[string]bool)
+	entries := make([]fs.DirEntry, 0)
+
+	for name, f := range m {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		child, _, isDir := strings.Cut(strings.TrimPrefix(name, prefix), "/")
+		if seen[child] {
+			continue
+		}
+
+		seen[child] = true
+
+		if isDir {
+			entries = append(entries, fs.FileInfoToDirEntry(memDirInfo(child)))
+		} else {
+			entries = append(entries, fs.FileInfoToDirEntry(
+				memFileInfo{name: child, size: int64(len(f.data)), mode: f.mode, modTime: f.modTime},
+			))
+		}
+	}
+
+	if len(entries) == 0 && dir != "." {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries
+}
+
+// memFileInfo describes a file or a directory of a memFS.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func memDirInfo(name string) memFileInfo {
+	return memFileInfo{name: path.Base(name), mode: fs.ModeDir | 0o555}
+}
+
+func (i memFileInfo) Name() string {
+	return i.name
+}
+
+func (i memFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i memFileInfo) Mode() fs.FileMode {
+	return i.mode
+}
+
+func (i memFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i memFileInfo) IsDir() bool {
+	return i.mode.IsDir()
+}
+
+func (i memFileInfo) Sys() any {
+	return nil
+}
+
+// openMemFile is an opened file of a memFS.
+type openMemFile struct {
+	*bytes.Reader
+
+	info memFileInfo
+}
+
+func (f *openMemFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *openMemFile) Close() error {
+	return nil
+}
+
+// openMemDir is an opened directory of a memFS.
+type openMemDir struct {
+	info    memFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openMemDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *openMemDir) Close() error {
+	return nil
+}
+
+func (d *openMemDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")} //nolint: goerr113
+}
+
+func (d *openMemDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := d.entries[d.offset:]
+
+	if n > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+
+	d.offset += len(entries)
+
+	return entries, nil
+}
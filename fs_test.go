@@ -0,0 +1,81 @@
+package exec_test
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithStdinFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"input/data.txt": &fstest.MapFile{Data: []byte("hello world\n")},
+	}
+
+	out, err := exec.RunOutput(context.Background(), "tr", exec.WithArgs("[:lower:]", "[:upper:]"),
+		exec.WithStdinFS(fsys, "input/data.txt"),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO WORLD", out)
+
+	_, err = exec.Run("cat", exec.WithStdinFS(fsys, "missing.txt"))
+
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorContains(t, err, "could not open stdin:")
+}
+
+func TestWithOutputFiles(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", exec.WithArgs("-c", "mkdir -p dist/sub; echo a > a.txt; echo b > b.log; echo c > dist/sub/c.bin"),
+		exec.WithDirCreate(t.TempDir(), 0o755),
+		exec.WithOutputFiles("*.txt", "dist"),
+	)
+
+	assert.Nil(t, cmd.OutputFiles())
+
+	require.NoError(t, cmd.Run())
+
+	outputs := cmd.OutputFiles()
+
+	require.NoError(t, fstest.TestFS(outputs, "a.txt", "dist/sub/c.bin"))
+
+	data, err := fs.ReadFile(outputs, "dist/sub/c.bin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "c\n", string(data))
+
+	_, err = fs.Stat(outputs, "b.log")
+
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestWithOutputFiles_Result(t *testing.T) {
+	t.Parallel()
+
+	results, err := exec.Map(context.Background(), []string{"a", "b"}, func(s string) exec.Spec {
+		return exec.Spec{Name: "sh", Options: []exec.Option{
+			exec.WithArgs("-c", "echo "+s+" > out.txt; test "+s+" = a"),
+			exec.WithDirCreate(t.TempDir()+"/"+s, 0o755),
+			exec.WithOutputFiles("out.txt"),
+		}}
+	}, 2, exec.ContinueOnError())
+
+	assert.Error(t, err)
+	require.Len(t, results, 2)
+
+	for i, expected := range []string{"a\n", "b\n"} {
+		data, err := fs.ReadFile(results[i].Outputs, "out.txt")
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, string(data))
+	}
+}
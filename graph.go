@@ -0,0 +1,242 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Graph runs commands that depend on each other, like a Makefile. A command is started as soon as all of its
+// dependencies have succeeded, so the independent commands run concurrently.
+//
+//	g := exec.NewGraph(tracer)
+//
+//	deps := g.Add("deps", "go", exec.WithArgs("mod", "download"))
+//	build := g.Add("build", "go", exec.WithArgs("build", "./...")).After(deps)
+//	vet := g.Add("vet", "go", exec.WithArgs("vet", "./...")).After(deps)
+//	g.Add("deploy", "./deploy.sh").After(build, vet)
+//
+//	results, err := g.Run(ctx)
+type Graph struct {
+	tracer trace.Tracer
+	opts   []Option
+	nodes  []*Node
+}
+
+// Node is a command of a Graph.
+type Node struct {
+	graph *Graph
+	name  string
+	spec  Spec
+	deps  []*Node
+
+	done   chan struct{}
+	result Result
+}
+
+// NewGraph creates a new empty graph. When the tracer is not nil, the run of the graph has its own span, which is the
+// parent of the spans of the commands. The options apply to every command of the graph.
+func NewGraph(tracer trace.Tracer, opts ...Option) *Graph {
+	return &Graph{tracer: tracer, opts: opts}
+}
+
+// Add adds a command to the graph, under the given name.
+func (g *Graph) Add(name, cmd string, opts ...Option) *Node {
+	n := &Node{
+		graph: g,
+		name:  name,
+		spec:  Spec{Name: cmd, Options: opts},
+	}
+
+	g.nodes = append(g.nodes, n)
+
+	return n
+}
+
+// Name returns the name of the node.
+func (n *Node) Name() string {
+	return n.name
+}
+
+// After declares the nodes that must succeed before the command of the node is started.
+func (n *Node) After(deps ...*Node) *Node {
+	n.deps = append(n.deps, deps...)
+
+	return n
+}
+
+// Result returns the outcome of the command of the node once the graph has run.
+func (n *Node) Result() Result {
+	return n.result
+}
+
+// Run runs the commands of the graph and returns their results, in the order they were added.
+//
+// By default, a command whose dependency has failed is skipped with ErrSkipped, the others still run, and the returned
+// error aggregates all the failures in a MultiError. With FailFast, the first failure cancels the other commands and is
+// returned.
+func (g *Graph) Run(ctx context.Context, opts ...BatchOption) ([]Result, error) {
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		cfg   = newBatchConfig(opts...)
+		wg    sync.WaitGroup
+		once  sync.Once
+		first *Node
+	)
+
+	if g.tracer != nil {
+		var span trace.Span
+
+		ctx, span = g.tracer.Start(ctx, "exec:graph",
+			trace.WithAttributes(attribute.Int("exec.graph.nodes", len(g.nodes))),
+		)
+		defer span.End()
+
+		defer func() {
+			if err := g.err(first); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				span.SetStatus(codes.Ok, "")
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, n := range g.nodes {
+		n.done = make(chan struct{})
+	}
+
+	for _, n := range g.nodes {
+		wg.Add(1)
+
+		go func(n *Node) {
+			defer wg.Done()
+			defer close(n.done)
+
+			n.run(ctx)
+
+			if n.result.Err != nil && cfg.failFast {
+				once.Do(func() {
+					first = n
+
+					cancel()
+				})
+			}
+		}(n)
+	}
+
+	wg.Wait()
+
+	results := make([]Result, len(g.nodes))
+
+	for i, n := range g.nodes {
+		results[i] = n.result
+	}
+
+	return results, g.err(first)
+}
+
+// run waits for the dependencies of the node and runs its command if they have all succeeded.
+func (n *Node) run(ctx context.Context) {
+	for _, dep := range n.deps {
+		<-dep.done
+
+		if dep.result.Err != nil {
+			n.result = Result{ExitCode: -1, Err: fmt.Errorf("%w: %s", ErrSkipped, dep.name)}
+
+			return
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		n.result = Result{ExitCode: -1, Err: err}
+
+		return
+	}
+
+	opts := make([]Option, 0, len(n.graph.opts)+len(n.spec.Options)+1)
+	opts = append(opts, n.graph.opts...)
+
+	if n.graph.tracer != nil {
+		opts = append(opts, WithTracer(n.graph.tracer))
+	}
+
+	opts = append(opts, n.spec.Options...)
+
+	n.result = runSpec(ctx, Spec{Name: n.spec.Name, Options: opts})
+}
+
+// err returns the error of the graph, the one of the first failing node with FailFast.
+func (g *Graph) err(first *Node) error {
+	if first != nil {
+		return fmt.Errorf("%s: %w", first.name, first.result.Err)
+	}
+
+	var errs MultiError
+
+	for _, n := range g.nodes {
+		if err := n.result.Err; err != nil && !errors.Is(err, ErrSkipped) {
+			errs = append(errs, fmt.Errorf("%s: %w", n.name, err))
+		}
+	}
+
+	return errs.errorOrNil()
+}
+
+// validate checks that the dependencies belong to the graph and do not form a cycle.
+func (g *Graph) validate() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[*Node]int, len(g.nodes))
+
+	var visit func(n *Node) error
+
+	visit = func(n *Node) error {
+		switch state[n] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, n.name)
+
+		case visited:
+			return nil
+		}
+
+		state[n] = visiting
+
+		for _, dep := range n.deps {
+			if dep.graph != g {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, n.name, dep.name)
+			}
+
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[n] = visited
+
+		return nil
+	}
+
+	for _, n := range g.nodes {
+		if err := visit(n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestGraph_Run(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+	out := newSafeBuffer()
+
+	g := exec.NewGraph(tracer, exec.WithStdout(out))
+
+	a := g.Add("a", "sh", exec.WithArgs("-c", "echo a"))
+	b := g.Add("b", "sh", exec.WithArgs("-c", "sleep 0.2; echo b")).After(a)
+	c := g.Add("c", "sh", exec.WithArgs("-c", "sleep 0.2; echo c")).After(a)
+	g.Add("d", "sh", exec.WithArgs("-c", "echo d")).After(b, c)
+
+	start := time.Now()
+
+	results, err := g.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	// b and c run concurrently.
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+
+	lines := getOutput(out)
+
+	assert.Regexp(t, `^a\n(b\nc|c\nb)\nd$`, lines)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 5)
+
+	root := spans[len(spans)-1]
+
+	assert.Equal(t, "exec:graph", root.Name())
+
+	for _, s := range spans[:4] {
+		assert.Equal(t, root.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+}
+
+func TestGraph_Run_DependencyFails(t *testing.T) {
+	t.Parallel()
+
+	g := exec.NewGraph(nil)
+
+	a := g.Add("a", "sh", exec.WithArgs("-c", "exit 2"))
+	b := g.Add("b", "true").After(a)
+	c := g.Add("c", "true")
+
+	results, err := g.Run(context.Background())
+
+	require.EqualError(t, err, "a: exit status 2")
+
+	assert.Equal(t, 2, results[0].ExitCode)
+	assert.ErrorIs(t, b.Result().Err, exec.ErrSkipped)
+	assert.NoError(t, c.Result().Err)
+}
+
+func TestGraph_Run_FailFast(t *testing.T) {
+	t.Parallel()
+
+	g := exec.NewGraph(nil)
+
+	g.Add("slow", "sleep", exec.WithArgs("10"))
+	g.Add("fail", "sh", exec.WithArgs("-c", "sleep 0.1; exit 1"))
+
+	start := time.Now()
+
+	_, err := g.Run(context.Background(), exec.FailFast())
+
+	require.EqualError(t, err, "fail: exit status 1")
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestGraph_Run_Cycle(t *testing.T) {
+	t.Parallel()
+
+	g := exec.NewGraph(nil)
+
+	a := g.Add("a", "true")
+	b := g.Add("b", "true").After(a)
+
+	a.After(b)
+
+	_, err := g.Run(context.Background())
+
+	require.ErrorIs(t, err, exec.ErrDependencyCycle)
+}
+
+func TestGraph_Run_UnknownDependency(t *testing.T) {
+	t.Parallel()
+
+	other := exec.NewGraph(nil).Add("a", "true")
+
+	g := exec.NewGraph(nil)
+	g.Add("b", "true").After(other)
+
+	_, err := g.Run(context.Background())
+
+	require.ErrorIs(t, err, exec.ErrUnknownDependency)
+}
@@ -0,0 +1,97 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Group runs commands concurrently, like an errgroup. The first failure cancels the context of the group, so the other
+// commands are killed, and Wait returns the failure.
+//
+//	g, ctx := exec.NewGroup(ctx)
+//
+//	g.Go("make", exec.WithArgs("frontend"))
+//	g.Go("make", exec.WithArgs("backend"))
+//
+//	if err := g.Wait(); err != nil {
+//		// ...
+//	}
+type Group struct {
+	ctx    context.Context //nolint: containedctx
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	errs MultiError
+}
+
+// NewGroup creates a new group, and the context of the group that is cancelled at the first failure or when Wait
+// returns.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &Group{ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go runs the command in a new goroutine, with the context of the group. The command is returned right away to be
+// inspected once Wait has returned.
+func (g *Group) Go(name string, opts ...Option) *Cmd {
+	cmd := CommandContext(g.ctx, name, opts...)
+
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		err := cmd.Err
+		if err == nil {
+			err = cmd.Run()
+		}
+
+		if err != nil {
+			g.fail(cmd, err)
+		}
+	}()
+
+	return cmd
+}
+
+// fail records the failure and cancels the group. Once the group is cancelled, the commands that are killed or not
+// started because of it are not failures.
+func (g *Group) fail(cmd *Cmd, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.errs) > 0 && killedByGroup(cmd, err) {
+		return
+	}
+
+	g.errs = append(g.errs, err)
+
+	g.cancel()
+}
+
+func killedByGroup(cmd *Cmd, err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	return cmd.ProcessState != nil && isSignaled(cmd.ProcessState)
+}
+
+// Wait waits for all the commands of the group and returns the first failure with the ones of the commands that have
+// failed on their own before being killed, in a MultiError, or the only one.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.errs) == 1 {
+		return g.errs[0]
+	}
+
+	return g.errs.errorOrNil()
+}
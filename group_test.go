@@ -0,0 +1,81 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestGroup_Success(t *testing.T) {
+	t.Parallel()
+
+	g, _ := exec.NewGroup(context.Background())
+
+	a := g.Go("echo", exec.WithArgs("a"), exec.WithStdout(newSafeBuffer()))
+	b := g.Go("echo", exec.WithArgs("b"), exec.WithStdout(newSafeBuffer()))
+
+	require.NoError(t, g.Wait())
+
+	assert.True(t, a.ProcessState.Success())
+	assert.True(t, b.ProcessState.Success())
+}
+
+func TestGroup_FirstFailureCancelsOthers(t *testing.T) {
+	t.Parallel()
+
+	g, ctx := exec.NewGroup(context.Background())
+	start := time.Now()
+
+	slow := g.Go("sleep", exec.WithArgs("10"))
+	g.Go("sh", exec.WithArgs("-c", "sleep 0.1; exit 3"))
+
+	err := g.Wait()
+
+	require.EqualError(t, err, "exit status 3")
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.False(t, slow.ProcessState.Success())
+}
+
+func TestGroup_CombinedErrors(t *testing.T) {
+	t.Parallel()
+
+	g, _ := exec.NewGroup(context.Background())
+
+	g.Go("not_found")
+	g.Go("not_found_either")
+
+	err := g.Wait()
+
+	var errs exec.MultiError
+
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 2)
+
+	for _, err := range errs {
+		assert.ErrorIs(t, err, exec.ErrNotFound)
+	}
+}
+
+func TestGroup_ParentCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	g, _ := exec.NewGroup(ctx)
+
+	g.Go("sleep", exec.WithArgs("10"))
+	g.Go("sleep", exec.WithArgs("10"))
+
+	err := g.Wait()
+
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "\n")
+}
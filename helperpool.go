@@ -0,0 +1,353 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrHelperPoolClosed indicates that the helper pool has been closed.
+var ErrHelperPoolClosed = errors.New("exec: helper pool closed")
+
+// HelperProtocol exchanges a request and a response with a helper process, through its standard input and output.
+type HelperProtocol interface {
+	RoundTrip(w io.Writer, r *bufio.Reader, request []byte) ([]byte, error)
+}
+
+// HelperProtocolFunc is a function that implements HelperProtocol.
+type HelperProtocolFunc func(w io.Writer, r *bufio.Reader, request []byte) ([]byte, error)
+
+// RoundTrip calls f(w, r, request).
+func (f HelperProtocolFunc) RoundTrip(w io.Writer, r *bufio.Reader, request []byte) ([]byte, error) {
+	return f(w, r, request)
+}
+
+// LineProtocol writes the request as a line and reads one line back, the response does not have the new line.
+func LineProtocol() HelperProtocol {
+	return HelperProtocolFunc(func(w io.Writer, r *bufio.Reader, request []byte) ([]byte, error) {
+		if _, err := w.Write(append(bytes.TrimSuffix(request, []byte{'\n'}), '\n')); err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+
+		return bytes.TrimSuffix(line, []byte{'\n'}), nil
+	})
+}
+
+// HelperPool keeps warm helper processes of a server-style tool, which reads requests from its standard input and
+// writes the responses to its standard output, and dispatches the requests to them. It saves the cost of spawning a
+// process for every request of a high-frequency workload.
+//
+// When the tool can not be kept running, for example because it exits after the first request, the pool falls back to
+// spawning a command for every request, with the request as the standard input and the standard output as the response.
+type HelperPool struct {
+	spec     Spec
+	size     int
+	protocol HelperProtocol
+	fallback *Spec
+
+	// idle holds one token per slot, a token is either an idle helper or nil when the helper of the slot has to be
+	// spawned.
+	idle        chan *helper
+	closed      chan struct{}
+	closeOnce   sync.Once
+	unsupported atomic.Bool
+}
+
+type helper struct {
+	cmd        *Cmd
+	stdin      *os.File
+	stdoutFile *os.File
+	stdout     *bufio.Reader
+	served     int
+}
+
+// NewHelperPool creates a pool of at most size helper processes of the command described by the spec. The helpers are
+// spawned when they are needed, or by Warm.
+func NewHelperPool(spec Spec, size int, opts ...HelperPoolOption) *HelperPool {
+	if size <= 0 {
+		size = 1
+	}
+
+	p := &HelperPool{
+		spec:     spec,
+		size:     size,
+		protocol: LineProtocol(),
+		fallback: &spec,
+		idle:     make(chan *helper, size),
+		closed:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.applyHelperPoolOption(p)
+	}
+
+	for i := 0; i < size; i++ {
+		p.idle <- nil
+	}
+
+	return p
+}
+
+// Warm spawns the helpers that are not running. It returns the first error, the slots that could not be spawned are
+// tried again on demand.
+func (p *HelperPool) Warm() error {
+	tokens := make([]*helper, 0, p.size)
+
+	for i := 0; i < p.size; i++ {
+		select {
+		case <-p.closed:
+			p.release(tokens...)
+
+			return ErrHelperPoolClosed
+
+		case h := <-p.idle:
+			tokens = append(tokens, h)
+		}
+	}
+
+	var err error
+
+	for i, h := range tokens {
+		if h != nil {
+			continue
+		}
+
+		if tokens[i], err = p.spawn(); err != nil {
+			break
+		}
+	}
+
+	p.release(tokens...)
+
+	return err
+}
+
+func (p *HelperPool) release(tokens ...*helper) {
+	for _, h := range tokens {
+		p.idle <- h
+	}
+}
+
+// Do sends the request to an idle helper and returns its response, it waits for a helper to be idle. Once the pool is
+// closed, it fails with ErrHelperPoolClosed, even if the requests are served by the fallback.
+func (p *HelperPool) Do(ctx context.Context, request []byte) ([]byte, error) {
+	select {
+	case <-p.closed:
+		return nil, ErrHelperPoolClosed
+
+	default:
+	}
+
+	if p.unsupported.Load() {
+		return p.runFallback(ctx, request, nil)
+	}
+
+	var h *helper
+
+	select {
+	case <-p.closed:
+		return nil, ErrHelperPoolClosed
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case h = <-p.idle:
+	}
+
+	if h == nil {
+		var err error
+
+		if h, err = p.spawn(); err != nil {
+			p.release(nil)
+
+			return p.runFallback(ctx, request, err)
+		}
+	}
+
+	resp, err := p.roundTrip(ctx, h, request)
+	if err == nil {
+		p.release(h)
+
+		return resp, nil
+	}
+
+	h.discard()
+	p.release(nil)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	// A helper that does not survive its first request does not support running as a server.
+	if h.served <= 1 {
+		p.unsupported.Store(true)
+	}
+
+	return p.runFallback(ctx, request, err)
+}
+
+func (p *HelperPool) roundTrip(ctx context.Context, h *helper, request []byte) ([]byte, error) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = h.cmd.kill() //nolint: errcheck
+
+		case <-done:
+		}
+	}()
+
+	resp, err := p.protocol.RoundTrip(h.stdin, h.stdout, request)
+	if err == nil {
+		h.served++
+	}
+
+	return resp, err
+}
+
+func (p *HelperPool) runFallback(ctx context.Context, request []byte, cause error) ([]byte, error) {
+	if p.fallback == nil {
+		if cause == nil {
+			cause = errors.New("exec: helper is not supported") //nolint: goerr113
+		}
+
+		return nil, cause
+	}
+
+	out := new(bytes.Buffer)
+	opts := append(p.fallback.Options[:len(p.fallback.Options):len(p.fallback.Options)],
+		WithStdin(bytes.NewReader(request)),
+		teeStdout(out),
+	)
+
+	if _, err := RunWithContext(ctx, p.fallback.Name, opts...); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(out.Bytes(), []byte{'\n'}), nil
+}
+
+func (p *HelperPool) spawn() (*helper, error) {
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not create helper stdin: %w", err)
+	}
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		_ = stdinR.Close() //nolint: errcheck
+		_ = stdinW.Close() //nolint: errcheck
+
+		return nil, fmt.Errorf("could not create helper stdout: %w", err)
+	}
+
+	opts := append(p.spec.Options[:len(p.spec.Options):len(p.spec.Options)], WithStdin(stdinR), WithStdout(stdoutW))
+	cmd := CommandContext(context.Background(), p.spec.Name, opts...)
+
+	err = cmd.Err
+	if err == nil {
+		err = cmd.Start()
+	}
+
+	_ = stdinR.Close()  //nolint: errcheck
+	_ = stdoutW.Close() //nolint: errcheck
+
+	if err != nil {
+		_ = stdinW.Close()  //nolint: errcheck
+		_ = stdoutR.Close() //nolint: errcheck
+
+		return nil, err
+	}
+
+	go func() {
+		_ = cmd.Wait() //nolint: errcheck
+	}()
+
+	return &helper{
+		cmd:        cmd,
+		stdin:      stdinW,
+		stdoutFile: stdoutR,
+		stdout:     bufio.NewReader(stdoutR),
+	}, nil
+}
+
+func (h *helper) discard() {
+	_ = h.stdin.Close() //nolint: errcheck
+	_ = h.cmd.kill()    //nolint: errcheck
+
+	<-h.cmd.done
+
+	_ = h.stdoutFile.Close() //nolint: errcheck
+}
+
+// close closes the standard input of the helper, so it exits, and kills it if it does not exit within the grace period.
+func (h *helper) close() {
+	_ = h.stdin.Close() //nolint: errcheck
+
+	timer := time.NewTimer(defaultGracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-h.cmd.done:
+		_ = h.stdoutFile.Close() //nolint: errcheck
+
+	case <-timer.C:
+		h.discard()
+	}
+}
+
+// Close stops the helpers once they are idle. The requests that are waiting for a helper fail with
+// ErrHelperPoolClosed.
+func (p *HelperPool) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+
+		for i := 0; i < p.size; i++ {
+			if h := <-p.idle; h != nil {
+				h.close()
+			}
+		}
+	})
+
+	return nil
+}
+
+// HelperPoolOption is an option to configure a HelperPool.
+type HelperPoolOption interface {
+	applyHelperPoolOption(p *HelperPool)
+}
+
+type helperPoolOptionFunc func(p *HelperPool)
+
+func (f helperPoolOptionFunc) applyHelperPoolOption(p *HelperPool) {
+	f(p)
+}
+
+// WithHelperProtocol sets the protocol spoken by the helpers, LineProtocol by default.
+func WithHelperProtocol(protocol HelperProtocol) HelperPoolOption {
+	return helperPoolOptionFunc(func(p *HelperPool) {
+		p.protocol = protocol
+	})
+}
+
+// WithHelperFallback sets the command used for a request when no helper can serve it. By default, the command of the
+// pool is used. A nil spec disables the fallback, the error of the helper is returned instead.
+func WithHelperFallback(spec *Spec) HelperPoolOption {
+	return helperPoolOptionFunc(func(p *HelperPool) {
+		p.fallback = spec
+	})
+}
@@ -0,0 +1,167 @@
+package exec_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestHelperPool(t *testing.T) {
+	t.Parallel()
+
+	p := exec.NewHelperPool(exec.Spec{
+		Name:    "sh",
+		Options: []exec.Option{exec.WithArgs("-c", `while read -r l; do echo "$$ $l"; done`)},
+	}, 2)
+
+	defer p.Close() //nolint: errcheck
+
+	require.NoError(t, p.Warm())
+
+	var (
+		mu   sync.Mutex
+		pids = make(map[string]struct{})
+		wg   sync.WaitGroup
+	)
+
+	for i := 0; i < 20; i++ {
+		i := i
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			resp, err := p.Do(context.Background(), []byte(fmt.Sprintf("request %d", i)))
+			require.NoError(t, err)
+
+			pid, req, _ := strings.Cut(string(resp), " ")
+
+			assert.Equal(t, fmt.Sprintf("request %d", i), req)
+
+			mu.Lock()
+			pids[pid] = struct{}{}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, pids, 2)
+}
+
+func TestHelperPool_Fallback(t *testing.T) {
+	t.Parallel()
+
+	// The tool exits after the first request, so it can not be kept running.
+	p := exec.NewHelperPool(exec.Spec{
+		Name:    "sh",
+		Options: []exec.Option{exec.WithArgs("-c", `read -r l; echo "once $l"`)},
+	}, 1, exec.WithHelperFallback(&exec.Spec{
+		Name:    "sh",
+		Options: []exec.Option{exec.WithArgs("-c", `read -r l; echo "fallback $l"`)},
+	}))
+
+	defer p.Close() //nolint: errcheck
+
+	var actual []string
+
+	for i := 0; i < 3; i++ {
+		resp, err := p.Do(context.Background(), []byte("hello"))
+		require.NoError(t, err)
+
+		actual = append(actual, string(resp))
+	}
+
+	// The first helper serves the first request and exits, the next requests are served by the fallback.
+	expected := []string{"once hello", "fallback hello", "fallback hello"}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestHelperPool_NoFallback(t *testing.T) {
+	t.Parallel()
+
+	p := exec.NewHelperPool(exec.Spec{Name: "not_found"}, 1, exec.WithHelperFallback(nil))
+
+	defer p.Close() //nolint: errcheck
+
+	assert.ErrorIs(t, p.Warm(), exec.ErrNotFound)
+
+	_, err := p.Do(context.Background(), []byte("hello"))
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+}
+
+func TestHelperPool_Closed(t *testing.T) {
+	t.Parallel()
+
+	p := exec.NewHelperPool(exec.Spec{Name: "cat"}, 1)
+
+	resp, err := p.Do(context.Background(), []byte("hello"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", string(resp))
+
+	require.NoError(t, p.Close())
+
+	_, err = p.Do(context.Background(), []byte("hello"))
+
+	assert.ErrorIs(t, err, exec.ErrHelperPoolClosed)
+}
+
+func TestHelperPool_Closed_Fallback(t *testing.T) {
+	t.Parallel()
+
+	// The tool exits after the first request, the next ones are served by the fallback until the pool is closed.
+	p := exec.NewHelperPool(exec.Spec{
+		Name:    "sh",
+		Options: []exec.Option{exec.WithArgs("-c", `read -r l; echo "$l"`)},
+	}, 1)
+
+	for i := 0; i < 2; i++ {
+		resp, err := p.Do(context.Background(), []byte("hello"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello", string(resp))
+	}
+
+	require.NoError(t, p.Close())
+
+	resp, err := p.Do(context.Background(), []byte("hello"))
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, exec.ErrHelperPoolClosed)
+}
+
+func TestHelperPool_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	// The helper never answers.
+	p := exec.NewHelperPool(exec.Spec{Name: "sh", Options: []exec.Option{exec.WithArgs("-c", "exec sleep 10")}}, 1)
+
+	defer p.Close() //nolint: errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := p.Do(ctx, []byte("hello"))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The helper has been killed, the next request spawns a new one.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = p.Do(ctx, []byte("hello"))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,98 @@
+package exec
+
+import "context"
+
+// WithBeforeStart calls fn right before the process is started, with the context of the command and its span. The
+// function may change the command, such as its environment, or return an error to prevent it from starting: Start
+// returns the error.
+//
+// The functions are called in the order of the options, only for the command they are set on, not for the next stages
+// of its pipeline.
+func WithBeforeStart(fn func(ctx context.Context, c *Cmd) error) Option {
+	return optionFunc(func(c *Cmd) {
+		c.addHook(hook{
+			beforeStart: func(c *Cmd) error {
+				return fn(c.ctx, c)
+			},
+		})
+	})
+}
+
+// WithAfterWait calls fn once the command has exited and has been waited, or when it could not be started, with the
+// error returned by Wait or Start. The span of the command has ended and the next stages of the pipeline have been
+// waited.
+//
+// The functions are called in the order of the options, only for the command they are set on.
+func WithAfterWait(fn func(ctx context.Context, c *Cmd, err error)) Option {
+	return optionFunc(func(c *Cmd) {
+		c.afterWait = append(c.afterWait, fn)
+	})
+}
+
+func (c *Cmd) runAfterWait(err error) {
+	for _, fn := range c.afterWait {
+		fn(c.ctx, c, err)
+	}
+}
+
+// hook plugs internal behavior into the lifecycle of a command, every function is optional.
+type hook struct {
+	// beforeStart is called right before the process is started.
+	beforeStart func(c *Cmd) error
+	// afterStart is called right after the process has started.
+	afterStart func(c *Cmd)
+	// afterExit is called when the process has exited, or when it could not be started after beforeStart succeeded.
+	// It may replace the error.
+	afterExit func(c *Cmd, err error) error
+	// afterPipeline is called when the command and the next stages of its pipeline have been waited, or when it could
+	// not be started. It may replace the error.
+	afterPipeline func(c *Cmd, err error) error
+}
+
+func (c *Cmd) addHook(h hook) {
+	c.hooks = append(c.hooks, h)
+}
+
+func (c *Cmd) runBeforeStart() error {
+	for i, h := range c.hooks {
+		if h.beforeStart == nil {
+			continue
+		}
+
+		if err := h.beforeStart(c); err != nil {
+			c.runAfterExit(c.hooks[:i], err)
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cmd) runAfterStart() {
+	for _, h := range c.hooks {
+		if h.afterStart != nil {
+			h.afterStart(c)
+		}
+	}
+}
+
+func (c *Cmd) runAfterPipeline(err error) error {
+	for i := len(c.hooks) - 1; i >= 0; i-- {
+		if c.hooks[i].afterPipeline != nil {
+			err = c.hooks[i].afterPipeline(c, err)
+		}
+	}
+
+	return err
+}
+
+func (c *Cmd) runAfterExit(hooks []hook, err error) error {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if hooks[i].afterExit != nil {
+			err = hooks[i].afterExit(c, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,97 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithBeforeStart(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	out, err := exec.RunOutput(context.Background(), "sh",
+		exec.WithArgs("-c", "echo $GREETING"),
+		exec.WithBeforeStart(func(_ context.Context, c *exec.Cmd) error {
+			calls = append(calls, "first")
+
+			c.Env = append(c.Environ(), "GREETING=hello")
+
+			return nil
+		}),
+		exec.WithBeforeStart(func(context.Context, *exec.Cmd) error {
+			calls = append(calls, "second")
+
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", out)
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestWithBeforeStart_Error(t *testing.T) {
+	t.Parallel()
+
+	var waitErr error
+
+	cmd, err := exec.Run("echo",
+		exec.WithBeforeStart(func(context.Context, *exec.Cmd) error {
+			return errors.New("denied")
+		}),
+		exec.WithAfterWait(func(_ context.Context, _ *exec.Cmd, err error) {
+			waitErr = err
+		}),
+	)
+
+	require.EqualError(t, err, "denied")
+
+	assert.Nil(t, cmd.Process)
+	assert.Equal(t, err, waitErr)
+}
+
+func TestWithAfterWait(t *testing.T) {
+	t.Parallel()
+
+	var (
+		waitErr  error
+		exitCode int
+	)
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo oops >&2; exit 3"),
+		exec.WithErrorStderr(100),
+		exec.WithAfterWait(func(_ context.Context, c *exec.Cmd, err error) {
+			waitErr = err
+			exitCode = c.ProcessState.ExitCode()
+		}),
+	)
+	require.Error(t, err)
+
+	assert.Equal(t, err, waitErr)
+	assert.Equal(t, 3, exitCode)
+	assert.EqualError(t, waitErr, "exit status 3: oops")
+}
+
+func TestWithAfterWait_Pipe(t *testing.T) {
+	t.Parallel()
+
+	var nextState exec.StageState
+
+	_, err := exec.Run("echo",
+		exec.Pipe("cat"),
+		exec.WithAfterWait(func(_ context.Context, c *exec.Cmd, _ error) {
+			nextState = c.Next.State()
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, exec.StageExited, nextState)
+}
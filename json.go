@@ -0,0 +1,102 @@
+package exec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+type cmdJSON struct {
+	Name      string        `json:"name"`
+	Path      string        `json:"path"`
+	Args      []string      `json:"args"`
+	Dir       string        `json:"dir,omitempty"`
+	PID       int           `json:"pid,omitempty"`
+	ExitCode  *int          `json:"exit_code,omitempty"`
+	StartedAt *time.Time    `json:"started_at,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Skipped   bool          `json:"skipped,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Next      *cmdJSON      `json:"next,omitempty"`
+}
+
+// MarshalJSON encodes the command and the next commands of the pipeline, with the arguments redacted. The environment
+// is never encoded.
+//
+// It must not be called while the command is running.
+func (c *Cmd) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toJSON(c.redact)) //nolint: wrapcheck
+}
+
+// toJSON converts the command with the given redactor, the redactors of the next commands are applied on top of it,
+// like describe does, so a secret of the pipeline is never encoded.
+func (c *Cmd) toJSON(redact argsRedactor) *cmdJSON {
+	v := &cmdJSON{
+		Name:     c.name,
+		Path:     c.Path,
+		Args:     redact(c.Args...),
+		Dir:      c.Dir,
+		Duration: c.duration,
+		Skipped:  c.skipped,
+	}
+
+	if next := c.Next; next != nil {
+		v.Next = next.toJSON(func(args ...string) []string {
+			return redact(next.redact(args...)...)
+		})
+	}
+
+	if c.Process != nil {
+		v.PID = c.Process.Pid
+	}
+
+	if c.ProcessState != nil {
+		code := c.ProcessState.ExitCode()
+		v.ExitCode = &code
+	}
+
+	if !c.startedAt.IsZero() {
+		v.StartedAt = &c.startedAt
+	}
+
+	if c.Err != nil {
+		v.Error = c.redactString(c.Err.Error())
+	}
+
+	return v
+}
+
+type resultJSON struct {
+	Cmd      *Cmd          `json:"cmd,omitempty"`
+	ExitCode int           `json:"exit_code"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Duration time.Duration `json:"duration"`
+	PID      int           `json:"pid,omitempty"`
+	Signaled bool          `json:"signaled,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Checksum string        `json:"checksum,omitempty"`
+}
+
+// MarshalJSON encodes the result, with the arguments, the standard error and the error redacted.
+func (r Result) MarshalJSON() ([]byte, error) {
+	v := resultJSON{
+		Cmd:      r.Cmd,
+		ExitCode: r.ExitCode,
+		Stderr:   r.Stderr,
+		Duration: r.Duration,
+		PID:      r.PID,
+		Signaled: r.Signaled,
+		Checksum: hex.EncodeToString(r.Checksum),
+	}
+
+	if r.Err != nil {
+		v.Error = r.Err.Error()
+	}
+
+	if r.Cmd != nil {
+		v.Stderr = r.Cmd.redactString(v.Stderr)
+		v.Error = r.Cmd.redactString(v.Error)
+	}
+
+	return json.Marshal(v) //nolint: wrapcheck
+}
@@ -0,0 +1,115 @@
+package exec_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("echo",
+		exec.WithArgs("--token", "secret"),
+		exec.RedactArgs("secret"),
+		exec.Pipe("cat"),
+	)
+
+	data, err := json.Marshal(cmd)
+	require.NoError(t, err)
+
+	var actual map[string]any
+
+	require.NoError(t, json.Unmarshal(data, &actual))
+
+	assert.Equal(t, "echo", actual["name"])
+	assert.Equal(t, cmd.Path, actual["path"])
+	assert.Equal(t, []any{cmd.Path, "--token", "******"}, actual["args"])
+	assert.NotContains(t, actual, "exit_code")
+	assert.NotContains(t, actual, "env")
+	assert.Equal(t, "cat", actual["next"].(map[string]any)["name"]) //nolint: forcetypeassert
+
+	require.NoError(t, cmd.Run())
+
+	data, err = json.Marshal(cmd)
+	require.NoError(t, err)
+
+	actual = nil
+
+	require.NoError(t, json.Unmarshal(data, &actual))
+
+	assert.Equal(t, float64(0), actual["exit_code"])
+	assert.Contains(t, actual, "pid")
+	assert.Contains(t, actual, "started_at")
+	assert.NotContains(t, string(data), "secret")
+}
+
+func TestCmd_MarshalJSON_StageRedactor(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("echo",
+		exec.WithArgs("--token", "secret"),
+		exec.RedactArgs("secret"),
+		exec.PipeWith("grep", exec.WithArgs("-e", "secret", "-e", "other"), exec.RedactArgs("other")),
+	)
+
+	data, err := json.Marshal(cmd)
+	require.NoError(t, err)
+
+	var actual map[string]any
+
+	require.NoError(t, json.Unmarshal(data, &actual))
+
+	// The stage redacts its own secret and the ones of the stages before it, like String does.
+	next := actual["next"].(map[string]any) //nolint: forcetypeassert
+
+	assert.Equal(t, []any{cmd.Next.Path, "-e", "******", "-e", "******"}, next["args"])
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, cmd.String(), "secret")
+}
+
+func TestCmd_MarshalJSON_LookPathError(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(exec.Command("not_found"))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `"error":"exec: \"not_found\": executable file not found in $PATH"`)
+}
+
+func TestResult_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", exec.WithArgs("-c", "echo secret >&2; exit 3", "secret"), exec.RedactArgs("secret"))
+
+	r := exec.Result{
+		Cmd:      cmd,
+		ExitCode: 3,
+		Stderr:   "oops: secret",
+		Duration: time.Second,
+		PID:      42,
+		Signaled: true,
+		Err:      errors.New("failed with secret"), //nolint: goerr113
+	}
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+
+	var actual map[string]any
+
+	require.NoError(t, json.Unmarshal(data, &actual))
+
+	assert.Equal(t, float64(3), actual["exit_code"])
+	assert.Equal(t, "oops: ******", actual["stderr"])
+	assert.Equal(t, "failed with ******", actual["error"])
+	assert.Equal(t, float64(time.Second), actual["duration"])
+	assert.Equal(t, float64(42), actual["pid"])
+	assert.Equal(t, true, actual["signaled"])
+	assert.NotContains(t, string(data), "secret")
+}
@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+)
+
+// maxLineSize is the size after which a line without a line break is passed to the line functions anyway, so a stream
+// without line breaks does not grow the buffer forever.
+const maxLineSize = 64 * 1024
+
+// WithStdoutLineFunc calls fn with every line of the standard output, without the line break, as the process writes
+// it. The last line is passed when the process exits, even if it does not end with a line break. The standard output
+// still receives the output.
+//
+// The function is called from the goroutine that copies the output, it blocks the process when it is slow.
+func WithStdoutLineFunc(fn func(line string)) Option {
+	return optionFunc(func(c *Cmd) {
+		c.addLineFunc(&c.Cmd.Stdout, fn)
+	})
+}
+
+// WithStderrLineFunc calls fn with every line of the standard error, like WithStdoutLineFunc does. The standard error
+// is still captured and reported in the errors.
+func WithStderrLineFunc(fn func(line string)) Option {
+	return optionFunc(func(c *Cmd) {
+		c.addLineFunc(&c.Cmd.Stderr, fn)
+	})
+}
+
+// addLineFunc plugs the line writer into the stream when the command starts, once the other options have set it.
+func (c *Cmd) addLineFunc(stream *io.Writer, fn func(line string)) {
+	if fn == nil {
+		return
+	}
+
+	w := &lineWriter{fn: fn}
+
+	c.addHook(hook{
+		beforeStart: func(*Cmd) error {
+			w.buf.Reset()
+
+			*stream = teeWriter(*stream, w)
+
+			return nil
+		},
+		afterExit: func(_ *Cmd, err error) error {
+			w.flush()
+
+			return err
+		},
+	})
+}
+
+// teeWriter writes to w and to extra, or only to extra if w is nil.
+func teeWriter(w, extra io.Writer) io.Writer {
+	if w == nil {
+		return extra
+	}
+
+	return io.MultiWriter(w, extra)
+}
+
+// lineWriter calls a function with every line written to it.
+type lineWriter struct {
+	fn  func(line string)
+	buf bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 || w.buf.Len()+i > maxLineSize {
+			chunk := p
+
+			if room := maxLineSize - w.buf.Len(); len(chunk) > room {
+				chunk = chunk[:room]
+			}
+
+			w.buf.Write(chunk)
+
+			if w.buf.Len() >= maxLineSize {
+				w.emit()
+			}
+
+			p = p[len(chunk):]
+
+			continue
+		}
+
+		w.buf.Write(p[:i])
+		w.emit()
+
+		p = p[i+1:]
+	}
+
+	return n, nil
+}
+
+func (w *lineWriter) flush() {
+	if w.buf.Len() > 0 {
+		w.emit()
+	}
+}
+
+func (w *lineWriter) emit() {
+	line := w.buf.Bytes()
+
+	if l := len(line); l > 0 && line[l-1] == '\r' {
+		line = line[:l-1]
+	}
+
+	w.fn(string(line))
+	w.buf.Reset()
+}
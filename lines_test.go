@@ -0,0 +1,86 @@
+package exec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithStdoutLineFunc(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+
+	out := new(bytes.Buffer)
+
+	_, err := exec.Run("printf",
+		exec.WithStdoutLineFunc(func(line string) {
+			lines = append(lines, line)
+		}),
+		exec.WithArgs(`first\nsecond\r\n\nlast`),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"first", "second", "", "last"}, lines)
+	assert.Equal(t, "first\nsecond\r\n\nlast", out.String())
+}
+
+func TestWithStdoutLineFunc_Pipe(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+
+	_, err := exec.Run("printf",
+		exec.WithArgs(`b\na\n`),
+		exec.Pipe("sort"),
+		exec.WithStdoutLineFunc(func(line string) {
+			lines = append(lines, line)
+		}),
+	)
+	require.NoError(t, err)
+
+	// The function receives the output of the command it is set on.
+	assert.Equal(t, []string{"b", "a"}, lines)
+}
+
+func TestWithStdoutLineFunc_LongLine(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+
+	_, err := exec.Run("head",
+		exec.WithArgs("-c", "100000", "/dev/zero"),
+		exec.WithStdoutLineFunc(func(line string) {
+			lines = append(lines, line)
+		}),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, lines, 2)
+	assert.Equal(t, 64*1024, len(lines[0]))
+	assert.Equal(t, 100000-64*1024, len(lines[1]))
+}
+
+func TestWithStderrLineFunc(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo out; echo one >&2; echo two >&2; exit 1"),
+		exec.WithStderrLineFunc(func(line string) {
+			lines = append(lines, line)
+		}),
+		exec.WithErrorStderr(100),
+	)
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"one", "two"}, lines)
+	assert.True(t, strings.HasSuffix(err.Error(), "one\ntwo"), err.Error())
+}
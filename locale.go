@@ -0,0 +1,31 @@
+package exec
+
+// portableLocale is the locale every POSIX system has.
+const portableLocale = "C"
+
+// WithLocale pins the locale of the command and of the stages of its pipeline, so their output can be parsed reliably.
+// LANG and LC_ALL are set to the locale, and LANGUAGE is removed because GNU tools prefer it to LC_ALL for their
+// messages.
+func WithLocale(locale string) Option {
+	return withLocale(locale, "WithLocale")
+}
+
+// WithPortableLocale pins the "C" locale, which exists on every POSIX system unlike "C.UTF-8". See WithLocale.
+func WithPortableLocale() Option {
+	return withLocale(portableLocale, "WithPortableLocale")
+}
+
+func withLocale(locale, option string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("locale", option)
+
+		if locale == "" {
+			c.invalid("%s requires a locale", option)
+
+			return
+		}
+
+		c.unsetEnv("LANG", "LC_ALL", "LANGUAGE")
+		c.setEnv("LANG="+locale, "LC_ALL="+locale)
+	})
+}
@@ -0,0 +1,75 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithLocale(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", `echo "$LANG $LC_ALL ${LANGUAGE-unset}"`),
+		exec.WithEnv("LANG", "fr_FR.UTF-8"),
+		exec.WithEnv("LANGUAGE", "fr"),
+		exec.WithLocale("C.UTF-8"),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "C.UTF-8 C.UTF-8 unset", getOutput(out))
+}
+
+func TestWithPortableLocale_Pipe(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("echo",
+		exec.Pipe("sh", "-c", `cat > /dev/null; echo "$LANG $LC_ALL"`),
+		exec.WithPortableLocale(),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "C C", getOutput(out))
+}
+
+func TestWithLocale_Invalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		options  []exec.Option
+		expected string
+	}{
+		{
+			scenario: "empty locale",
+			options:  []exec.Option{exec.WithLocale("")},
+			expected: "exec: conflicting options: WithLocale requires a locale",
+		},
+		{
+			scenario: "twice",
+			options:  []exec.Option{exec.WithPortableLocale(), exec.WithLocale("C.UTF-8")},
+			expected: "exec: conflicting options: WithPortableLocale and WithLocale both set the locale",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := exec.Command("true", tc.options...)
+
+			assert.EqualError(t, cmd.Err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+//go:build !windows
+
+package exec
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	defaultLoginShell = "/bin/sh"
+	defaultLoginPath  = "/usr/local/bin:/usr/bin:/bin"
+	rootLoginPath     = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// WithLoginShell runs the command through the login shell of the given user, like `su - user -c` does. The command
+// runs in a new session with the credentials of the user, in its home directory unless the working directory is set,
+// and with HOME, SHELL, USER, LOGNAME and PATH set from the user database. The other variables of the environment are
+// kept.
+//
+// Running the command as another user requires the privileges to do so, usually root. The other stages of the pipeline
+// run as the current user, in the same working directory.
+func WithLoginShell(username string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("backend", "WithLoginShell")
+
+		if username == "" {
+			c.invalid("WithLoginShell requires a user")
+
+			return
+		}
+
+		c.loginUser = username
+	})
+}
+
+// WithControllingTerminal starts the command in a new session and makes its standard input, which must be a terminal,
+// the controlling terminal of the session, so the command can take over an interactive session.
+func WithControllingTerminal() Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("controlling terminal", "WithControllingTerminal")
+
+		sysProcAttr(c).Setsid = true
+		sysProcAttr(c).Setctty = true
+		sysProcAttr(c).Ctty = 0
+	})
+}
+
+func sysProcAttr(c *Cmd) *syscall.SysProcAttr {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	return c.SysProcAttr
+}
+
+// applyLoginShell replaces the command with the login shell of the user that runs it.
+func applyLoginShell(c *Cmd) {
+	if c.loginUser == "" {
+		return
+	}
+
+	u, err := user.Lookup(c.loginUser)
+	if err != nil {
+		c.Err = fmt.Errorf("could not look up user %q: %w", c.loginUser, err)
+
+		return
+	}
+
+	cred, err := loginCredential(u)
+	if err != nil {
+		c.Err = fmt.Errorf("could not look up the groups of user %q: %w", c.loginUser, err)
+
+		return
+	}
+
+	shell := lookupLoginShell(u.Username)
+	args := make([]string, 0, len(c.Args))
+	args = append(args, c.name)
+	args = append(args, c.Args[1:]...)
+
+	c.setPath(shell)
+	c.Args = []string{"-" + filepath.Base(shell), "-c", QuoteCommand(args)}
+
+	path := defaultLoginPath
+	if u.Uid == "0" {
+		path = rootLoginPath
+	}
+
+	c.unsetEnv("HOME", "SHELL", "USER", "LOGNAME", "PATH")
+	c.setEnv("HOME="+u.HomeDir, "SHELL="+shell, "USER="+u.Username, "LOGNAME="+u.Username, "PATH="+path)
+
+	if c.Dir == "" {
+		c.Dir = u.HomeDir
+	}
+
+	sysProcAttr(c).Setsid = true
+	sysProcAttr(c).Credential = cred
+}
+
+// loginCredential returns the credential of the user, nil if it is the current user.
+func loginCredential(u *user.User) (*syscall.Credential, error) {
+	if u.Uid == strconv.Itoa(os.Getuid()) {
+		return nil, nil //nolint: nilnil
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	groupIDs, err := u.GroupIds()
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	groups := make([]uint32, 0, len(groupIDs))
+
+	for _, id := range groupIDs {
+		g, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+
+		groups = append(groups, uint32(g))
+	}
+
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups}, nil
+}
+
+// lookupLoginShell reads the login shell of the user from /etc/passwd, which os/user does not expose.
+func lookupLoginShell(username string) string {
+	f, err := os.Open("/etc/passwd")
+	if err != nil {
+		return defaultLoginShell
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	s := bufio.NewScanner(f)
+
+	for s.Scan() {
+		fields := strings.Split(s.Text(), ":")
+
+		if len(fields) == 7 && fields[0] == username && fields[6] != "" {
+			return fields[6]
+		}
+	}
+
+	return defaultLoginShell
+}
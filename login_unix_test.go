@@ -0,0 +1,79 @@
+//go:build !windows
+
+package exec_test
+
+import (
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithLoginShell(t *testing.T) {
+	t.Parallel()
+
+	current, err := user.Current()
+	require.NoError(t, err)
+
+	out := newSafeBuffer()
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", `echo "$HOME $USER $LOGNAME"; pwd`),
+		exec.WithEnv("USER", "someone-else"),
+		exec.WithLoginShell(current.Username),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	lines := strings.Split(getOutput(out), "\n")
+	require.GreaterOrEqual(t, len(lines), 2)
+
+	assert.Equal(t, strings.Join([]string{current.HomeDir, current.Username, current.Username}, " "), lines[len(lines)-2])
+	assert.Equal(t, current.HomeDir, lines[len(lines)-1])
+
+	assert.True(t, strings.HasPrefix(cmd.Args[0], "-"))
+	assert.Equal(t, []string{"-c", `sh -c 'echo "$HOME $USER $LOGNAME"; pwd'`}, cmd.Args[1:])
+	assert.True(t, cmd.SysProcAttr.Setsid)
+}
+
+func TestWithLoginShell_Invalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		options  []exec.Option
+		expected string
+	}{
+		{
+			scenario: "unknown user",
+			options:  []exec.Option{exec.WithLoginShell("not-a-user")},
+			expected: `could not look up user "not-a-user": user: unknown user not-a-user`,
+		},
+		{
+			scenario: "empty user",
+			options:  []exec.Option{exec.WithLoginShell("")},
+			expected: "exec: conflicting options: WithLoginShell requires a user",
+		},
+		{
+			scenario: "backend",
+			options:  []exec.Option{exec.WithBackend(exec.SSH("example.com")), exec.WithLoginShell("root")},
+			expected: "exec: conflicting options: WithBackend and WithLoginShell both set the backend",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := exec.Command("true", tc.options...)
+
+			assert.EqualError(t, cmd.Err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package exec
+
+// WithLoginShell is not supported on Windows, the command fails with ErrOptionConflict.
+func WithLoginShell(string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.invalid("WithLoginShell is not supported on windows")
+	})
+}
+
+// WithControllingTerminal is not supported on Windows, the command fails with ErrOptionConflict.
+func WithControllingTerminal() Option {
+	return optionFunc(func(c *Cmd) {
+		c.invalid("WithControllingTerminal is not supported on windows")
+	})
+}
+
+func applyLoginShell(*Cmd) {}
@@ -0,0 +1,83 @@
+package exec
+
+import (
+	"context"
+
+	"github.com/bool64/ctxd"
+)
+
+// LogLevel is the level of the messages the commands log.
+type LogLevel int
+
+const (
+	// LogLevelDebug logs the messages with ctxd.Logger.Debug.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo logs the messages with ctxd.Logger.Info.
+	LogLevelInfo
+	// LogLevelImportant logs the messages with ctxd.Logger.Important.
+	LogLevelImportant
+	// LogLevelWarn logs the messages with ctxd.Logger.Warn.
+	LogLevelWarn
+	// LogLevelError logs the messages with ctxd.Logger.Error.
+	LogLevelError
+)
+
+// String returns the name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelImportant:
+		return "important"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+func (l LogLevel) logFunc(logger ctxd.Logger) func(ctx context.Context, msg string, keysAndValues ...any) {
+	switch l {
+	case LogLevelInfo:
+		return logger.Info
+	case LogLevelImportant:
+		return logger.Important
+	case LogLevelWarn:
+		return logger.Warn
+	case LogLevelError:
+		return logger.Error
+	}
+
+	return logger.Debug
+}
+
+// WithLogLevel sets the level of the messages the command logs when it fails, LogLevelDebug by default. Every next
+// stage of the pipeline inherits the level.
+func WithLogLevel(level LogLevel) Option {
+	return optionFunc(func(c *Cmd) {
+		c.logLevel = level
+	})
+}
+
+// WithLogSuccess logs every execution that succeeds at the info level, with the exec.command, exec.duration and
+// exec.exit_code fields. Every next stage of the pipeline inherits it.
+func WithLogSuccess(enabled bool) Option {
+	return optionFunc(func(c *Cmd) {
+		c.logSuccess = enabled
+	})
+}
+
+// WithoutLogging silences the logging of the command and of its pipeline, it is the same as
+// WithLogger(ctxd.NoOpLogger{}).
+func WithoutLogging() Option {
+	return WithLogger(ctxd.NoOpLogger{})
+}
+
+// logFailure logs a failure of the command at its level.
+func (c *Cmd) logFailure(ctx context.Context, msg string, keysAndValues ...any) {
+	c.logLevel.logFunc(c.logger)(ctx, msg, keysAndValues...)
+}
@@ -0,0 +1,112 @@
+package exec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bool64/ctxd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestLogLevel_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "debug", exec.LogLevelDebug.String())
+	assert.Equal(t, "info", exec.LogLevelInfo.String())
+	assert.Equal(t, "important", exec.LogLevelImportant.String())
+	assert.Equal(t, "warn", exec.LogLevelWarn.String())
+	assert.Equal(t, "error", exec.LogLevelError.String())
+	assert.Equal(t, "unknown", exec.LogLevel(42).String())
+}
+
+func TestWithLogLevel(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "exit 3"),
+		exec.WithLogger(logger),
+		exec.WithLogLevel(exec.LogLevelWarn),
+	)
+	require.Error(t, err)
+
+	require.Len(t, logger.LoggedEntries, 1)
+
+	assert.Equal(t, "warn", logger.LoggedEntries[0].Level)
+	assert.Equal(t, "failed to execute `sh`", logger.LoggedEntries[0].Message)
+}
+
+func TestWithLogLevel_NotFound(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("not_found", exec.WithLogger(logger), exec.WithLogLevel(exec.LogLevelError))
+	require.Error(t, err)
+
+	require.NotEmpty(t, logger.LoggedEntries)
+
+	for _, e := range logger.LoggedEntries {
+		assert.Equal(t, "error", e.Level, e.Message)
+	}
+}
+
+func TestWithLogSuccess(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithLogger(logger),
+		exec.WithLogSuccess(true),
+		exec.Pipe("cat"),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, logger.LoggedEntries, 2)
+
+	messages := make([]string, 0, 2)
+
+	for _, e := range logger.LoggedEntries {
+		messages = append(messages, e.Message)
+
+		assert.Equal(t, "info", e.Level)
+		assert.Equal(t, 0, e.Data["exec.exit_code"])
+		assert.IsType(t, time.Duration(0), e.Data["exec.duration"])
+		assert.NotEmpty(t, e.Data["exec.command"])
+	}
+
+	assert.ElementsMatch(t, []string{"executed `echo`", "executed `cat`"}, messages)
+}
+
+func TestWithLogSuccess_Disabled(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("echo", exec.WithLogger(logger), exec.WithLogSuccess(false))
+	require.NoError(t, err)
+
+	assert.Empty(t, logger.LoggedEntries)
+}
+
+func TestWithoutLogging(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "exit 3"),
+		exec.WithLogger(logger),
+		exec.WithLogSuccess(true),
+		exec.WithoutLogging(),
+	)
+	require.Error(t, err)
+
+	assert.Empty(t, logger.LoggedEntries)
+}
@@ -0,0 +1,148 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var lookPathCache atomic.Pointer[LookPathCache]
+
+// LookPathCache caches the resolution of the executables in the PATH. The cache is invalidated when the PATH
+// environment variable changes, and each entry expires after the TTL. The failed resolutions are not cached.
+type LookPathCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	path    string
+	entries map[string]lookPathEntry
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+type lookPathEntry struct {
+	path      string
+	expiresAt time.Time
+}
+
+// LookPathStats are the metrics of a LookPathCache.
+type LookPathStats struct {
+	// Hits is the number of resolutions served by the cache.
+	Hits uint64
+	// Misses is the number of resolutions that searched the PATH.
+	Misses uint64
+	// Invalidations is the number of times the cache has been emptied because the PATH changed.
+	Invalidations uint64
+}
+
+// NewLookPathCache creates a new LookPathCache, 0 means the entries do not expire.
+func NewLookPathCache(ttl time.Duration) *LookPathCache {
+	return &LookPathCache{
+		ttl:     ttl,
+		entries: make(map[string]lookPathEntry),
+	}
+}
+
+// SetLookPathCache makes LookPath and the new commands resolve the executables with the cache, nil disables the cache.
+// The cache is disabled by default.
+func SetLookPathCache(c *LookPathCache) {
+	lookPathCache.Store(c)
+}
+
+// LookPath is like the LookPath function of the package, with the cache.
+func (c *LookPathCache) LookPath(file string) (string, error) {
+	if filepath.Base(file) != file {
+		return exec.LookPath(file) //nolint: wrapcheck
+	}
+
+	env := os.Getenv("PATH")
+	now := time.Now()
+
+	c.mu.Lock()
+
+	if env != c.path {
+		if len(c.entries) > 0 {
+			c.entries = make(map[string]lookPathEntry)
+
+			c.invalidations.Add(1)
+		}
+
+		c.path = env
+	}
+
+	e, ok := c.entries[file]
+
+	c.mu.Unlock()
+
+	if ok && (e.expiresAt.IsZero() || now.Before(e.expiresAt)) {
+		c.hits.Add(1)
+
+		return e.path, nil
+	}
+
+	c.misses.Add(1)
+
+	lp, err := exec.LookPath(file)
+	if err != nil {
+		return lp, err //nolint: wrapcheck
+	}
+
+	e = lookPathEntry{path: lp}
+
+	if c.ttl > 0 {
+		e.expiresAt = now.Add(c.ttl)
+	}
+
+	c.mu.Lock()
+
+	if env == c.path {
+		c.entries[file] = e
+	}
+
+	c.mu.Unlock()
+
+	return lp, nil
+}
+
+// Invalidate empties the cache.
+func (c *LookPathCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]lookPathEntry)
+}
+
+// Stats returns the metrics of the cache.
+func (c *LookPathCache) Stats() LookPathStats {
+	return LookPathStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+}
+
+// newStdCmd creates the exec.Cmd of the named program, resolved with the LookPath cache when it is enabled.
+func newStdCmd(ctx context.Context, name string) *exec.Cmd {
+	cache := lookPathCache.Load()
+	if cache == nil || filepath.Base(name) != name {
+		return exec.CommandContext(ctx, name) //nolint: gosec
+	}
+
+	lp, err := cache.LookPath(name)
+
+	std := exec.CommandContext(ctx, lp) //nolint: gosec
+	std.Args[0] = name
+
+	if err != nil {
+		std.Path = name
+		std.Err = err
+	}
+
+	return std
+}
@@ -0,0 +1,97 @@
+package exec_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestLookPathCache(t *testing.T) {
+	t.Parallel()
+
+	c := exec.NewLookPathCache(0)
+
+	expected, err := exec.LookPath("echo")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		actual, err := c.LookPath("echo")
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual)
+	}
+
+	_, err = c.LookPath("not_found")
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+
+	_, err = c.LookPath("not_found")
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.Equal(t, exec.LookPathStats{Hits: 2, Misses: 3}, c.Stats())
+
+	c.Invalidate()
+
+	_, err = c.LookPath("echo")
+	require.NoError(t, err)
+
+	assert.Equal(t, exec.LookPathStats{Hits: 2, Misses: 4}, c.Stats())
+}
+
+func TestLookPathCache_TTL(t *testing.T) {
+	t.Parallel()
+
+	c := exec.NewLookPathCache(10 * time.Millisecond)
+
+	_, err := c.LookPath("echo")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = c.LookPath("echo")
+	require.NoError(t, err)
+
+	assert.Equal(t, exec.LookPathStats{Misses: 2}, c.Stats())
+}
+
+func TestSetLookPathCache(t *testing.T) { //nolint: paralleltest
+	c := exec.NewLookPathCache(0)
+
+	exec.SetLookPathCache(c)
+
+	defer exec.SetLookPathCache(nil)
+
+	for i := 0; i < 3; i++ {
+		out := newSafeBuffer()
+
+		_, err := exec.Run("echo", exec.WithArgs("hello"), exec.WithStdout(out))
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello", getOutput(out))
+	}
+
+	assert.Equal(t, exec.LookPathStats{Hits: 2, Misses: 1}, c.Stats())
+
+	cmd := exec.Command("echo")
+
+	assert.Equal(t, []string{"echo"}, cmd.Args)
+
+	// An executable that is not found is reported like without the cache.
+	_, err := exec.Run("not_found")
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.Equal(t, "not_found ", exec.Command("not_found").String())
+
+	// A change of PATH invalidates the cache.
+	t.Setenv("PATH", os.Getenv("PATH")+string(os.PathListSeparator)+t.TempDir())
+
+	_, err = exec.Run("echo", exec.WithStdout(newSafeBuffer()))
+	require.NoError(t, err)
+
+	assert.Equal(t, exec.LookPathStats{Hits: 3, Misses: 4, Invalidations: 1}, c.Stats())
+}
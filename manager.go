@@ -0,0 +1,416 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGracePeriod       = 5 * time.Second
+	defaultReadinessInterval = 100 * time.Millisecond
+)
+
+// ErrUnknownDependency indicates that a process depends on a process that is not registered in the Manager.
+var ErrUnknownDependency = errors.New("exec: unknown dependency")
+
+// ErrDependencyCycle indicates that the dependencies of the processes in a Manager form a cycle.
+var ErrDependencyCycle = errors.New("exec: dependency cycle")
+
+// ErrProcessExists indicates that a process with the same name is already registered in the Manager.
+var ErrProcessExists = errors.New("exec: process already exists")
+
+// ErrManagerStarted indicates that the Manager has already been started.
+var ErrManagerStarted = errors.New("exec: manager already started")
+
+// ReadinessCheck reports whether a started process is ready to serve. It is called repeatedly until it returns nil or
+// the process exits.
+type ReadinessCheck func(ctx context.Context) error
+
+// ProcessStatus is the lifecycle status of a managed process.
+type ProcessStatus string
+
+const (
+	// ProcessPending means the process has not been started yet.
+	ProcessPending ProcessStatus = "pending"
+	// ProcessStarting means the process has been started but is not ready yet.
+	ProcessStarting ProcessStatus = "starting"
+	// ProcessRunning means the process is running and ready.
+	ProcessRunning ProcessStatus = "running"
+	// ProcessStopping means the process is being stopped.
+	ProcessStopping ProcessStatus = "stopping"
+	// ProcessStopped means the process exited successfully or was stopped by the Manager.
+	ProcessStopped ProcessStatus = "stopped"
+	// ProcessFailed means the process could not be started or exited unexpectedly.
+	ProcessFailed ProcessStatus = "failed"
+)
+
+// ProcessHealth is the health of a managed process.
+type ProcessHealth struct {
+	Status ProcessStatus
+	Err    error
+}
+
+// Health is the aggregate health of the processes in a Manager.
+type Health struct {
+	Healthy   bool
+	Processes map[string]ProcessHealth
+}
+
+// Manager starts a group of long-running commands in the order of their dependencies and stops them in the reverse
+// order.
+type Manager struct {
+	mu        sync.Mutex
+	processes map[string]*managedProcess
+	order     []string
+	started   bool
+	stopping  bool
+	wg        sync.WaitGroup
+}
+
+type managedProcess struct {
+	name        string
+	cmd         *Cmd
+	dependsOn   []string
+	ready       ReadinessCheck
+	interval    time.Duration
+	gracePeriod time.Duration
+
+	status  ProcessStatus
+	err     error
+	started bool
+	exited  chan struct{}
+}
+
+// NewManager creates a new Manager.
+func NewManager() *Manager {
+	return &Manager{
+		processes: make(map[string]*managedProcess),
+	}
+}
+
+// Add registers a command under the given name. The command must not be started.
+func (m *Manager) Add(name string, cmd *Cmd, opts ...ProcessOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return ErrManagerStarted
+	}
+
+	if _, ok := m.processes[name]; ok {
+		return fmt.Errorf("%w: %s", ErrProcessExists, name)
+	}
+
+	p := &managedProcess{
+		name:        name,
+		cmd:         cmd,
+		interval:    defaultReadinessInterval,
+		gracePeriod: defaultGracePeriod,
+		status:      ProcessPending,
+		exited:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.applyProcessOption(p)
+	}
+
+	m.processes[name] = p
+
+	return nil
+}
+
+// Start starts all the processes, a process is only started when all of its dependencies are ready. If a process can
+// not be started or does not become ready, the processes that have been started are stopped and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+
+	if m.started {
+		m.mu.Unlock()
+
+		return ErrManagerStarted
+	}
+
+	order, err := m.resolveOrder()
+	if err != nil {
+		m.mu.Unlock()
+
+		return err
+	}
+
+	m.started = true
+	m.order = order
+
+	m.mu.Unlock()
+
+	for _, name := range order {
+		if err := m.startProcess(ctx, m.processes[name]); err != nil {
+			_ = m.Stop(context.Background()) //nolint: errcheck,contextcheck
+
+			return fmt.Errorf("could not start %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) startProcess(ctx context.Context, p *managedProcess) error {
+	if p.cmd.Err != nil {
+		m.setStatus(p, ProcessFailed, p.cmd.Err)
+
+		return p.cmd.Err
+	}
+
+	m.setStatus(p, ProcessStarting, nil)
+
+	if err := p.cmd.Start(); err != nil {
+		m.setStatus(p, ProcessFailed, err)
+
+		return err
+	}
+
+	m.mu.Lock()
+	p.started = true
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		defer close(p.exited)
+
+		err := p.cmd.Wait()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		switch {
+		case p.status == ProcessFailed:
+
+		case m.stopping || p.status == ProcessStopping:
+			p.status = ProcessStopped
+
+		case err != nil:
+			p.status, p.err = ProcessFailed, err
+
+		default:
+			p.status = ProcessStopped
+		}
+	}()
+
+	if p.ready == nil {
+		m.setStatus(p, ProcessRunning, nil)
+
+		return nil
+	}
+
+	if err := m.waitReady(ctx, p); err != nil {
+		m.setStatus(p, ProcessFailed, err)
+
+		return err
+	}
+
+	m.setStatus(p, ProcessRunning, nil)
+
+	return nil
+}
+
+func (m *Manager) waitReady(ctx context.Context, p *managedProcess) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		err := p.ready(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-p.exited:
+			return errors.New("exited before becoming ready") //nolint: goerr113
+
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) setStatus(p *managedProcess, status ProcessStatus, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p.status == ProcessStopped || p.status == ProcessFailed {
+		return
+	}
+
+	p.status, p.err = status, err
+}
+
+// Stop stops the running processes in the reverse order of their dependencies. Each process is given its grace period
+// to exit before being killed. The errors of all the processes that could not be stopped are returned in a MultiError.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopping = true
+
+	running := make([]*managedProcess, 0, len(m.order))
+
+	for i := len(m.order) - 1; i >= 0; i-- {
+		if p := m.processes[m.order[i]]; p.started {
+			running = append(running, p)
+		}
+	}
+
+	m.mu.Unlock()
+
+	var errs MultiError
+
+	for _, p := range running {
+		m.setStatus(p, ProcessStopping, nil)
+
+		if err := p.cmd.Stop(ctx, p.gracePeriod); err != nil {
+			errs = append(errs, fmt.Errorf("could not stop %s: %w", p.name, err))
+
+			continue
+		}
+
+		<-p.exited
+	}
+
+	m.wg.Wait()
+
+	return errs.errorOrNil()
+}
+
+// Wait waits for all the started processes to exit and returns the error of the first process that failed.
+func (m *Manager) Wait() error {
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range m.order {
+		if p := m.processes[name]; p.err != nil {
+			return fmt.Errorf("%s: %w", name, p.err)
+		}
+	}
+
+	return nil
+}
+
+// Health returns the aggregate health of the processes. The Manager is healthy when every process is running.
+func (m *Manager) Health() Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := Health{
+		Healthy:   len(m.processes) > 0,
+		Processes: make(map[string]ProcessHealth, len(m.processes)),
+	}
+
+	for name, p := range m.processes {
+		h.Processes[name] = ProcessHealth{Status: p.status, Err: p.err}
+
+		if p.status != ProcessRunning {
+			h.Healthy = false
+		}
+	}
+
+	return h
+}
+
+// resolveOrder sorts the processes topologically, the result is stable in the order of registration names.
+func (m *Manager) resolveOrder() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	names := make([]string, 0, len(m.processes))
+	for name := range m.processes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string) error
+
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, name)
+
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+
+		for _, dep := range m.processes[name].dependsOn {
+			if _, ok := m.processes[dep]; !ok {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, name, dep)
+			}
+
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+		order = append(order, name)
+
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// ProcessOption is an option to configure a process in a Manager.
+type ProcessOption interface {
+	applyProcessOption(p *managedProcess)
+}
+
+type processOptionFunc func(p *managedProcess)
+
+func (f processOptionFunc) applyProcessOption(p *managedProcess) {
+	f(p)
+}
+
+// DependsOn declares the processes that must be ready before the process is started.
+func DependsOn(names ...string) ProcessOption {
+	return processOptionFunc(func(p *managedProcess) {
+		p.dependsOn = append(p.dependsOn, names...)
+	})
+}
+
+// WithReadinessCheck sets the check that tells whether the process is ready, and the interval between two checks.
+func WithReadinessCheck(check ReadinessCheck, interval time.Duration) ProcessOption {
+	return processOptionFunc(func(p *managedProcess) {
+		p.ready = check
+
+		if interval > 0 {
+			p.interval = interval
+		}
+	})
+}
+
+// WithGracePeriod sets how long the process is given to exit after being asked to stop before it is killed.
+func WithGracePeriod(d time.Duration) ProcessOption {
+	return processOptionFunc(func(p *managedProcess) {
+		p.gracePeriod = d
+	})
+}
@@ -0,0 +1,156 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestManager_Start_UnknownDependency(t *testing.T) {
+	t.Parallel()
+
+	m := exec.NewManager()
+
+	err := m.Add("web", exec.Command("sleep", exec.WithArgs("10")), exec.DependsOn("db"))
+	require.NoError(t, err)
+
+	err = m.Start(context.Background())
+
+	assert.ErrorIs(t, err, exec.ErrUnknownDependency)
+}
+
+func TestManager_Start_DependencyCycle(t *testing.T) {
+	t.Parallel()
+
+	m := exec.NewManager()
+
+	require.NoError(t, m.Add("a", exec.Command("sleep", exec.WithArgs("10")), exec.DependsOn("b")))
+	require.NoError(t, m.Add("b", exec.Command("sleep", exec.WithArgs("10")), exec.DependsOn("a")))
+
+	err := m.Start(context.Background())
+
+	assert.ErrorIs(t, err, exec.ErrDependencyCycle)
+}
+
+func TestManager_Add_Duplicated(t *testing.T) {
+	t.Parallel()
+
+	m := exec.NewManager()
+
+	require.NoError(t, m.Add("a", exec.Command("sleep", exec.WithArgs("10"))))
+
+	err := m.Add("a", exec.Command("sleep", exec.WithArgs("10")))
+
+	assert.ErrorIs(t, err, exec.ErrProcessExists)
+}
+
+func TestManager_StartAndStop(t *testing.T) {
+	t.Parallel()
+
+	marker := filepath.Join(t.TempDir(), "db.ready")
+
+	m := exec.NewManager()
+
+	err := m.Add("web", exec.Command("sleep", exec.WithArgs("10")),
+		exec.DependsOn("db"),
+		exec.WithGracePeriod(time.Second),
+	)
+	require.NoError(t, err)
+
+	err = m.Add("db", exec.Command("sh", exec.WithArgs("-c", `touch "$0"; exec sleep 10`, marker)),
+		exec.WithReadinessCheck(func(context.Context) error {
+			_, err := os.Stat(marker)
+
+			return err
+		}, 10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	err = m.Start(context.Background())
+	require.NoError(t, err)
+
+	health := m.Health()
+
+	assert.True(t, health.Healthy)
+	assert.Equal(t, exec.ProcessRunning, health.Processes["db"].Status)
+	assert.Equal(t, exec.ProcessRunning, health.Processes["web"].Status)
+
+	err = m.Stop(context.Background())
+	require.NoError(t, err)
+
+	health = m.Health()
+
+	assert.False(t, health.Healthy)
+	assert.Equal(t, exec.ProcessStopped, health.Processes["db"].Status)
+	assert.Equal(t, exec.ProcessStopped, health.Processes["web"].Status)
+	assert.NoError(t, m.Wait())
+}
+
+func TestManager_Start_NotReady(t *testing.T) {
+	t.Parallel()
+
+	m := exec.NewManager()
+
+	err := m.Add("db", exec.Command("sh", exec.WithArgs("-c", "exit 1")),
+		exec.WithReadinessCheck(func(context.Context) error {
+			return errors.New("not ready")
+		}, 10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	err = m.Start(context.Background())
+
+	assert.EqualError(t, err, "could not start db: exited before becoming ready")
+	assert.Equal(t, exec.ProcessFailed, m.Health().Processes["db"].Status)
+}
+
+func TestManager_Health_ProcessExited(t *testing.T) {
+	t.Parallel()
+
+	m := exec.NewManager()
+
+	require.NoError(t, m.Add("job", exec.Command("sh", exec.WithArgs("-c", "exit 3"))))
+	require.NoError(t, m.Start(context.Background()))
+
+	err := m.Wait()
+
+	assert.EqualError(t, err, "job: exit status 3")
+	assert.False(t, m.Health().Healthy)
+	assert.Equal(t, exec.ProcessFailed, m.Health().Processes["job"].Status)
+}
+
+func TestManager_Stop_WhileStarting(t *testing.T) {
+	t.Parallel()
+
+	m := exec.NewManager()
+
+	err := m.Add("db", exec.Command("sleep", exec.WithArgs("10")),
+		exec.WithReadinessCheck(func(context.Context) error {
+			return errors.New("not ready") //nolint: goerr113
+		}, 10*time.Millisecond),
+		exec.WithGracePeriod(time.Second),
+	)
+	require.NoError(t, err)
+
+	started := make(chan error, 1)
+
+	go func() {
+		started <- m.Start(context.Background())
+	}()
+
+	// The process is stopped while the manager waits for it to become ready.
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, m.Stop(context.Background()))
+
+	assert.Error(t, <-started)
+	assert.Equal(t, exec.ProcessStopped, m.Health().Processes["db"].Status)
+}
@@ -0,0 +1,118 @@
+package exec
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Map runs the commands built from the inputs concurrently with at most the given number of workers. If workers is not
+// positive, the number of CPUs is used.
+//
+// The results have the same order as the inputs. By default, every command is executed and the returned error
+// aggregates all the failures in a MultiError. With FailFast, the first failure cancels the others and is returned.
+func Map(ctx context.Context, inputs []string, buildCmd func(input string) Spec, workers int, opts ...BatchOption) ([]Result, error) {
+	return runPool(ctx, len(inputs), func(i int) Spec {
+		return buildCmd(inputs[i])
+	}, workers, newBatchConfig(opts...))
+}
+
+func runPool(ctx context.Context, size int, buildCmd func(i int) Spec, workers int, cfg *batchConfig) ([]Result, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		results  = make([]Result, size)
+		jobs     = make(chan int)
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for i := 0; i < workers && i < size; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				// A job may be received while the pool is being canceled, it is not started.
+				if err := ctx.Err(); err != nil {
+					results[idx] = Result{ExitCode: -1, Err: err}
+
+					continue
+				}
+
+				results[idx] = runSpec(ctx, buildCmd(idx))
+
+				if results[idx].Err != nil && cfg.failFast {
+					mu.Lock()
+
+					if firstErr == nil {
+						firstErr = results[idx].Err
+
+						cancel()
+					}
+
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	// The cancellation is checked before each send, select picks a ready case at random and would keep dispatching to
+	// an idle worker.
+	for idx := 0; idx < size; idx++ {
+		if ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case jobs <- idx:
+				continue
+			}
+		}
+
+		for i := idx; i < size; i++ {
+			results[i] = Result{ExitCode: -1, Err: ctx.Err()}
+		}
+
+		break
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if cfg.failFast {
+		if firstErr != nil {
+			return results, firstErr
+		}
+
+		return results, ctx.Err()
+	}
+
+	return results, collectErrors(results)
+}
+
+func runSpec(ctx context.Context, spec Spec) Result {
+	cmd := spec.Command(ctx)
+	if cmd.Err != nil {
+		return newResult(cmd, cmd.Err)
+	}
+
+	return newResult(cmd, cmd.Run())
+}
+
+func collectErrors(results []Result) error {
+	var errs MultiError
+
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+
+	return errs.errorOrNil()
+}
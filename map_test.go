@@ -0,0 +1,114 @@
+package exec_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestMap_Success(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{"a", "b", "c", "d"}
+	outputs := make(map[string]*safeBuffer, len(inputs))
+
+	for _, in := range inputs {
+		outputs[in] = newSafeBuffer()
+	}
+
+	results, err := exec.Map(context.Background(), inputs, func(input string) exec.Spec {
+		return exec.Spec{
+			Name:    "echo",
+			Options: []exec.Option{exec.WithArgs(strings.ToUpper(input)), exec.WithStdout(outputs[input])},
+		}
+	}, 2)
+	require.NoError(t, err)
+	require.Len(t, results, len(inputs))
+
+	for i, in := range inputs {
+		assert.NoError(t, results[i].Err)
+		assert.Equal(t, 0, results[i].ExitCode)
+		assert.Equal(t, strings.ToUpper(in), getOutput(outputs[in]))
+	}
+}
+
+func TestMap_ContinueOnError(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{"0", "1", "0", "2"}
+
+	results, err := exec.Map(context.Background(), inputs, func(input string) exec.Spec {
+		return exec.Spec{
+			Name:    "sh",
+			Options: []exec.Option{exec.WithArgs("-c", "echo >&2 oops; exit "+input)},
+		}
+	}, 0)
+
+	assert.EqualError(t, err, "exit status 1\nexit status 2")
+
+	expected := []int{0, 1, 0, 2}
+
+	for i, code := range expected {
+		assert.Equal(t, code, results[i].ExitCode)
+		assert.Equal(t, "oops", results[i].Stderr)
+	}
+}
+
+func TestMap_FailFast(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{"exit 1", "sleep 10", "sleep 10", "sleep 10"}
+
+	results, err := exec.Map(context.Background(), inputs, func(input string) exec.Spec {
+		return exec.Spec{
+			Name:    "sh",
+			Options: []exec.Option{exec.WithArgs("-c", input)},
+		}
+	}, 1, exec.FailFast())
+
+	assert.EqualError(t, err, "exit status 1")
+	assert.Equal(t, 1, results[0].ExitCode)
+
+	for _, r := range results[1:] {
+		assert.ErrorIs(t, r.Err, context.Canceled)
+	}
+}
+
+func TestMap_FailFast_StopsDispatching(t *testing.T) {
+	t.Parallel()
+
+	inputs := make([]string, 50)
+
+	var built int32
+
+	results, err := exec.Map(context.Background(), inputs, func(string) exec.Spec {
+		atomic.AddInt32(&built, 1)
+
+		return exec.Spec{Name: "false"}
+	}, 1, exec.FailFast())
+
+	assert.EqualError(t, err, "exit status 1")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&built))
+
+	for _, r := range results[1:] {
+		assert.ErrorIs(t, r.Err, context.Canceled)
+	}
+}
+
+func TestMap_LookupError(t *testing.T) {
+	t.Parallel()
+
+	results, err := exec.Map(context.Background(), []string{"x"}, func(string) exec.Spec {
+		return exec.Spec{Name: "not_found"}
+	}, 1)
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.ErrorIs(t, results[0].Err, exec.ErrNotFound)
+	assert.Equal(t, -1, results[0].ExitCode)
+}
@@ -0,0 +1,93 @@
+package exec
+
+import (
+	"path/filepath"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// instrumentationName is the name of the meter of the package.
+const instrumentationName = "go.nhat.io/exec"
+
+// meters are the instruments of every meter provider, they are created once per provider.
+var meters sync.Map
+
+// WithMeterProvider records the metrics of the executions with the meter provider, the stages of the pipeline inherit
+// it:
+//
+//   - exec.duration, a histogram of the time elapsed between the start and the exit of the processes, in seconds.
+//   - exec.runs, a counter of the executions.
+//   - exec.in_flight, the number of processes that are running.
+//
+// The measurements have the name of the executable as exec.command, and the exit code as exec.exit_code, -1 if the
+// process could not be started or was terminated by a signal.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return optionFunc(func(c *Cmd) {
+		c.metrics = newExecMetrics(mp)
+	})
+}
+
+// execMetrics are the instruments of a meter provider.
+type execMetrics struct {
+	duration metric.Float64Histogram
+	runs     metric.Int64Counter
+	inFlight metric.Int64UpDownCounter
+}
+
+func newExecMetrics(mp metric.MeterProvider) *execMetrics {
+	if m, ok := meters.Load(mp); ok {
+		return m.(*execMetrics) //nolint: forcetypeassert
+	}
+
+	meter := mp.Meter(instrumentationName)
+
+	// The errors are ignored, a meter always returns a usable instrument.
+	duration, _ := meter.Float64Histogram("exec.duration", //nolint: errcheck
+		metric.WithDescription("The duration of the executions."),
+		metric.WithUnit("s"),
+	)
+	runs, _ := meter.Int64Counter("exec.runs", //nolint: errcheck
+		metric.WithDescription("The number of executions."),
+	)
+	inFlight, _ := meter.Int64UpDownCounter("exec.in_flight", //nolint: errcheck
+		metric.WithDescription("The number of processes that are running."),
+	)
+
+	m, _ := meters.LoadOrStore(mp, &execMetrics{duration: duration, runs: runs, inFlight: inFlight})
+
+	return m.(*execMetrics) //nolint: forcetypeassert
+}
+
+func (c *Cmd) metricCommand() attribute.KeyValue {
+	return attribute.String("exec.command", filepath.Base(c.Path))
+}
+
+// recordStarted records a process that has started.
+func (c *Cmd) recordStarted() {
+	if c.metrics == nil {
+		return
+	}
+
+	c.metrics.inFlight.Add(c.ctx, 1, metric.WithAttributes(c.metricCommand()))
+}
+
+// recordExited records an execution once the process has exited, or could not be started.
+func (c *Cmd) recordExited() {
+	if c.metrics == nil {
+		return
+	}
+
+	command := c.metricCommand()
+	attrs := metric.WithAttributes(command, attribute.Int("exec.exit_code", c.ProcessState.ExitCode()))
+
+	c.metrics.runs.Add(c.ctx, 1, attrs)
+
+	if c.ProcessState == nil {
+		return
+	}
+
+	c.metrics.inFlight.Add(c.ctx, -1, metric.WithAttributes(command))
+	c.metrics.duration.Record(c.ctx, c.duration.Seconds(), attrs)
+}
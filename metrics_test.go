@@ -0,0 +1,154 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"go.nhat.io/exec"
+)
+
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	metrics := make(map[string]metricdata.Aggregation)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	return metrics
+}
+
+func TestWithMeterProvider(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	_, err := exec.Run("echo", exec.WithMeterProvider(mp), exec.WithArgs("hello"))
+	require.NoError(t, err)
+
+	_, err = exec.Run("sh", exec.WithMeterProvider(mp), exec.WithArgs("-c", "exit 3"))
+	require.Error(t, err)
+
+	metrics := collectMetrics(t, reader)
+
+	runs, ok := metrics["exec.runs"].(metricdata.Sum[int64])
+	require.True(t, ok)
+
+	counts := make(map[attribute.Distinct]int64)
+
+	for _, dp := range runs.DataPoints {
+		counts[dp.Attributes.Equivalent()] = dp.Value
+	}
+
+	echo := attribute.NewSet(attribute.String("exec.command", "echo"), attribute.Int("exec.exit_code", 0))
+	sh := attribute.NewSet(attribute.String("exec.command", "sh"), attribute.Int("exec.exit_code", 3))
+
+	assert.Equal(t, map[attribute.Distinct]int64{echo.Equivalent(): 1, sh.Equivalent(): 1}, counts)
+
+	duration, ok := metrics["exec.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, duration.DataPoints, 2)
+
+	for _, dp := range duration.DataPoints {
+		assert.Equal(t, uint64(1), dp.Count)
+		assert.Positive(t, dp.Sum)
+	}
+
+	inFlight, ok := metrics["exec.in_flight"].(metricdata.Sum[int64])
+	require.True(t, ok)
+
+	for _, dp := range inFlight.DataPoints {
+		assert.Zero(t, dp.Value)
+	}
+}
+
+func TestWithMeterProvider_InFlight(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cmd := exec.Command("sleep", exec.WithMeterProvider(mp), exec.WithArgs("1"))
+
+	require.NoError(t, cmd.Start())
+
+	inFlight, ok := collectMetrics(t, reader)["exec.in_flight"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, inFlight.DataPoints, 1)
+
+	assert.Equal(t, int64(1), inFlight.DataPoints[0].Value)
+	assert.Equal(t, attribute.NewSet(attribute.String("exec.command", "sleep")), inFlight.DataPoints[0].Attributes)
+
+	require.NoError(t, cmd.Wait())
+
+	inFlight, ok = collectMetrics(t, reader)["exec.in_flight"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, inFlight.DataPoints, 1)
+
+	assert.Zero(t, inFlight.DataPoints[0].Value)
+}
+
+func TestWithMeterProvider_NotStarted(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cmd := exec.Command("sh", exec.WithMeterProvider(mp), exec.WithDir("/does/not/exist"))
+
+	require.Error(t, cmd.Run())
+
+	metrics := collectMetrics(t, reader)
+
+	runs, ok := metrics["exec.runs"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, runs.DataPoints, 1)
+
+	assert.Equal(t, int64(1), runs.DataPoints[0].Value)
+	assert.Equal(t,
+		attribute.NewSet(attribute.String("exec.command", "sh"), attribute.Int("exec.exit_code", -1)),
+		runs.DataPoints[0].Attributes,
+	)
+
+	assert.NotContains(t, metrics, "exec.duration")
+}
+
+func TestWithMeterProvider_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	_, err := exec.Run("echo",
+		exec.WithMeterProvider(mp),
+		exec.WithArgs("hello"),
+		exec.Pipe("cat"),
+	)
+	require.NoError(t, err)
+
+	runs, ok := collectMetrics(t, reader)["exec.runs"].(metricdata.Sum[int64])
+	require.True(t, ok)
+
+	commands := make([]string, 0, len(runs.DataPoints))
+
+	for _, dp := range runs.DataPoints {
+		v, _ := dp.Attributes.Value("exec.command")
+		commands = append(commands, v.AsString())
+	}
+
+	assert.ElementsMatch(t, []string{"echo", "cat"}, commands)
+}
@@ -0,0 +1,316 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var muxColors = []string{
+	"\x1b[36m", // cyan
+	"\x1b[33m", // yellow
+	"\x1b[32m", // green
+	"\x1b[35m", // magenta
+	"\x1b[34m", // blue
+	"\x1b[31m", // red
+}
+
+const muxColorReset = "\x1b[0m"
+
+// MultiWriterMux merges the output of many commands into one writer. Every line is prefixed with the name of the
+// command that wrote it and is written atomically, so the lines of concurrent commands never interleave.
+type MultiWriterMux struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	color    bool
+	ringSize int
+	width    int
+
+	writers    map[string]*muxWriter
+	errWriters map[string]*muxWriter
+	order      []string
+}
+
+// NewMultiWriterMux creates a new MultiWriterMux that writes to w.
+func NewMultiWriterMux(w io.Writer, opts ...MuxOption) *MultiWriterMux {
+	m := &MultiWriterMux{
+		w:          w,
+		writers:    make(map[string]*muxWriter),
+		errWriters: make(map[string]*muxWriter),
+	}
+
+	for _, opt := range opts {
+		opt.applyMuxOption(m)
+	}
+
+	return m
+}
+
+// Writer returns the writer for the given name. Calling Writer with the same name returns the same writer.
+func (m *MultiWriterMux) Writer(name string) io.WriteCloser {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.writerLocked(name)
+}
+
+// errWriter returns a writer that shares the prefix and the ring buffer of the writer of the given name but keeps its
+// own incomplete line, so the standard output and error of a command do not get mixed within a line.
+func (m *MultiWriterMux) errWriter(name string) io.WriteCloser {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if w, ok := m.errWriters[name]; ok {
+		return w
+	}
+
+	w := *m.writerLocked(name)
+	w.buf = nil
+
+	m.errWriters[name] = &w
+
+	return &w
+}
+
+func (m *MultiWriterMux) writerLocked(name string) *muxWriter {
+	if w, ok := m.writers[name]; ok {
+		return w
+	}
+
+	w := &muxWriter{
+		mux:  m,
+		name: name,
+	}
+
+	if m.ringSize > 0 {
+		w.ring = newRingBuffer(m.ringSize)
+	}
+
+	if m.color {
+		w.color = muxColors[len(m.order)%len(muxColors)]
+	}
+
+	if len(name) > m.width {
+		m.width = len(name)
+	}
+
+	m.writers[name] = w
+	m.order = append(m.order, name)
+
+	return w
+}
+
+// Tail returns the last lines written by the given name, it is empty unless WithMuxRingBuffer is used.
+func (m *MultiWriterMux) Tail(name string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.writers[name]
+	if !ok || w.ring == nil {
+		return nil
+	}
+
+	return w.ring.lines()
+}
+
+// Flush writes the incomplete lines of all the writers.
+func (m *MultiWriterMux) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range m.order {
+		if err := m.writers[name].flushLocked(); err != nil {
+			return err
+		}
+
+		if w, ok := m.errWriters[name]; ok {
+			if err := w.flushLocked(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *MultiWriterMux) writeLine(w *muxWriter, line []byte) error {
+	buf := new(bytes.Buffer)
+
+	if w.color != "" {
+		buf.WriteString(w.color)
+	}
+
+	fmt.Fprintf(buf, "%-*s | ", m.width, w.name)
+
+	if w.color != "" {
+		buf.WriteString(muxColorReset)
+	}
+
+	buf.Write(line)
+	buf.WriteByte('\n')
+
+	if w.ring != nil {
+		w.ring.add(string(line))
+	}
+
+	_, err := m.w.Write(buf.Bytes())
+
+	return err //nolint: wrapcheck
+}
+
+type muxWriter struct {
+	mux   *MultiWriterMux
+	name  string
+	color string
+	ring  *ringBuffer
+	buf   []byte
+}
+
+// Write writes the complete lines to the mux and keeps the incomplete one until the next write.
+func (w *muxWriter) Write(p []byte) (int, error) {
+	w.mux.mu.Lock()
+	defer w.mux.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	var start int
+
+	defer func() {
+		// The incomplete line is moved to the beginning of the buffer, so the lines that have been written do not stay
+		// in memory.
+		w.buf = w.buf[:copy(w.buf, w.buf[start:])]
+	}()
+
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+
+		line := bytes.TrimSuffix(w.buf[start:start+i], []byte{'\r'})
+
+		if err := w.mux.writeLine(w, line); err != nil {
+			return 0, err
+		}
+
+		start += i + 1
+	}
+
+	return len(p), nil
+}
+
+// Close writes the incomplete line to the mux.
+func (w *muxWriter) Close() error {
+	w.mux.mu.Lock()
+	defer w.mux.mu.Unlock()
+
+	return w.flushLocked()
+}
+
+func (w *muxWriter) flushLocked() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := w.buf
+	w.buf = nil
+
+	return w.mux.writeLine(w, line)
+}
+
+type ringBuffer struct {
+	buf  []string
+	next int
+	full bool
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{buf: make([]string, size)}
+}
+
+func (r *ringBuffer) add(line string) {
+	r.buf[r.next] = line
+	r.next = (r.next + 1) % len(r.buf)
+
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *ringBuffer) lines() []string {
+	if !r.full {
+		return append([]string(nil), r.buf[:r.next]...)
+	}
+
+	return append(append([]string(nil), r.buf[r.next:]...), r.buf[:r.next]...)
+}
+
+// MuxOption is an option to configure a MultiWriterMux.
+type MuxOption interface {
+	applyMuxOption(m *MultiWriterMux)
+}
+
+type muxOptionFunc func(m *MultiWriterMux)
+
+func (f muxOptionFunc) applyMuxOption(m *MultiWriterMux) {
+	f(m)
+}
+
+// WithMuxColor colorizes the prefixes, each name gets its own color.
+func WithMuxColor() MuxOption {
+	return muxOptionFunc(func(m *MultiWriterMux) {
+		m.color = true
+	})
+}
+
+// WithMuxRingBuffer keeps the last n lines of each name in memory, they can be retrieved with MultiWriterMux.Tail.
+func WithMuxRingBuffer(n int) MuxOption {
+	return muxOptionFunc(func(m *MultiWriterMux) {
+		m.ringSize = n
+	})
+}
+
+// WithMuxPrefixWidth sets the minimum width of the prefixes, so the output is aligned when the names are known upfront.
+func WithMuxPrefixWidth(n int) MuxOption {
+	return muxOptionFunc(func(m *MultiWriterMux) {
+		m.width = n
+	})
+}
+
+// WithOutputMux writes the standard output and error of the command to the mux under the given name. The last lines
+// are written even if they do not end with a line break once the command and the stages of its pipeline, which share
+// the writers, have exited.
+func WithOutputMux(m *MultiWriterMux, name string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard output", "WithOutputMux")
+		c.claim("standard error", "WithOutputMux")
+
+		stdout, stderr := m.Writer(name), m.errWriter(name)
+
+		c.Stdout = stdout
+		c.Stderr = stderr
+
+		c.addHook(hook{
+			afterPipeline: func(_ *Cmd, err error) error {
+				outErr := stdout.Close()
+				errErr := stderr.Close()
+
+				if err != nil {
+					return err
+				}
+
+				if outErr != nil {
+					return fmt.Errorf("could not flush output mux: %w", outErr)
+				}
+
+				if errErr != nil {
+					return fmt.Errorf("could not flush output mux: %w", errErr)
+				}
+
+				return nil
+			},
+		})
+	})
+}
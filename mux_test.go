@@ -0,0 +1,101 @@
+package exec_test
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestMultiWriterMux_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	mux := exec.NewMultiWriterMux(out, exec.WithMuxPrefixWidth(5))
+
+	var wg sync.WaitGroup
+
+	for _, name := range []string{"a", "b", "c"} {
+		wg.Add(1)
+
+		go func(name string) {
+			defer wg.Done()
+
+			_, err := exec.Run("sh",
+				exec.WithArgs("-c", `for i in 1 2 3; do echo "line $i"; done; printf "partial" >&2`),
+				exec.WithOutputMux(mux, name),
+			)
+			assert.NoError(t, err)
+		}(name)
+	}
+
+	wg.Wait()
+
+	require.NoError(t, mux.Flush())
+
+	lines := strings.Split(getOutput(out), "\n")
+	sort.Strings(lines)
+
+	expected := []string{
+		"a     | line 1", "a     | line 2", "a     | line 3", "a     | partial",
+		"b     | line 1", "b     | line 2", "b     | line 3", "b     | partial",
+		"c     | line 1", "c     | line 2", "c     | line 3", "c     | partial",
+	}
+
+	assert.Equal(t, expected, lines)
+}
+
+func TestMultiWriterMux_Color(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	mux := exec.NewMultiWriterMux(out, exec.WithMuxColor())
+
+	_, _ = mux.Writer("web").Write([]byte("hello\r\n")) //nolint: errcheck
+	_, _ = mux.Writer("db").Write([]byte("world\n"))    //nolint: errcheck
+
+	expected := "\x1b[36mweb | \x1b[0mhello\n\x1b[33mdb  | \x1b[0mworld"
+
+	assert.Equal(t, expected, getOutput(out))
+}
+
+func TestMultiWriterMux_Tail(t *testing.T) {
+	t.Parallel()
+
+	mux := exec.NewMultiWriterMux(newSafeBuffer(), exec.WithMuxRingBuffer(2))
+
+	w := mux.Writer("job")
+
+	_, _ = w.Write([]byte("1\n2\n3\n4")) //nolint: errcheck
+
+	assert.Equal(t, []string{"2", "3"}, mux.Tail("job"))
+
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"3", "4"}, mux.Tail("job"))
+	assert.Empty(t, mux.Tail("unknown"))
+}
+
+func TestWithOutputMux_PartialLines(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	mux := exec.NewMultiWriterMux(out)
+
+	// The partial lines of both streams interleave, the last ones do not end with a line break.
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", `printf "out "; sleep 0.05; printf "err " >&2; sleep 0.05; `+
+			`printf "line\n"; sleep 0.05; printf "line\n" >&2; sleep 0.05; printf "last"; printf "oops" >&2`),
+		exec.WithOutputMux(mux, "sh"),
+	)
+	require.NoError(t, err)
+
+	lines := strings.Split(getOutput(out), "\n")
+
+	assert.ElementsMatch(t, []string{"sh | out line", "sh | err line", "sh | last", "sh | oops"}, lines)
+}
@@ -0,0 +1,141 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// EventType is the type of an execution event.
+type EventType string
+
+const (
+	// EventStart is emitted when the process has started.
+	EventStart EventType = "start"
+	// EventSuccess is emitted when the process has exited successfully.
+	EventSuccess EventType = "success"
+	// EventFailure is emitted when the process could not start or has exited with an error.
+	EventFailure EventType = "failure"
+)
+
+// Event is an execution event sent to the notifiers.
+type Event struct {
+	Type   EventType    `json:"type"`
+	Time   time.Time    `json:"time"`
+	Record ResultRecord `json:"result"`
+}
+
+// Notifier receives the execution events.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// NotifierFunc is a function that implements Notifier.
+type NotifierFunc func(ctx context.Context, e Event) error
+
+// Notify calls the function.
+func (f NotifierFunc) Notify(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// ChannelNotifier sends the events to the channel. The send blocks until the channel is ready or the context is done.
+func ChannelNotifier(ch chan<- Event) Notifier {
+	return NotifierFunc(func(ctx context.Context, e Event) error {
+		select {
+		case ch <- e:
+			return nil
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// WebhookNotifier posts the events as JSON to the URL. A nil client means http.DefaultClient.
+func WebhookNotifier(url string, client *http.Client, events ...EventType) Notifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return NotifierFunc(func(ctx context.Context, e Event) error {
+		if !hasEventType(events, e.Type) {
+			return nil
+		}
+
+		body, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("could not encode event: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("could not create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("could not send event: %w", err)
+		}
+
+		defer resp.Body.Close() //nolint: errcheck
+
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("could not send event: unexpected status %s", resp.Status) //nolint: goerr113
+		}
+
+		return nil
+	})
+}
+
+func hasEventType(events []EventType, t EventType) bool {
+	if len(events) == 0 {
+		return true
+	}
+
+	for _, e := range events {
+		if e == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithNotifier sends the start, success and failure events of the command to the notifier. The option can be used
+// several times to add several notifiers.
+func WithNotifier(n Notifier) Option {
+	return optionFunc(func(c *Cmd) {
+		c.notifiers = append(c.notifiers, n)
+	})
+}
+
+func (c *Cmd) notify(t EventType, err error) {
+	if len(c.notifiers) == 0 {
+		return
+	}
+
+	e := Event{
+		Type:   t,
+		Time:   time.Now(),
+		Record: newResultRecord(c, err),
+	}
+
+	for _, n := range c.notifiers {
+		if err := n.Notify(c.ctx, e); err != nil {
+			c.logger.Debug(c.ctx, fmt.Sprintf("failed to notify %s of `%s`", t, c.name), "error", err)
+		}
+	}
+}
+
+func (c *Cmd) notifyResult(err error) {
+	if err != nil {
+		c.notify(EventFailure, err)
+	} else {
+		c.notify(EventSuccess, nil)
+	}
+}
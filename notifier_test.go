@@ -0,0 +1,111 @@
+package exec_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithNotifier_Channel(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan exec.Event, 4)
+
+	_, err := exec.Run("echo", exec.WithStdout(io.Discard), exec.WithNotifier(exec.ChannelNotifier(events)))
+	require.NoError(t, err)
+
+	_, err = exec.Run("sh", exec.WithArgs("-c", "exit 2"), exec.WithNotifier(exec.ChannelNotifier(events)))
+	require.Error(t, err)
+
+	close(events)
+
+	var types []exec.EventType
+
+	for e := range events {
+		types = append(types, e.Type)
+	}
+
+	assert.Equal(t, []exec.EventType{exec.EventStart, exec.EventSuccess, exec.EventStart, exec.EventFailure}, types)
+}
+
+func TestWithNotifier_StartFailure(t *testing.T) {
+	t.Parallel()
+
+	var got []exec.Event
+
+	n := exec.NotifierFunc(func(_ context.Context, e exec.Event) error {
+		got = append(got, e)
+
+		return errors.New("ignored")
+	})
+
+	cmd := exec.Command("sleep", exec.WithArgs("10"), exec.WithNotifier(n))
+
+	require.NoError(t, cmd.Start())
+	require.NoError(t, cmd.Process.Kill())
+
+	err := cmd.Wait()
+
+	assert.EqualError(t, err, "signal: killed")
+	require.Len(t, got, 2)
+	assert.Equal(t, exec.EventFailure, got[1].Type)
+	assert.Equal(t, -1, got[1].Record.ExitCode)
+	assert.Equal(t, "signal: killed", got[1].Record.Error)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		events []exec.Event
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e exec.Event
+
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		events = append(events, e)
+	}))
+	defer srv.Close()
+
+	_, err := exec.Run("sh", exec.WithArgs("-c", "exit 1"),
+		exec.RedactArgs("exit"),
+		exec.WithNotifier(exec.WebhookNotifier(srv.URL, srv.Client(), exec.EventFailure)),
+	)
+	require.Error(t, err)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, exec.EventFailure, events[0].Type)
+	assert.Equal(t, "sh", events[0].Record.Name)
+	assert.Equal(t, 1, events[0].Record.ExitCode)
+	assert.Equal(t, []string{"-c", "****** 1"}, events[0].Record.Args[1:])
+}
+
+func TestWebhookNotifier_UnexpectedStatus(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	err := exec.WebhookNotifier(srv.URL, nil).Notify(context.Background(), exec.Event{Type: exec.EventStart})
+
+	assert.EqualError(t, err, "could not send event: unexpected status 500 Internal Server Error")
+}
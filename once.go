@@ -0,0 +1,170 @@
+package exec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OnceStore stores the completion markers of the commands that must run only once.
+type OnceStore interface {
+	// IsDone reports whether the command of the given key has completed.
+	IsDone(ctx context.Context, key string) (bool, error)
+	// MarkDone records the completion of the command of the given key.
+	MarkDone(ctx context.Context, key string) error
+}
+
+// FileOnceStore stores the completion markers as files in a directory.
+type FileOnceStore struct {
+	dir string
+}
+
+var _ OnceStore = (*FileOnceStore)(nil)
+
+// NewFileOnceStore creates a new FileOnceStore that keeps the markers in the given directory.
+func NewFileOnceStore(dir string) *FileOnceStore {
+	return &FileOnceStore{dir: dir}
+}
+
+func (s *FileOnceStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".done")
+}
+
+// IsDone reports whether the marker file of the key exists.
+func (s *FileOnceStore) IsDone(_ context.Context, key string) (bool, error) {
+	_, err := os.Stat(s.path(key))
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err //nolint: wrapcheck
+}
+
+// MarkDone writes the marker file of the key atomically, by renaming a temporary file.
+func (s *FileOnceStore) MarkDone(_ context.Context, key string) error {
+	return writeFileAtomic(s.path(key), []byte(fmt.Sprintf("%s\n%s\n", key, time.Now().UTC().Format(time.RFC3339))))
+}
+
+// writeFileAtomic writes the data to a temporary file in the same directory then renames it, so readers never see a
+// partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint: gosec
+		return err //nolint: wrapcheck
+	}
+
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	defer os.Remove(f.Name()) //nolint: errcheck
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close() //nolint: errcheck
+
+		return err //nolint: wrapcheck
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close() //nolint: errcheck
+
+		return err //nolint: wrapcheck
+	}
+
+	if err := f.Close(); err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	return os.Rename(f.Name(), path) //nolint: wrapcheck
+}
+
+// MemoryOnceStore stores the completion markers in memory.
+type MemoryOnceStore struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+var _ OnceStore = (*MemoryOnceStore)(nil)
+
+// NewMemoryOnceStore creates a new MemoryOnceStore.
+func NewMemoryOnceStore() *MemoryOnceStore {
+	return &MemoryOnceStore{keys: make(map[string]struct{})}
+}
+
+// IsDone reports whether the key has been marked as done.
+func (s *MemoryOnceStore) IsDone(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.keys[key]
+
+	return ok, nil
+}
+
+// MarkDone marks the key as done.
+func (s *MemoryOnceStore) MarkDone(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key] = struct{}{}
+
+	return nil
+}
+
+type onceGuard struct {
+	key   string
+	store OnceStore
+}
+
+// Once runs the command only if it has not completed successfully before under the same key. When the command is
+// skipped, Start and Wait return nil without starting any process and Cmd.Skipped reports true. The completion is
+// recorded in the store after the command succeeds.
+func Once(key string, store OnceStore) Option {
+	return optionFunc(func(c *Cmd) {
+		c.once = &onceGuard{key: key, store: store}
+	})
+}
+
+// Skipped reports whether the command has not been executed because it has already completed, see Once.
+func (c *Cmd) Skipped() bool {
+	return c.skipped
+}
+
+func (c *Cmd) isOnceDone(ctx context.Context) (bool, error) {
+	if c.once == nil {
+		return false, nil
+	}
+
+	done, err := c.once.store.IsDone(ctx, c.once.key)
+	if err != nil {
+		return false, fmt.Errorf("could not check completion of %q: %w", c.once.key, err)
+	}
+
+	return done, nil
+}
+
+func (c *Cmd) markOnceDone(ctx context.Context) error {
+	if c.once == nil {
+		return nil
+	}
+
+	if err := c.once.store.MarkDone(ctx, c.once.key); err != nil {
+		return fmt.Errorf("could not record completion of %q: %w", c.once.key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package exec_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestOnce_FileStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := exec.NewFileOnceStore(dir)
+	out := newSafeBuffer()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	for i := 0; i < 2; i++ {
+		cmd, err := exec.Run("echo", exec.WithArgs("migrated"),
+			exec.WithStdout(out),
+			exec.WithTracer(tracer),
+			exec.Once("migration-1", store),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, i == 1, cmd.Skipped())
+	}
+
+	assert.Equal(t, "migrated", getOutput(out))
+
+	done, err := store.IsDone(context.Background(), "migration-1")
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.NotContains(t, spans[0].Attributes(), attribute.Bool("exec.skipped", true))
+	assert.Contains(t, spans[1].Attributes(), attribute.Bool("exec.skipped", true))
+	assert.Equal(t, "skipped", spans[1].Events()[0].Name)
+}
+
+func TestOnce_NotMarkedOnFailure(t *testing.T) {
+	t.Parallel()
+
+	store := exec.NewMemoryOnceStore()
+
+	for i := 0; i < 2; i++ {
+		cmd, err := exec.Run("sh", exec.WithArgs("-c", "exit 1"), exec.Once("bootstrap", store))
+
+		assert.EqualError(t, err, "exit status 1")
+		assert.False(t, cmd.Skipped())
+	}
+
+	done, err := store.IsDone(context.Background(), "bootstrap")
+	require.NoError(t, err)
+	assert.False(t, done)
+}
@@ -0,0 +1,199 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder decodes the output of a command into v, json.Unmarshal is a Decoder.
+type Decoder func(data []byte, v any) error
+
+// Output runs the command with the given context and decodes its standard output into a T. The output is decoded as
+// JSON unless a decoder is set with WithOutputDecoder. If the command is a pipeline, the output is the one of the last
+// command.
+//
+//	type repo struct {
+//		Name  string `json:"name"`
+//		Stars int    `json:"stargazerCount"`
+//	}
+//
+//	r, err := exec.Output[repo](ctx, "gh", exec.WithArgs("repo", "view", "--json", "name,stargazerCount"))
+func Output[T any](ctx context.Context, name string, opts ...Option) (T, error) {
+	var v T
+
+	out := new(bytes.Buffer)
+
+	cmd, err := RunWithContext(ctx, name, append(opts[:len(opts):len(opts)], teeStdout(out))...)
+	if err != nil {
+		return v, err
+	}
+
+	decode := cmd.decoder
+	if decode == nil {
+		decode = json.Unmarshal
+	}
+
+	if err := decode(out.Bytes(), &v); err != nil {
+		return v, fmt.Errorf("could not decode output: %w", err)
+	}
+
+	return v, nil
+}
+
+// defaultDecodeStderr is the size of the end of the standard error added to the decoding errors, unless set by
+// WithErrorStderr.
+const defaultDecodeStderr = 1024
+
+// RunJSON runs the command with the given context and decodes its standard output as JSON into v. If the command is a
+// pipeline, the output is the one of the last command.
+//
+// The standard error is captured, and its end is added to the error when the output can not be decoded, because the
+// tools usually explain there why they did not print what was expected.
+func RunJSON(ctx context.Context, v any, name string, opts ...Option) (*Cmd, error) {
+	return runDecode(ctx, json.Unmarshal, v, name, opts)
+}
+
+// RunYAML runs the command with the given context and decodes its standard output as YAML into v, like RunJSON.
+func RunYAML(ctx context.Context, v any, name string, opts ...Option) (*Cmd, error) {
+	return runDecode(ctx, yaml.Unmarshal, v, name, opts)
+}
+
+func runDecode(ctx context.Context, decode Decoder, v any, name string, opts []Option) (*Cmd, error) {
+	out := new(bytes.Buffer)
+
+	cmd, err := RunWithContext(ctx, name, append(opts[:len(opts):len(opts)], WithStderrCapture(), teeStdout(out))...)
+	if err != nil {
+		return cmd, err
+	}
+
+	if err := decode(out.Bytes(), v); err != nil {
+		return cmd, cmd.lastAttempt().lastStage().decodeError(err)
+	}
+
+	return cmd, nil
+}
+
+func (c *Cmd) decodeError(err error) error {
+	err = fmt.Errorf("could not decode output: %w", err)
+
+	limit := c.errorStderr
+	if limit <= 0 {
+		limit = defaultDecodeStderr
+	}
+
+	if out := c.stderrTail(limit); out != "" {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+
+	return err
+}
+
+// WithOutputDecoder sets the decoder used by Output.
+func WithOutputDecoder(d Decoder) Option {
+	return optionFunc(func(c *Cmd) {
+		c.decoder = d
+	})
+}
+
+// Output runs the command and returns its standard output, the one of the last command if it is a pipeline. If the
+// standard error is not set, it is captured and set on the *exec.ExitError of the failed commands, like os/exec does.
+func (c *Cmd) Output() ([]byte, error) {
+	last := c.lastStage()
+	if last.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set") //nolint: goerr113
+	}
+
+	out := new(lockedBuffer)
+	last.Stdout = out
+
+	stages := c.Pipeline()
+	captureStderr := c.Stderr == nil
+
+	if captureStderr {
+		for _, s := range stages {
+			s.captureStderr = true
+		}
+	}
+
+	err := c.Run()
+
+	if captureStderr && err != nil {
+		for _, s := range c.lastAttempt().Pipeline() {
+			var exitErr *exec.ExitError
+
+			if errors.As(s.StageErr(), &exitErr) {
+				exitErr.Stderr = []byte(s.CapturedStderr())
+			}
+		}
+	}
+
+	return out.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its standard output and the standard error of every command of the
+// pipeline, combined.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	last := c.lastStage()
+	if last.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set") //nolint: goerr113
+	}
+
+	if c.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set") //nolint: goerr113
+	}
+
+	out := new(lockedBuffer)
+	last.Stdout = out
+
+	for _, s := range c.Pipeline() {
+		s.Stderr = out
+	}
+
+	err := c.Run()
+
+	return out.Bytes(), err
+}
+
+func (c *Cmd) lastStage() *Cmd {
+	last := c
+
+	for last.Next != nil {
+		last = last.Next
+	}
+
+	return last
+}
+
+// lockedBuffer is a buffer shared by the stages of a pipeline.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p) //nolint: wrapcheck
+}
+
+func (b *lockedBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf.Reset()
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Bytes()
+}
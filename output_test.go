@@ -0,0 +1,179 @@
+package exec_test
+
+import (
+	"context"
+	"encoding/xml"
+	osexec "os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	type repo struct {
+		Name  string `json:"name" xml:"name"`
+		Stars int    `json:"stars" xml:"stars"`
+	}
+
+	actual, err := exec.Output[repo](context.Background(), "echo", exec.WithArgs(`{"name":"go-exec","stars":42}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, repo{Name: "go-exec", Stars: 42}, actual)
+
+	actual, err = exec.Output[repo](context.Background(), "echo",
+		exec.WithArgs(`<repo><name>go-exec</name><stars>42</stars></repo>`),
+		exec.WithOutputDecoder(xml.Unmarshal),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, repo{Name: "go-exec", Stars: 42}, actual)
+}
+
+func TestOutput_Pipe(t *testing.T) {
+	t.Parallel()
+
+	actual, err := exec.Output[[]string](context.Background(), "echo", exec.WithArgs(`["a","b"]`),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"A", "B"}, actual)
+}
+
+func TestOutput_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Output[map[string]any](context.Background(), "sh", exec.WithArgs("-c", "exit 1"))
+
+	assert.ErrorIs(t, err, exec.ExitCodeError(1))
+
+	_, err = exec.Output[map[string]any](context.Background(), "echo", exec.WithArgs("not json"))
+
+	assert.EqualError(t, err, "could not decode output: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func TestCmd_Output(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.Command("echo", exec.WithArgs("hello world"), exec.Pipe("tr", "[:lower:]", "[:upper:]")).Output()
+	require.NoError(t, err)
+
+	assert.Equal(t, "HELLO WORLD\n", string(out))
+}
+
+func TestCmd_Output_ExitError(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.Command("sh", exec.WithArgs("-c", "echo out; echo oops >&2; exit 2")).Output()
+
+	assert.Equal(t, "out\n", string(out))
+
+	var exitErr *osexec.ExitError
+
+	require.ErrorAs(t, err, &exitErr)
+
+	assert.Equal(t, "oops\n", string(exitErr.Stderr))
+	assert.ErrorIs(t, err, exec.ExitCodeError(2))
+}
+
+func TestCmd_Output_StdoutAlreadySet(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Command("echo", exec.WithStdout(newSafeBuffer())).Output()
+
+	assert.EqualError(t, err, "exec: Stdout already set")
+
+	_, err = exec.Command("echo", exec.WithStdout(newSafeBuffer())).CombinedOutput()
+
+	assert.EqualError(t, err, "exec: Stdout already set")
+
+	_, err = exec.Command("echo", exec.WithStderr(newSafeBuffer())).CombinedOutput()
+
+	assert.EqualError(t, err, "exec: Stderr already set")
+}
+
+func TestCmd_CombinedOutput(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.Command("sh",
+		exec.WithArgs("-c", "echo first >&2; echo hello"),
+		exec.Pipe("sh", "-c", "cat; echo second >&2"),
+	).CombinedOutput()
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+
+	assert.ElementsMatch(t, []string{"first", "hello", "second"}, lines)
+}
+
+func TestRunJSON(t *testing.T) {
+	t.Parallel()
+
+	var actual struct {
+		Name  string `json:"name"`
+		Stars int    `json:"stars"`
+	}
+
+	_, err := exec.RunJSON(context.Background(), &actual, "echo", exec.WithArgs(`{"name":"go-exec","stars":42}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "go-exec", actual.Name)
+	assert.Equal(t, 42, actual.Stars)
+}
+
+func TestRunJSON_DecodeError(t *testing.T) {
+	t.Parallel()
+
+	var actual map[string]any
+
+	_, err := exec.RunJSON(context.Background(), &actual, "sh",
+		exec.WithArgs("-c", "echo 'not json'; echo 'unknown flag: --output' >&2"),
+	)
+
+	assert.EqualError(t, err,
+		"could not decode output: invalid character 'o' in literal null (expecting 'u'): unknown flag: --output")
+}
+
+func TestRunJSON_Pipe(t *testing.T) {
+	t.Parallel()
+
+	var actual []int
+
+	_, err := exec.RunJSON(context.Background(), &actual, "echo",
+		exec.WithArgs("[3, 1, 2]"),
+		exec.Pipe("tr", ",", " "),
+	)
+
+	assert.EqualError(t, err, "could not decode output: invalid character '1' after array element")
+}
+
+func TestRunYAML(t *testing.T) {
+	t.Parallel()
+
+	var actual struct {
+		Name string   `yaml:"name"`
+		Tags []string `yaml:"tags"`
+	}
+
+	_, err := exec.RunYAML(context.Background(), &actual, "printf", exec.WithArgs(`name: go-exec\ntags: [a, b]\n`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "go-exec", actual.Name)
+	assert.Equal(t, []string{"a", "b"}, actual.Tags)
+}
+
+func TestRunYAML_ExitError(t *testing.T) {
+	t.Parallel()
+
+	var actual map[string]any
+
+	_, err := exec.RunYAML(context.Background(), &actual, "sh", exec.WithArgs("-c", "exit 2"))
+
+	assert.ErrorIs(t, err, exec.ExitCodeError(2))
+}
@@ -0,0 +1,189 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// WithStdoutFile appends the standard output of the command, the one of the last command if it is a pipeline, to the
+// file at path. The file is created if it does not exist, opened when the command starts, and synced and closed once
+// the pipeline has been waited. See WithFileRotation to rotate it.
+func WithStdoutFile(path string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard output", "WithStdoutFile")
+
+		c.Stdout = c.addOutputFile(path)
+	})
+}
+
+// WithStderrFile appends the standard error of the command and of the stages of its pipeline to the file at path, like
+// WithStdoutFile. The standard error is still captured and reported in the errors.
+func WithStderrFile(path string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard error", "WithStderrFile")
+
+		c.Stderr = c.addOutputFile(path)
+	})
+}
+
+// WithFileRotation rotates the files of WithStdoutFile and WithStderrFile before a write would make them larger than
+// maxSize bytes. The rotated files are renamed with a numeric suffix, path.1 being the most recent one, and only
+// maxBackups of them are kept, 1 if it is not positive.
+//
+// A write is never split, a file may exceed maxSize if a single write does.
+func WithFileRotation(maxSize int64, maxBackups int) Option {
+	if maxBackups <= 0 {
+		maxBackups = 1
+	}
+
+	return optionFunc(func(c *Cmd) {
+		c.fileRotation = &fileRotation{maxSize: maxSize, maxBackups: maxBackups}
+	})
+}
+
+type fileRotation struct {
+	maxSize    int64
+	maxBackups int
+}
+
+func (c *Cmd) addOutputFile(path string) *outputFile {
+	f := &outputFile{path: path}
+
+	c.outputFiles = append(c.outputFiles, f)
+
+	c.addHook(hook{
+		beforeStart: func(c *Cmd) error {
+			return f.open(c.fileRotation)
+		},
+		// The file is closed once the pipeline has been waited, or right away if the command could not be started,
+		// because of a next hook or of the spawn, since it is not waited.
+		afterExit: func(c *Cmd, err error) error {
+			if c.Process != nil {
+				return err
+			}
+
+			if cErr := f.close(); err == nil {
+				err = cErr
+			}
+
+			return err
+		},
+		afterPipeline: func(_ *Cmd, err error) error {
+			if cErr := f.close(); err == nil {
+				err = cErr
+			}
+
+			return err
+		},
+	})
+
+	return f
+}
+
+// outputFile is a file the output of a command is appended to.
+type outputFile struct {
+	path     string
+	rotation *fileRotation
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (f *outputFile) open(rotation *fileRotation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rotation = rotation
+
+	return f.openFile()
+}
+
+func (f *outputFile) openFile() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint: gosec
+	if err != nil {
+		return fmt.Errorf("could not open output file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close() //nolint: errcheck
+
+		return fmt.Errorf("could not open output file: %w", err)
+	}
+
+	f.file, f.size = file, info.Size()
+
+	return nil
+}
+
+func (f *outputFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r := f.rotation; r != nil && r.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > r.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err //nolint: wrapcheck
+}
+
+// rotate closes the file, shifts the backups and opens a new file.
+func (f *outputFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	for i := f.rotation.maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(f.backup(i), f.backup(i+1)) //nolint: errcheck
+	}
+
+	if err := os.Rename(f.path, f.backup(1)); err != nil {
+		return fmt.Errorf("could not rotate output file: %w", err)
+	}
+
+	return f.openFile()
+}
+
+func (f *outputFile) backup(i int) string {
+	return f.path + "." + strconv.Itoa(i)
+}
+
+func (f *outputFile) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	return f.closeFile()
+}
+
+func (f *outputFile) closeFile() error {
+	file := f.file
+	f.file = nil
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close() //nolint: errcheck
+
+		return fmt.Errorf("could not sync output file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("could not close output file: %w", err)
+	}
+
+	return nil
+}
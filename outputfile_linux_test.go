@@ -0,0 +1,58 @@
+//go:build linux
+
+package exec_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithStdoutFile_StartError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		name     string
+		options  []exec.Option
+	}{
+		{
+			scenario: "next file can not be opened",
+			name:     "echo",
+			options:  []exec.Option{exec.WithStderrFile("/does/not/exist/err.log")},
+		},
+		{
+			scenario: "command can not be spawned",
+			name:     "echo",
+			options:  []exec.Option{exec.WithDir("/does/not/exist")},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "out.log")
+
+			_, err := exec.Run(tc.name, append([]exec.Option{exec.WithStdoutFile(path)}, tc.options...)...)
+			require.Error(t, err)
+
+			// The file opened before the failure is closed.
+			entries, err := os.ReadDir("/proc/self/fd")
+			require.NoError(t, err)
+
+			for _, e := range entries {
+				target, _ := os.Readlink(filepath.Join("/proc/self/fd", e.Name())) //nolint: errcheck
+
+				assert.NotEqual(t, path, target)
+			}
+		})
+	}
+}
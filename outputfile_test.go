@@ -0,0 +1,101 @@
+package exec_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithStdoutFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "out.log")
+
+	for i := 0; i < 2; i++ {
+		_, err := exec.Run("echo",
+			exec.WithArgs("hello world"),
+			exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+			exec.WithStdoutFile(path),
+		)
+		require.NoError(t, err)
+	}
+
+	content, err := os.ReadFile(filepath.Clean(path))
+	require.NoError(t, err)
+
+	assert.Equal(t, "HELLO WORLD\nHELLO WORLD\n", string(content))
+}
+
+func TestWithStderrFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "err.log")
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo first >&2"),
+		exec.Pipe("sh", "-c", "cat; echo second >&2; exit 1"),
+		exec.WithStderrFile(path),
+		exec.WithErrorStderr(100),
+	)
+	require.EqualError(t, err, "exit status 1: second")
+
+	content, err := os.ReadFile(filepath.Clean(path))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"first", "second"}, strings.Fields(string(content)))
+}
+
+func TestWithFileRotation(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "out.log")
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "for i in 1 2 3 4 5; do echo line$i; sleep 0.05; done"),
+		exec.WithStdoutFile(path),
+		exec.WithFileRotation(12, 2),
+	)
+	require.NoError(t, err)
+
+	for file, expected := range map[string]string{
+		path:        "line5\n",
+		path + ".1": "line3\nline4\n",
+		path + ".2": "line1\nline2\n",
+	} {
+		content, err := os.ReadFile(filepath.Clean(file))
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, string(content), file)
+	}
+
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestWithStdoutFile_OpenError(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.WithStdoutFile(filepath.Join(t.TempDir(), "missing", "out.log")))
+
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorContains(t, err, "could not open output file")
+}
+
+func TestWithStdoutFile_Conflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.WithStdout(os.Stdout), exec.WithStdoutFile("out.log"))
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+	assert.ErrorContains(t, err, "WithStdout and WithStdoutFile both set the standard output")
+
+	_, err = exec.Run("echo", exec.WithFileRotation(10, 1))
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+	assert.ErrorContains(t, err, "WithFileRotation requires WithStdoutFile or WithStderrFile")
+}
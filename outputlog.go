@@ -0,0 +1,55 @@
+package exec
+
+import "io"
+
+// WithOutputLogging logs every line of the standard output of the pipeline and of the standard error of its stages
+// with the logger, at the given level, with the exec.command and exec.stream fields. The lines are redacted like the
+// arguments. The streams still receive the output.
+func WithOutputLogging(level LogLevel) Option {
+	return optionFunc(func(c *Cmd) {
+		c.outputLogging = &level
+	})
+}
+
+// wireOutputLogging logs the standard output of the last stage and the standard error of every stage.
+func (c *Cmd) wireOutputLogging() {
+	if c.outputLogging == nil {
+		return
+	}
+
+	log := c.outputLogging.logFunc(c.logger)
+	command := c.redactString(c.Cmd.String())
+
+	logLines := func(stream string, w *io.Writer) *lineWriter {
+		lw := &lineWriter{fn: func(line string) {
+			log(c.ctx, c.redactString(line),
+				"exec.command", command,
+				"exec.stream", stream,
+			)
+		}}
+
+		*w = teeWriter(*w, lw)
+
+		return lw
+	}
+
+	var stdout *lineWriter
+
+	if c.Next == nil {
+		stdout = logLines("stdout", &c.Cmd.Stdout)
+	}
+
+	stderr := logLines("stderr", &c.Cmd.Stderr)
+
+	c.addHook(hook{
+		afterExit: func(_ *Cmd, err error) error {
+			if stdout != nil {
+				stdout.flush()
+			}
+
+			stderr.flush()
+
+			return err
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/bool64/ctxd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithOutputLogging(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo secret; echo warning >&2"),
+		exec.Pipe("tr", "[:lower:]", "[:upper:]"),
+		exec.WithLogger(logger),
+		exec.WithOutputLogging(exec.LogLevelInfo),
+		exec.RedactArgs("SECRET"),
+	)
+	require.NoError(t, err)
+
+	type entry struct {
+		level, message, stream string
+	}
+
+	entries := make([]entry, 0, len(logger.LoggedEntries))
+
+	for _, e := range logger.LoggedEntries {
+		entries = append(entries, entry{level: e.Level, message: e.Message, stream: e.Data["exec.stream"].(string)}) //nolint: forcetypeassert
+	}
+
+	assert.ElementsMatch(t, []entry{
+		{level: "info", message: "warning", stream: "stderr"},
+		{level: "info", message: "******", stream: "stdout"},
+	}, entries)
+}
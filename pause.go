@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Pause suspends the running processes of the pipeline, whichever stage it is called on, with SIGSTOP, and records a
+// paused event on their spans. The stages that have not started yet or have exited are left as is. The timeouts and
+// the budgets keep running while the processes are paused.
+//
+// On Windows, the threads of the processes are suspended with NtSuspendProcess.
+func (c *Cmd) Pause() error {
+	return c.eachRunningStage((*Cmd).pause)
+}
+
+// Resume resumes the processes suspended by Pause, with SIGCONT on Unix systems, and records a resumed event on their spans.
+func (c *Cmd) Resume() error {
+	return c.eachRunningStage((*Cmd).resume)
+}
+
+func (c *Cmd) eachRunningStage(fn func(s *Cmd) error) error {
+	var (
+		started bool
+		err     error
+	)
+
+	for _, s := range c.Pipeline() {
+		if s.Process == nil {
+			continue
+		}
+
+		started = true
+
+		select {
+		case <-s.done:
+			continue
+
+		default:
+		}
+
+		if sErr := fn(s); sErr != nil && err == nil {
+			err = sErr
+		}
+	}
+
+	if !started {
+		return errors.New("exec: not started") //nolint: goerr113
+	}
+
+	return err
+}
+
+func (c *Cmd) pause() error {
+	if !c.paused.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	if err := suspendProcess(c.Process); err != nil {
+		c.paused.Store(false)
+
+		return c.signalError("pause", err)
+	}
+
+	c.span.AddEvent("paused")
+
+	return nil
+}
+
+func (c *Cmd) resume() error {
+	if !c.paused.CompareAndSwap(true, false) {
+		return nil
+	}
+
+	if err := resumeProcess(c.Process); err != nil {
+		c.paused.Store(true)
+
+		return c.signalError("resume", err)
+	}
+
+	c.span.AddEvent("resumed")
+
+	return nil
+}
+
+// signalError ignores the processes that have exited in the meantime.
+func (c *Cmd) signalError(action string, err error) error {
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+
+	return fmt.Errorf("could not %s `%s`: %w", action, filepath.Base(c.Path), err)
+}
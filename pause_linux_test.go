@@ -0,0 +1,103 @@
+package exec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_PauseResume(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	cmd := exec.Command("sleep",
+		exec.WithArgs("0.2"),
+		exec.WithTracer(tracer),
+	)
+
+	require.NoError(t, cmd.Start())
+
+	require.NoError(t, cmd.Pause())
+	require.NoError(t, cmd.Pause())
+
+	assert.Eventually(t, func() bool {
+		return processStatus(cmd.Process.Pid) == "T"
+	}, time.Second, 10*time.Millisecond)
+
+	// The process does not exit while it is paused.
+	time.Sleep(300 * time.Millisecond)
+
+	assert.Equal(t, "T", processStatus(cmd.Process.Pid))
+
+	require.NoError(t, cmd.Resume())
+	require.NoError(t, cmd.Wait())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	events := make([]string, 0, 4)
+
+	for _, e := range spans[0].Events() {
+		events = append(events, e.Name)
+	}
+
+	assert.Equal(t, []string{"process started", "paused", "resumed", "process exited"}, events)
+}
+
+func TestCmd_Pause_Pipe(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep",
+		exec.WithArgs("0.2"),
+		exec.Pipe("sleep", "0.2"),
+	)
+
+	require.NoError(t, cmd.Start())
+
+	result := make(chan error, 1)
+
+	go func() {
+		result <- cmd.Wait()
+	}()
+
+	require.Equal(t, exec.StageRunning, cmd.Next.State())
+
+	require.NoError(t, cmd.Next.Pause())
+
+	for _, s := range cmd.Pipeline() {
+		pid := s.Process.Pid
+
+		assert.Eventually(t, func() bool {
+			return processStatus(pid) == "T"
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	require.NoError(t, cmd.Resume())
+	require.NoError(t, <-result)
+}
+
+func TestCmd_Pause_NotStarted(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("echo")
+
+	assert.EqualError(t, cmd.Pause(), "exec: not started")
+	assert.EqualError(t, cmd.Resume(), "exec: not started")
+}
+
+func TestCmd_Pause_Exited(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("true")
+	require.NoError(t, err)
+
+	assert.NoError(t, cmd.Pause())
+}
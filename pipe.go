@@ -0,0 +1,232 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// pipeTransportKernel is the transport of the stages that share an OS pipe, the data is moved by the kernel and
+	// is never copied to the user space.
+	pipeTransportKernel = "os-pipe"
+	// pipeTransportCopy is the transport of the stages that are connected through an io.Pipe, the data is copied by
+	// os/exec on both sides.
+	pipeTransportCopy = "io-pipe"
+	// pipeTransportBuffered is the transport of the stages that are connected through a buffered copier, see
+	// WithPipeBuffer.
+	pipeTransportBuffered = "buffered-pipe"
+	// pipeTransportThrottled is the transport of the stages that are connected through an in-memory pipe with a rate
+	// limit, see WithPipeRateLimit.
+	pipeTransportThrottled = "throttled-pipe"
+)
+
+// openStagePipe connects the standard output of the command to the standard input of the next stage. The pipe is
+// opened when the command starts, before its streams are wrapped, so a pipeline that is built but never started, like
+// the one of Describe or of a dry run, does not hold any file.
+func (c *Cmd) openStagePipe() {
+	next := c.Next
+	if next == nil || next.Err != nil {
+		return
+	}
+
+	r, w, transport := c.stagePipe()
+
+	if pr, ok := r.(pipeReader); ok {
+		r = stageReader{pr}
+	}
+
+	next.Stdin = r
+	next.stdinPipe = r
+	next.pipeTransport = transport
+
+	c.Stdout = w
+	c.closer = w
+
+	if c.pipeStderr {
+		c.Stderr = w
+	}
+
+	if transport != pipeTransportKernel {
+		return
+	}
+
+	// The read end is held by the next process once it has started, the copy of the parent must be closed, so the
+	// writer gets EPIPE if the reader exits early.
+	next.addHook(hook{
+		afterStart: func(*Cmd) {
+			_ = r.Close() //nolint: errcheck
+		},
+		afterExit: func(_ *Cmd, err error) error {
+			_ = r.Close() //nolint: errcheck
+
+			return err
+		},
+	})
+}
+
+// closeStagePipe closes both ends of the pipe to the next stage when the command could not be started, nothing is
+// going to use it.
+func (c *Cmd) closeStagePipe() {
+	_ = c.closer.Close() //nolint: errcheck
+
+	if c.Next != nil && c.Next.stdinPipe != nil {
+		_ = c.Next.stdinPipe.Close() //nolint: errcheck
+	}
+}
+
+// stdinTransport returns how the output of the previous stage reaches the command. The OS pipe is only shared by the
+// processes if the previous stage writes to it directly, when its standard output is wrapped, by a tee or a line
+// function for example, os/exec copies the output to the pipe.
+func (c *Cmd) stdinTransport() string {
+	if c.pipeTransport != pipeTransportKernel {
+		return c.pipeTransport
+	}
+
+	if w, ok := c.prev.closer.(io.Writer); !ok || c.prev.Cmd.Stdout != w {
+		return pipeTransportCopy
+	}
+
+	return c.pipeTransport
+}
+
+// stagePipe creates the pipe between the command and the next stage.
+func (c *Cmd) stagePipe() (io.ReadCloser, io.WriteCloser, string) {
+	if c.pipeRateLimit > 0 {
+		return newThrottledPipe(c.pipeBuffer, c.pipeRateLimit)
+	}
+
+	return newStagePipe(c.pipeBuffer)
+}
+
+// startNext starts the next stage of the pipeline right after the command, like a shell does, so every stage consumes
+// the output of the previous one while it runs. If the next stage can not be started, the command is stopped and Wait
+// returns the error.
+func (c *Cmd) startNext() {
+	if c.Next == nil {
+		return
+	}
+
+	if c.nextErr = c.Next.Start(); c.nextErr != nil {
+		c.abortPipeline()
+	}
+}
+
+// WithPipefail sets which error a pipeline returns when several of its stages fail. By default, the error of the first
+// failing stage is returned.
+//
+// With true, like `set -o pipefail` in bash, the error of the last failing stage is returned. With false, like bash
+// without the option, only the error of the last stage is returned and the failures of the other stages are ignored,
+// they are still recorded on their spans and their states.
+//
+// When the returned error is the one of a stage whose output was not consumed because the next one exited early, it is
+// a BrokenPipeError.
+func WithPipefail(enabled bool) Option {
+	return optionFunc(func(c *Cmd) {
+		c.pipefail = &enabled
+	})
+}
+
+// pipelineError combines the error of the stage and the one of the rest of the pipeline.
+func (c *Cmd) pipelineError(err, downstream error) error {
+	if c.pipefail != nil && !*c.pipefail {
+		return downstream
+	}
+
+	if c.pipefail != nil && downstream != nil {
+		return downstream
+	}
+
+	switch {
+	case err == nil:
+		return downstream
+
+	case c.brokenPipe(err):
+		return &BrokenPipeError{ExitCode: c.Next.ProcessState.ExitCode(), Err: err, Downstream: downstream}
+	}
+
+	return err
+}
+
+// errDownstreamExited is the error of the writes to a stage that does not read its standard input anymore.
+var errDownstreamExited = errors.New("exec: downstream exited")
+
+// BrokenPipeError is the error of a stage of a pipeline whose output was not consumed because the next stage exited
+// early.
+type BrokenPipeError struct {
+	// ExitCode is the exit code of the next stage, or -1 if it was terminated by a signal.
+	ExitCode int
+	// Err is the error of the stage.
+	Err error
+	// Downstream is the error of the next stage, nil if it has succeeded.
+	Downstream error
+}
+
+// Error returns the exit code of the next stage and the error of the stage.
+func (e *BrokenPipeError) Error() string {
+	return fmt.Sprintf("exec: downstream exited (code %d) before consuming input: %s", e.ExitCode, e.Err)
+}
+
+// Unwrap returns the error of the stage.
+func (e *BrokenPipeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error of the next stage matches the target, the outcome of the pipeline is the one of the next
+// stage, like in a shell.
+func (e *BrokenPipeError) Is(target error) bool {
+	return e.Downstream != nil && errors.Is(e.Downstream, target)
+}
+
+// As finds the first error of the next stage that matches the target.
+func (e *BrokenPipeError) As(target any) bool {
+	return e.Downstream != nil && errors.As(e.Downstream, target)
+}
+
+// brokenPipe reports whether the command failed because the next stage stopped reading its output.
+func (c *Cmd) brokenPipe(err error) bool {
+	if errors.Is(err, errDownstreamExited) {
+		return true
+	}
+
+	return c.ProcessState != nil && isBrokenPipeSignal(c.ProcessState)
+}
+
+// pipeReader is the read end of an in-memory pipe, like an io.PipeReader.
+type pipeReader interface {
+	io.ReadCloser
+	CloseWithError(err error) error
+}
+
+// stageReader is the standard input of a stage that is connected through an in-memory pipe. When the process stops
+// reading, the pipe is closed, so the previous stage does not block forever on a write that nobody reads.
+type stageReader struct {
+	pipeReader
+}
+
+func (r stageReader) WriteTo(w io.Writer) (int64, error) {
+	dw := &deadWriter{Writer: w}
+
+	n, err := copyBuffer(dw, r.pipeReader)
+	if dw.err != nil {
+		_ = r.CloseWithError(errDownstreamExited) //nolint: errcheck
+	}
+
+	return n, err
+}
+
+// deadWriter remembers the write error.
+type deadWriter struct {
+	io.Writer
+
+	err error
+}
+
+func (w *deadWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	if err != nil {
+		w.err = err
+	}
+
+	return n, err //nolint: wrapcheck
+}
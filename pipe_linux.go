@@ -0,0 +1,57 @@
+//go:build linux
+
+package exec
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// newStagePipe creates an OS pipe that is shared by both processes, so the data goes from one to the other without
+// leaving the kernel, like splice does, and without any goroutine. It falls back to an io.Pipe if the pipe cannot be
+// created.
+//
+// With a buffer size, the size of the OS pipe is changed. If it can not be, the pipe is a buffered copier.
+func newStagePipe(size int) (io.ReadCloser, io.WriteCloser, string) {
+	r, w, err := os.Pipe()
+	if err == nil && size > 0 {
+		err = setPipeSize(w, size)
+		if err != nil {
+			_ = r.Close() //nolint: errcheck
+			_ = w.Close() //nolint: errcheck
+		}
+	}
+
+	switch {
+	case err == nil:
+		return r, w, pipeTransportKernel
+
+	case size > 0:
+		br, bw := newBufferedPipe(size)
+
+		return br, bw, pipeTransportBuffered
+	}
+
+	pr, pw := io.Pipe()
+
+	return pr, pw, pipeTransportCopy
+}
+
+func setPipeSize(f *os.File, size int) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	var setErr error
+
+	if err := conn.Control(func(fd uintptr) {
+		_, setErr = unix.FcntlInt(fd, unix.F_SETPIPE_SZ, size)
+	}); err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	return setErr
+}
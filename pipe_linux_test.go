@@ -0,0 +1,335 @@
+//go:build linux
+
+package exec_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestRun_Pipe_KernelTransport(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	out := newSafeBuffer()
+
+	// More than a pipe buffer goes through the pipe.
+	_, err := exec.RunWithContext(context.Background(), "head",
+		exec.WithArgs("-c", "1048576", "/dev/zero"),
+		exec.Pipe("wc", "-c"),
+		exec.WithStdout(out),
+		exec.WithTracer(tracer),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1048576", getOutput(out))
+
+	spans := stageSpans(recorder.Ended())
+	require.Len(t, spans, 2)
+
+	transports := make(map[string]string, len(spans))
+
+	for _, s := range spans {
+		for _, attr := range s.Attributes() {
+			if attr.Key == "exec.pipe.transport" {
+				transports[filepath.Base(s.Attributes()[0].Value.AsStringSlice()[0])] = attr.Value.AsString()
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]string{"wc": "os-pipe"}, transports)
+}
+
+func TestRun_Pipe_WrappedTransport(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	out := newSafeBuffer()
+
+	// The line function wraps the output of the first stage, os/exec copies it to the pipe.
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithStdoutLineFunc(func(string) {}),
+		exec.Pipe("cat"),
+		exec.WithStdout(out),
+		exec.WithTracer(tracer),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", getOutput(out))
+
+	var transports []string
+
+	for _, s := range recorder.Ended() {
+		for _, attr := range s.Attributes() {
+			if attr.Key == "exec.pipe.transport" {
+				transports = append(transports, attr.Value.AsString())
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"io-pipe"}, transports)
+}
+
+func TestCommand_Pipe_NotStarted(t *testing.T) { //nolint: paralleltest
+	countFiles := func() int {
+		entries, err := os.ReadDir("/proc/self/fd")
+		require.NoError(t, err)
+
+		return len(entries)
+	}
+
+	before := countFiles()
+
+	for i := 0; i < 10; i++ {
+		cmd := exec.Command("echo", exec.Pipe("cat"), exec.Pipe("cat"))
+
+		_ = cmd.String()
+
+		_, err := exec.Run("echo", exec.Pipe("cat"), exec.WithDryRun())
+		require.NoError(t, err)
+
+		_, err = exec.Run("echo", exec.WithDir("/does/not/exist"), exec.Pipe("cat"))
+		require.Error(t, err)
+	}
+
+	// The pipes are only opened when the stages start, and closed when they can not.
+	assert.Equal(t, before, countFiles())
+}
+
+func TestRun_Pipe_ReaderExitsEarly(t *testing.T) {
+	t.Parallel()
+
+	// The parent does not hold the read end, so the writer is killed by SIGPIPE when the reader exits.
+	_, err := exec.Run("yes",
+		exec.Pipe("head", "-n", "1"),
+	)
+	require.Error(t, err)
+
+	var pipeErr *exec.BrokenPipeError
+
+	require.ErrorAs(t, err, &pipeErr)
+
+	assert.Equal(t, 0, pipeErr.ExitCode)
+	assert.EqualError(t, err, "exec: downstream exited (code 0) before consuming input: signal: broken pipe")
+
+	code, ok := exec.ExitCode(err)
+
+	assert.False(t, ok)
+	assert.Equal(t, -1, code)
+}
+
+func TestRun_Pipe_ReaderFails(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("yes",
+		exec.Pipe("sh", "-c", "exit 3"),
+	)
+
+	var pipeErr *exec.BrokenPipeError
+
+	require.ErrorAs(t, err, &pipeErr)
+
+	assert.Equal(t, 3, pipeErr.ExitCode)
+	assert.ErrorIs(t, err, exec.ExitCodeError(3))
+
+	code, ok := exec.ExitCode(err)
+
+	assert.True(t, ok)
+	assert.Equal(t, 3, code)
+}
+
+func TestRun_Pipe_Pipefail(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		options       []exec.Option
+		expectedError error
+	}{
+		{
+			scenario:      "first failing stage",
+			expectedError: exec.ExitCodeError(1),
+		},
+		{
+			scenario:      "enabled",
+			options:       []exec.Option{exec.WithPipefail(true)},
+			expectedError: exec.ExitCodeError(2),
+		},
+		{
+			scenario: "disabled",
+			options:  []exec.Option{exec.WithPipefail(false)},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]exec.Option{
+				exec.WithArgs("-c", "exit 1"),
+				exec.Pipe("sh", "-c", "cat; exit 2"),
+				exec.Pipe("cat"),
+			}, tc.options...)
+
+			_, err := exec.Run("sh", opts...)
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRun_Pipe_PipefailDisabled_LastStageFails(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "exit 1"),
+		exec.Pipe("sh", "-c", "cat; exit 4"),
+		exec.WithPipefail(false),
+	)
+
+	require.ErrorIs(t, err, exec.ExitCodeError(4))
+	assert.NotErrorIs(t, err, exec.ExitCodeError(1))
+}
+
+func TestRun_Pipe_PipefailDisabled_ReaderExitsEarly(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("yes",
+		exec.Pipe("head", "-n", "1"),
+		exec.WithPipefail(false),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, exec.StageFailed, cmd.State())
+	assert.Error(t, cmd.StageErr())
+}
+
+func TestRun_Pipe_LargeOutput(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	// Every stage must run while the previous one writes, the output does not fit in the pipe buffers.
+	_, err := exec.RunWithContext(context.Background(), "head",
+		exec.WithArgs("-c", "4194304", "/dev/zero"),
+		exec.Pipe("cat"),
+		exec.Pipe("cat"),
+		exec.Pipe("wc", "-c"),
+		exec.WithStdout(out),
+		exec.WithTimeout(10*time.Second),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "4194304", getOutput(out))
+}
+
+func TestCmd_Start_Pipe_StartsEveryStage(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep",
+		exec.WithArgs("0.1"),
+		exec.Pipe("cat"),
+		exec.Pipe("cat"),
+	)
+
+	require.NoError(t, cmd.Start())
+
+	for _, s := range cmd.Pipeline() {
+		assert.Equal(t, exec.StageRunning, s.State())
+		assert.NotNil(t, s.Process)
+	}
+
+	require.NoError(t, cmd.Wait())
+}
+
+func TestWithPipeBuffer_Transport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario          string
+		size              int
+		expectedTransport string
+	}{
+		{
+			scenario:          "os pipe",
+			size:              1 << 20,
+			expectedTransport: "os-pipe",
+		},
+		{
+			scenario:          "buffered copier",
+			size:              1<<31 + 1,
+			expectedTransport: "buffered-pipe",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+			out := newSafeBuffer()
+
+			_, err := exec.Run("head",
+				exec.WithArgs("-c", "4194304", "/dev/zero"),
+				exec.WithPipeBuffer(tc.size),
+				exec.Pipe("cat"),
+				exec.Pipe("wc", "-c"),
+				exec.WithStdout(out),
+				exec.WithTracer(tracer),
+			)
+			require.NoError(t, err)
+
+			assert.Equal(t, "4194304", getOutput(out))
+
+			var transports []string
+
+			for _, s := range recorder.Ended() {
+				for _, attr := range s.Attributes() {
+					if attr.Key == "exec.pipe.transport" {
+						transports = append(transports, attr.Value.AsString())
+					}
+				}
+			}
+
+			assert.Equal(t, []string{tc.expectedTransport, tc.expectedTransport}, transports)
+		})
+	}
+}
+
+func TestWithPipeBuffer_ReaderExitsEarly(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("yes",
+		exec.WithPipeBuffer(1<<31+1),
+		exec.Pipe("head", "-n", "1"),
+	)
+
+	var pipeErr *exec.BrokenPipeError
+
+	require.ErrorAs(t, err, &pipeErr)
+	assert.Equal(t, 0, pipeErr.ExitCode)
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package exec
+
+import "io"
+
+// newStagePipe creates an io.Pipe, the data is copied by os/exec from a process to the other. With a buffer size, the
+// pipe is a buffered copier.
+func newStagePipe(size int) (io.ReadCloser, io.WriteCloser, string) {
+	if size > 0 {
+		r, w := newBufferedPipe(size)
+
+		return r, w, pipeTransportBuffered
+	}
+
+	pr, pw := io.Pipe()
+
+	return pr, pw, pipeTransportCopy
+}
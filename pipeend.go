@@ -0,0 +1,143 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PipeFrom starts the pipeline from a reader, like a stage whose output is the content of the reader. Unlike
+// WithStdin, the copy has its own span, with the number of bytes read, and a read error fails the command with an error
+// that says where it comes from.
+//
+//	_, err := exec.Run("gzip",
+//		exec.PipeFrom(resp.Body),
+//		exec.PipeTo(f),
+//	)
+func PipeFrom(r io.Reader) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard input", "PipeFrom")
+
+		e := &pipeEnd{name: "exec:pipe_from"}
+		c.Stdin = &pipeSource{r: r, end: e}
+
+		c.addHook(e.hook())
+	})
+}
+
+// PipeTo ends the pipeline in a writer, like a stage that consumes the output of the last command. Unlike WithStdout,
+// the copy has its own span, with the number of bytes written, and a write error fails the command with an error that
+// says where it comes from. It must be the last stage of the pipeline.
+func PipeTo(w io.Writer) Option {
+	return optionFunc(func(c *Cmd) {
+		last := c
+		for last.Next != nil {
+			last = last.Next
+		}
+
+		// The standard output is set once the stage has inherited the settings of the previous one.
+		last.stageOpts = append(last.stageOpts, pipeTo(w))
+	})
+}
+
+func pipeTo(w io.Writer) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard output", "PipeTo")
+
+		e := &pipeEnd{name: "exec:pipe_to"}
+		c.Stdout = &pipeSink{w: w, end: e}
+
+		c.addHook(e.hook())
+	})
+}
+
+// pipeEnd is an endpoint of a pipeline, it is traced like a stage.
+type pipeEnd struct {
+	name string
+	span trace.Span
+	n    atomic.Int64
+	err  atomic.Pointer[error]
+}
+
+func (e *pipeEnd) hook() hook {
+	return hook{
+		beforeStart: func(c *Cmd) error {
+			if c.tracer != nil {
+				_, e.span = c.startTracerSpan(c.ctx, e.name)
+			}
+
+			return nil
+		},
+		afterExit: func(_ *Cmd, err error) error {
+			e.end()
+
+			return err
+		},
+	}
+}
+
+// fail records the error of the endpoint and returns it with the name of the endpoint.
+func (e *pipeEnd) fail(err error) error {
+	err = fmt.Errorf("%s: %w", e.name, err)
+
+	e.err.CompareAndSwap(nil, &err)
+
+	return err
+}
+
+// end ends the span of the endpoint, once the command has exited.
+func (e *pipeEnd) end() {
+	if e.span == nil {
+		return
+	}
+
+	e.span.SetAttributes(attribute.Int64("exec.pipe.bytes", e.n.Load()))
+
+	if err := e.err.Load(); err != nil {
+		e.span.RecordError(*err)
+		e.span.SetStatus(codes.Error, (*err).Error())
+	} else {
+		e.span.SetStatus(codes.Ok, "")
+	}
+
+	e.span.End()
+	e.span = nil
+}
+
+// pipeSource is the standard input of a pipeline started with PipeFrom.
+type pipeSource struct {
+	r   io.Reader
+	end *pipeEnd
+}
+
+func (s *pipeSource) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.end.n.Add(int64(n))
+
+	if err != nil && err != io.EOF { //nolint: errorlint
+		return n, s.end.fail(err)
+	}
+
+	return n, err //nolint: wrapcheck
+}
+
+// pipeSink is the standard output of a pipeline ended with PipeTo.
+type pipeSink struct {
+	w   io.Writer
+	end *pipeEnd
+}
+
+func (s *pipeSink) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.end.n.Add(int64(n))
+
+	if err != nil {
+		return n, s.end.fail(err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,103 @@
+package exec_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+type errWriter struct {
+	err error
+}
+
+func (w errWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+func TestPipeFrom_PipeTo(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+	out := newSafeBuffer()
+
+	_, err := exec.Run("tr",
+		exec.WithArgs("a-z", "A-Z"),
+		exec.WithTracer(tracer),
+		exec.PipeFrom(strings.NewReader("hello")),
+		exec.Pipe("rev"),
+		exec.PipeTo(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "OLLEH", getOutput(out))
+
+	bytes := make(map[string]int64)
+
+	for _, s := range recorder.Ended() {
+		for _, a := range s.Attributes() {
+			if a.Key == "exec.pipe.bytes" {
+				bytes[s.Name()] = a.Value.AsInt64()
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]int64{"exec:pipe_from": 5, "exec:pipe_to": 5}, bytes)
+}
+
+func TestPipeFrom_Error(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+	errRead := errors.New("read error")
+
+	_, err := exec.Run("cat",
+		exec.WithTracer(tracer),
+		exec.PipeFrom(errReader{err: errRead}),
+		exec.WithStdout(newSafeBuffer()),
+	)
+
+	require.ErrorIs(t, err, errRead)
+	require.EqualError(t, err, "exec:pipe_from: read error")
+
+	for _, s := range recorder.Ended() {
+		if s.Name() == "exec:pipe_from" {
+			assert.Equal(t, codes.Error, s.Status().Code)
+			assert.Contains(t, s.Attributes(), attribute.Int64("exec.pipe.bytes", 0))
+		}
+	}
+}
+
+func TestPipeTo_Error(t *testing.T) {
+	t.Parallel()
+
+	errWrite := errors.New("write error")
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.PipeTo(errWriter{err: errWrite}),
+	)
+
+	require.ErrorIs(t, err, errWrite)
+}
+
+func TestPipeTo_Conflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo",
+		exec.WithStdout(newSafeBuffer()),
+		exec.PipeTo(newSafeBuffer()),
+	)
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+}
@@ -0,0 +1,136 @@
+package exec
+
+import "io"
+
+// PipeFunc pipes the output of the last command into an in-process function, like Pipe does with a command. The
+// function reads the output of the command from r and writes what goes to the standard output, or to the next stage
+// of the pipeline, to w:
+//
+//	_, err := exec.Run("cat",
+//		exec.WithArgs("events.json"),
+//		exec.PipeFunc(func(r io.Reader, w io.Writer) error {
+//			return rewriteEvents(r, w)
+//		}),
+//		exec.Pipe("gzip"),
+//	)
+//
+// The functions run concurrently with the command, in the order of the options, and are waited with it. When a function
+// returns nil before the end of its input, the rest of the input is discarded. When it returns an error, the command
+// stops on a broken pipe and Wait returns the error of the function.
+func PipeFunc(fn func(r io.Reader, w io.Writer) error) Option {
+	return optionFunc(func(c *Cmd) {
+		if c.Next != nil {
+			PipeFunc(fn).applyOption(c.Next)
+
+			return
+		}
+
+		if c.pipeFuncs == nil {
+			p := &pipeFuncs{}
+
+			c.addHook(hook{
+				beforeStart: func(c *Cmd) error {
+					p.wire(c)
+
+					return nil
+				},
+				afterStart: func(*Cmd) {
+					p.start()
+				},
+				afterExit: func(_ *Cmd, err error) error {
+					return p.wait(err)
+				},
+			})
+
+			c.pipeFuncs = p
+		}
+
+		c.pipeFuncs.fns = append(c.pipeFuncs.fns, fn)
+	})
+}
+
+// pipeFuncs are the functions that transform the output of a command, in order.
+type pipeFuncs struct {
+	fns []func(r io.Reader, w io.Writer) error
+
+	r    *io.PipeReader
+	w    *io.PipeWriter
+	out  io.Writer
+	done chan error
+}
+
+// wire plugs the functions into the standard output of the command.
+func (p *pipeFuncs) wire(c *Cmd) {
+	p.out = c.Stdout
+	if p.out == nil {
+		p.out = io.Discard
+	}
+
+	p.r, p.w = io.Pipe()
+	c.Stdout = p.w
+}
+
+// start runs the functions once the process has started.
+func (p *pipeFuncs) start() {
+	p.done = make(chan error, 1)
+
+	go func() {
+		p.done <- runPipeFuncs(p.fns, p.r, p.out)
+	}()
+}
+
+// wait closes the input of the functions once the command has exited, and waits for them. The error of a function takes
+// precedence over the one of the command, the command fails because nothing reads its output anymore.
+func (p *pipeFuncs) wait(err error) error {
+	if p.w != nil {
+		_ = p.w.Close() //nolint: errcheck
+	}
+
+	if p.done == nil {
+		return err
+	}
+
+	if fnErr := <-p.done; fnErr != nil {
+		return fnErr
+	}
+
+	return err
+}
+
+// runPipeFuncs runs the functions concurrently, each one reads the output of the previous one.
+func runPipeFuncs(fns []func(r io.Reader, w io.Writer) error, r *io.PipeReader, out io.Writer) error {
+	if len(fns) == 1 {
+		return runPipeFunc(fns[0], r, out)
+	}
+
+	nr, nw := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		err := runPipeFunc(fns[0], r, nw)
+		_ = nw.CloseWithError(err) //nolint: errcheck
+
+		done <- err
+	}()
+
+	err := runPipeFuncs(fns[1:], nr, out)
+
+	// The failure of a function also fails the previous one, which can not write anymore.
+	if upErr := <-done; err == nil {
+		err = upErr
+	}
+
+	return err
+}
+
+func runPipeFunc(fn func(r io.Reader, w io.Writer) error, r *io.PipeReader, w io.Writer) error {
+	if err := fn(r, w); err != nil {
+		_ = r.CloseWithError(err) //nolint: errcheck
+
+		return err
+	}
+
+	_, err := copyBuffer(io.Discard, r)
+
+	return err
+}
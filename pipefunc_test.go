@@ -0,0 +1,89 @@
+package exec_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func upperFunc(r io.Reader, w io.Writer) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte(strings.ToUpper(string(b))))
+
+	return err
+}
+
+func TestPipeFunc(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("printf",
+		exec.WithArgs("a\nb\nc\n"),
+		exec.WithStdout(out),
+		exec.PipeFunc(upperFunc),
+		exec.Pipe("grep", "-v", "B"),
+		exec.PipeFunc(func(r io.Reader, w io.Writer) error {
+			s := bufio.NewScanner(r)
+
+			for s.Scan() {
+				if _, err := io.WriteString(w, "> "+s.Text()+"\n"); err != nil {
+					return err
+				}
+			}
+
+			return s.Err()
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "> A\n> C", getOutput(out))
+}
+
+func TestPipeFunc_ReturnsEarly(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("seq",
+		exec.WithArgs("100000"),
+		exec.WithStdout(out),
+		exec.PipeFunc(func(_ io.Reader, w io.Writer) error {
+			_, err := io.WriteString(w, "done")
+
+			return err
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "done", getOutput(out))
+}
+
+func TestPipeFunc_Error(t *testing.T) {
+	t.Parallel()
+
+	errFunc := errors.New("func error")
+
+	_, err := exec.Run("seq",
+		exec.WithArgs("100000"),
+		exec.WithStdout(newSafeBuffer()),
+		exec.PipeFunc(upperFunc),
+		exec.PipeFunc(func(io.Reader, io.Writer) error {
+			return errFunc
+		}),
+		exec.Pipe("cat"),
+	)
+
+	require.ErrorIs(t, err, errFunc)
+}
@@ -0,0 +1,268 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// ErrEmptyPipeline indicates that a pipeline has no stage to run.
+var ErrEmptyPipeline = errors.New("exec: empty pipeline")
+
+// Pipeline builds a pipeline of commands one stage at a time, every stage with its own options.
+//
+//	res, err := exec.NewPipeline(ctx, exec.WithStdout(os.Stdout)).
+//		Cmd("cat", "access.log").
+//		Stage("grep", exec.WithArgs(" 500 "), exec.WithEnv("LC_ALL", "C")).
+//		Cmd("wc", "-l").
+//		Run()
+//
+// The options of NewPipeline apply to the whole pipeline, like the options of Command do when the stages are added
+// with Pipe. The options of a stage only apply to it and take precedence over the ones it inherits from the pipeline.
+type Pipeline struct {
+	ctx    context.Context //nolint: containedctx
+	opts   []Option
+	stages []Spec
+}
+
+// NewPipeline creates a new empty pipeline with the options of the whole pipeline.
+func NewPipeline(ctx context.Context, opts ...Option) *Pipeline {
+	return &Pipeline{ctx: ctx, opts: opts}
+}
+
+// Cmd appends a stage that runs the command with the arguments.
+func (p *Pipeline) Cmd(name string, args ...string) *Pipeline {
+	return p.Stage(name, WithArgs(args...))
+}
+
+// Stage appends a stage that runs the command with the options.
+func (p *Pipeline) Stage(name string, opts ...Option) *Pipeline {
+	p.stages = append(p.stages, Spec{Name: name, Options: opts})
+
+	return p
+}
+
+// Command builds the pipeline and returns its first stage, to be started and waited like any other command. The
+// command of every call is a new one.
+func (p *Pipeline) Command() *Cmd {
+	return p.command()
+}
+
+func (p *Pipeline) command(extra ...Option) *Cmd {
+	if len(p.stages) == 0 {
+		cmd := CommandContext(p.ctx, "")
+		cmd.Err = ErrEmptyPipeline
+
+		return cmd
+	}
+
+	opts := make([]Option, 0, len(p.opts)+len(extra)+len(p.stages))
+	opts = append(opts, p.opts...)
+	opts = append(opts, extra...)
+	opts = append(opts, stageOptions(p.stages[0].Options))
+
+	for _, s := range p.stages[1:] {
+		opts = append(opts, pipeStage(s.Name, s.Options))
+	}
+
+	return CommandContext(p.ctx, p.stages[0].Name, opts...)
+}
+
+// Run builds the pipeline, runs it and returns the outcome of every stage with the error of the pipeline. When the
+// pipeline fails, the error is a *PipelineError, see WithPipelineError.
+func (p *Pipeline) Run() (PipelineResult, error) {
+	cmd := p.command(WithPipelineError())
+	if cmd.Err != nil {
+		return newPipelineResult(cmd, cmd.Err), cmd.Err
+	}
+
+	err := cmd.Run()
+
+	return newPipelineResult(cmd, err), err
+}
+
+// PipelineResult is the outcome of a pipeline.
+type PipelineResult struct {
+	// Stages are the outcomes of the stages, in order. The error of a stage is its own error, without the ones of the
+	// next stages.
+	Stages []Result
+	// Err is the error of the pipeline.
+	Err error
+}
+
+// ExitCodes returns the exit code of every stage, in order, like PIPESTATUS in bash. The exit code of a stage that has
+// not exited is -1.
+func (r PipelineResult) ExitCodes() []int {
+	codes := make([]int, len(r.Stages))
+
+	for i, s := range r.Stages {
+		codes[i] = s.ExitCode
+	}
+
+	return codes
+}
+
+func newPipelineResult(cmd *Cmd, err error) PipelineResult {
+	r := PipelineResult{Err: err}
+
+	if errors.Is(cmd.Err, ErrEmptyPipeline) {
+		return r
+	}
+
+	for _, s := range cmd.lastAttempt().Pipeline() {
+		r.Stages = append(r.Stages, newResult(s, s.ownErr()))
+	}
+
+	return r
+}
+
+// WithPipelineError makes Run return a *PipelineError when the pipeline fails, with the command line, the exit code and
+// the captured standard error of every failing stage, so the error says which stage has failed:
+//
+//	exec: pipeline failed: stage 2 `grep B`: exit status 1
+//
+// The standard error of every stage is captured. The PipelineError wraps the error that the pipeline would return
+// without the option, see WithPipefail, so it still matches ExitCodeError and the other errors with errors.Is.
+func WithPipelineError() Option {
+	return optionFunc(func(c *Cmd) {
+		c.pipelineErr = true
+		c.captureStderr = true
+	})
+}
+
+// PipelineError is the error of a pipeline with the failures of its stages.
+type PipelineError struct {
+	// Stages are the failing stages, in order.
+	Stages []PipelineStageError
+	// Err is the error of the pipeline.
+	Err error
+}
+
+// PipelineStageError is the failure of a stage of a pipeline.
+type PipelineStageError struct {
+	// Index is the position of the stage in the pipeline, starting at 0.
+	Index int
+	// Name is the name of the stage set by WithStageName, empty if the stage is not named.
+	Name string
+	// Command is the redacted command line of the stage, with the base name of the executable.
+	Command string
+	// ExitCode is the exit code of the stage, or -1 if it has not exited or was terminated by a signal.
+	ExitCode int
+	// Stderr is the captured standard error of the stage.
+	Stderr string
+	// Err is the error of the stage alone.
+	Err error
+}
+
+// Error returns the command line and the error of every failing stage.
+func (e *PipelineError) Error() string {
+	if len(e.Stages) == 0 {
+		return e.Err.Error()
+	}
+
+	msgs := make([]string, 0, len(e.Stages))
+
+	for _, s := range e.Stages {
+		name := s.Name
+		if name == "" {
+			name = strconv.Itoa(s.Index + 1)
+		}
+
+		err := s.Err
+
+		var stageErr *StageError
+
+		if errors.As(err, &stageErr) {
+			err = stageErr.Err
+		}
+
+		msgs = append(msgs, fmt.Sprintf("stage %s `%s`: %s", name, s.Command, err))
+	}
+
+	return "exec: pipeline failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the error of the pipeline.
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// pipelineFailure wraps the error of the pipeline in a PipelineError, with WithPipelineError.
+func (c *Cmd) pipelineFailure(err error) error {
+	if err == nil || !c.pipelineErr {
+		return err
+	}
+
+	pErr := &PipelineError{Err: err}
+
+	for i, s := range c.lastAttempt().Pipeline() {
+		stageErr := s.ownErr()
+		if stageErr == nil {
+			continue
+		}
+
+		r := newResult(s, stageErr)
+		args := s.redact(s.Args...)
+
+		pErr.Stages = append(pErr.Stages, PipelineStageError{
+			Index:    i,
+			Name:     s.stageName,
+			Command:  shellquote.Join(append([]string{filepath.Base(args[0])}, args[1:]...)...),
+			ExitCode: r.ExitCode,
+			Stderr:   r.Stderr,
+			Err:      stageErr,
+		})
+	}
+
+	return pErr
+}
+
+// stageOptions sets the options that only apply to the command, and not to the next stages of its pipeline.
+func stageOptions(opts []Option) Option {
+	return optionFunc(func(c *Cmd) {
+		c.stageOpts = append(c.stageOpts, opts...)
+	})
+}
+
+// pipeStage appends a stage to the pipeline, with options that only apply to it.
+func pipeStage(name string, opts []Option) Option {
+	return optionFunc(func(c *Cmd) {
+		if c.Next == nil {
+			// The options are applied when the pipeline is set up, after the stage inherits the settings of the
+			// previous one.
+			c.Next = CommandContext(c.ctx, name) //nolint: gosec
+			c.Next.stageOpts = opts
+		} else {
+			pipeStage(name, opts).applyOption(c.Next)
+		}
+	})
+}
+
+// applyStageOptions applies the options of the stage, once it has inherited the settings of the previous one. It returns
+// the error of the options that can not be applied.
+func (c *Cmd) applyStageOptions() error {
+	if len(c.stageOpts) == 0 {
+		return nil
+	}
+
+	opts, customizers := c.stageOpts, len(c.customizers)
+	c.stageOpts = nil
+
+	for _, opt := range opts {
+		opt.applyOption(c)
+	}
+
+	c.redactSecrets()
+	c.redactWithPatterns()
+
+	for _, customize := range c.customizers[customizers:] {
+		customize(c.Cmd)
+	}
+
+	return c.validate()
+}
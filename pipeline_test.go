@@ -0,0 +1,167 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	res, err := exec.NewPipeline(context.Background(), exec.WithStdout(out)).
+		Cmd("echo", "hello").
+		Cmd("tr", "a-z", "A-Z").
+		Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, "HELLO", getOutput(out))
+	assert.Equal(t, []int{0, 0}, res.ExitCodes())
+	assert.NoError(t, res.Err)
+
+	require.Len(t, res.Stages, 2)
+
+	assert.Equal(t, []string{"a-z", "A-Z"}, res.Stages[1].Cmd.Args[1:])
+}
+
+func TestPipeline_Run_StageOptions(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	stderr := newSafeBuffer()
+
+	_, err := exec.NewPipeline(context.Background(), exec.WithStdout(out), exec.WithEnv("SHARED", "yes")).
+		Stage("sh", exec.WithArgs("-c", "echo $SHARED ${OWN:-unset}"), exec.WithEnv("OWN", "head")).
+		Stage("sh", exec.WithArgs("-c", "cat; echo $SHARED ${OWN:-unset}; echo oops >&2"), exec.WithStderr(stderr)).
+		Stage("sh", exec.WithArgs("-c", "cat; echo $SHARED ${OWN:-unset}"), exec.WithEnv("OWN", "last")).
+		Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, "yes head\nyes unset\nyes last", getOutput(out))
+	assert.Equal(t, "oops", getOutput(stderr))
+}
+
+func TestPipeline_Run_Failure(t *testing.T) {
+	t.Parallel()
+
+	res, err := exec.NewPipeline(context.Background()).
+		Cmd("sh", "-c", "exit 3").
+		Cmd("cat").
+		Run()
+
+	require.ErrorIs(t, err, exec.ExitCodeError(3))
+
+	assert.Equal(t, err, res.Err)
+	assert.Equal(t, []int{3, 0}, res.ExitCodes())
+
+	require.Len(t, res.Stages, 2)
+
+	assert.ErrorIs(t, res.Stages[0].Err, exec.ExitCodeError(3))
+	assert.NoError(t, res.Stages[1].Err)
+}
+
+func TestPipeline_Run_PipelineError(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.NewPipeline(context.Background()).
+		Cmd("sh", "-c", "echo a; echo oops >&2; exit 3").
+		Stage("grep", exec.WithArgs("b"), exec.WithStageName("filter")).
+		Cmd("cat").
+		Run()
+
+	require.EqualError(t, err, "exec: pipeline failed: stage 1 `sh -c 'echo a; echo oops >&2; exit 3'`: exit status 3; "+
+		"stage filter `grep b`: exit status 1")
+	require.ErrorIs(t, err, exec.ExitCodeError(3))
+
+	var pErr *exec.PipelineError
+
+	require.ErrorAs(t, err, &pErr)
+	require.Len(t, pErr.Stages, 2)
+
+	assert.Equal(t, 0, pErr.Stages[0].Index)
+	assert.Equal(t, 3, pErr.Stages[0].ExitCode)
+	assert.Equal(t, "oops", pErr.Stages[0].Stderr)
+
+	assert.Equal(t, 1, pErr.Stages[1].Index)
+	assert.Equal(t, "filter", pErr.Stages[1].Name)
+	assert.Equal(t, "grep b", pErr.Stages[1].Command)
+	assert.Equal(t, 1, pErr.Stages[1].ExitCode)
+	assert.ErrorIs(t, pErr.Stages[1].Err, exec.ExitCodeError(1))
+}
+
+func TestWithPipelineError(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithPipelineError(),
+		exec.Pipe("grep", "world"),
+		exec.Pipe("cat"),
+	)
+
+	require.EqualError(t, err, "exec: pipeline failed: stage 2 `grep world`: exit status 1")
+
+	_, err = exec.Run("echo", exec.WithArgs("hello"), exec.WithPipelineError(), exec.Pipe("cat"))
+
+	require.NoError(t, err)
+}
+
+func TestPipeline_Run_NotFound(t *testing.T) {
+	t.Parallel()
+
+	res, err := exec.NewPipeline(context.Background()).
+		Cmd("echo", "hello").
+		Cmd("not_found").
+		Run()
+
+	require.ErrorIs(t, err, exec.ErrNotFound)
+
+	assert.Equal(t, []int{-1, -1}, res.ExitCodes())
+}
+
+func TestPipeline_Run_StageOptionConflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.NewPipeline(context.Background()).
+		Cmd("echo", "hello").
+		Stage("cat", exec.WithStdout(newSafeBuffer()), exec.WithStdoutFile(t.TempDir()+"/out")).
+		Run()
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+}
+
+func TestPipeline_Run_Empty(t *testing.T) {
+	t.Parallel()
+
+	res, err := exec.NewPipeline(context.Background()).Run()
+
+	require.ErrorIs(t, err, exec.ErrEmptyPipeline)
+
+	assert.Empty(t, res.Stages)
+}
+
+func TestPipeline_Command(t *testing.T) {
+	t.Parallel()
+
+	p := exec.NewPipeline(context.Background()).
+		Cmd("echo", "hello").
+		Cmd("cat")
+
+	cmd := p.Command()
+
+	require.NoError(t, cmd.Err)
+
+	assert.Len(t, cmd.Pipeline(), 2)
+	assert.NotSame(t, cmd, p.Command())
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello\n", string(out))
+}
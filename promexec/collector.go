@@ -0,0 +1,128 @@
+// Package promexec provides Prometheus collectors of the executions of the commands, for the projects that do not run
+// an OpenTelemetry metrics pipeline, see exec.WithMeterProvider otherwise.
+package promexec
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.nhat.io/exec"
+)
+
+// DefaultNamespace is the namespace of the metrics.
+const DefaultNamespace = "exec"
+
+// Collector collects the metrics of the executions of the commands it is set on with WithCollector:
+//
+//   - executions_total, the number of executions, by command.
+//   - failures_total, the number of executions that have failed, by command and exit code.
+//   - duration_seconds, a histogram of the time elapsed between the start and the exit of the processes, by command.
+//
+// The command is the name of the executable, the exit code is -1 if the process could not be started or was
+// terminated by a signal.
+type Collector struct {
+	executions *prometheus.CounterVec
+	failures   *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Option configures a Collector.
+type Option interface {
+	applyOption(o *options)
+}
+
+type optionFunc func(o *options)
+
+func (f optionFunc) applyOption(o *options) {
+	f(o)
+}
+
+type options struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace sets the namespace of the metrics, DefaultNamespace by default.
+func WithNamespace(namespace string) Option {
+	return optionFunc(func(o *options) {
+		o.namespace = namespace
+	})
+}
+
+// WithBuckets sets the buckets of the duration histogram, in seconds, prometheus.DefBuckets by default.
+func WithBuckets(buckets ...float64) Option {
+	return optionFunc(func(o *options) {
+		o.buckets = buckets
+	})
+}
+
+// New creates a new Collector, it has to be registered to be exported.
+func New(opts ...Option) *Collector {
+	o := options{namespace: DefaultNamespace, buckets: prometheus.DefBuckets}
+
+	for _, opt := range opts {
+		opt.applyOption(&o)
+	}
+
+	return &Collector{
+		executions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "executions_total",
+			Help:      "The number of executions.",
+		}, []string{"command"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "failures_total",
+			Help:      "The number of executions that have failed.",
+		}, []string{"command", "exit_code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "duration_seconds",
+			Help:      "The duration of the executions.",
+			Buckets:   o.buckets,
+		}, []string{"command"}),
+	}
+}
+
+// Describe sends the descriptors of the metrics.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.executions.Describe(ch)
+	c.failures.Describe(ch)
+	c.duration.Describe(ch)
+}
+
+// Collect sends the metrics.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.executions.Collect(ch)
+	c.failures.Collect(ch)
+	c.duration.Collect(ch)
+}
+
+// WithCollector records the execution of the command in the collector once it has been waited, or could not be
+// started. For a pipeline, the execution is the one of the whole pipeline, with the name of the first command, the
+// stages can be recorded on their own with exec.PipeWith.
+func WithCollector(c *Collector) exec.Option {
+	return exec.WithAfterWait(func(_ context.Context, cmd *exec.Cmd, err error) {
+		c.record(cmd, err)
+	})
+}
+
+func (c *Collector) record(cmd *exec.Cmd, err error) {
+	command := filepath.Base(cmd.Path)
+	res := cmd.Result()
+
+	c.executions.WithLabelValues(command).Inc()
+
+	if err != nil {
+		c.failures.WithLabelValues(command, strconv.Itoa(res.ExitCode)).Inc()
+	}
+
+	if cmd.ProcessState != nil {
+		c.duration.WithLabelValues(command).Observe(res.Duration.Seconds())
+	}
+}
@@ -0,0 +1,115 @@
+package promexec_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+	"go.nhat.io/exec/promexec"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	c := promexec.New()
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(c))
+
+	_, err := exec.Run("echo", exec.WithArgs("hello"), promexec.WithCollector(c))
+	require.NoError(t, err)
+
+	_, err = exec.Run("sh", exec.WithArgs("-c", "exit 3"), promexec.WithCollector(c))
+	require.Error(t, err)
+
+	_, err = exec.Run("sh", exec.WithDir("/does/not/exist"), promexec.WithCollector(c))
+	require.Error(t, err)
+
+	expected := `
+# HELP exec_executions_total The number of executions.
+# TYPE exec_executions_total counter
+exec_executions_total{command="echo"} 1
+exec_executions_total{command="sh"} 2
+# HELP exec_failures_total The number of executions that have failed.
+# TYPE exec_failures_total counter
+exec_failures_total{command="sh",exit_code="-1"} 1
+exec_failures_total{command="sh",exit_code="3"} 1
+`
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "exec_executions_total", "exec_failures_total")
+	require.NoError(t, err)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	counts := make(map[string]uint64)
+
+	for _, mf := range mfs {
+		if mf.GetName() != "exec_duration_seconds" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			counts[m.GetLabel()[0].GetValue()] = m.GetHistogram().GetSampleCount()
+		}
+	}
+
+	// The command that could not be started has no duration.
+	assert.Equal(t, map[string]uint64{"echo": 1, "sh": 1}, counts)
+}
+
+func TestCollector_Options(t *testing.T) {
+	t.Parallel()
+
+	c := promexec.New(promexec.WithNamespace("jobs"), promexec.WithBuckets(0.5, 1))
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(c))
+
+	_, err := exec.Run("true", promexec.WithCollector(c))
+	require.NoError(t, err)
+
+	expected := `
+# HELP jobs_executions_total The number of executions.
+# TYPE jobs_executions_total counter
+jobs_executions_total{command="true"} 1
+`
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "jobs_executions_total", "jobs_failures_total")
+	require.NoError(t, err)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	var buckets []float64
+
+	for _, mf := range mfs {
+		if mf.GetName() == "jobs_duration_seconds" {
+			for _, b := range mf.GetMetric()[0].GetHistogram().GetBucket() {
+				buckets = append(buckets, b.GetUpperBound())
+			}
+		}
+	}
+
+	assert.Equal(t, []float64{0.5, 1}, buckets)
+}
+
+func TestCollector_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	c := promexec.New()
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		promexec.WithCollector(c),
+		exec.PipeWith("cat", promexec.WithCollector(c)),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(c, "exec_executions_total"))
+}
@@ -0,0 +1,84 @@
+package exec
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// WithTraceParent sets the W3C trace context of the execution in the TRACEPARENT and TRACESTATE variables of the
+// environment of the process, so a child that is instrumented with an OpenTelemetry SDK joins the trace. It is the
+// same as WithPropagator(propagation.TraceContext{}).
+func WithTraceParent() Option {
+	return WithPropagator(propagation.TraceContext{})
+}
+
+// WithPropagator injects the context of the execution in the environment of the process with the propagator, for
+// example the global one of otel.GetTextMapPropagator(). The keys of the propagator are upper-cased to be the names of
+// the variables, traceparent is set as TRACEPARENT. Every next stage of the pipeline inherits the propagators.
+//
+// The propagators are used in addition to the TRACE_ID and SPAN_ID variables, see WithoutTraceEnv to leave them out.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return optionFunc(func(c *Cmd) {
+		c.propagators = append(c.propagators[:len(c.propagators):len(c.propagators)], p)
+	})
+}
+
+// injectPropagation sets the context of the execution in the environment of the process with the propagators.
+func (c *Cmd) injectPropagation(ctx context.Context) {
+	if len(c.propagators) == 0 {
+		return
+	}
+
+	carrier := envCarrier{}
+
+	propagation.NewCompositeTextMapPropagator(c.propagators...).Inject(ctx, carrier)
+
+	if len(carrier) == 0 {
+		return
+	}
+
+	keys := carrier.Keys()
+	env := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		env = append(env, k+"="+carrier[k])
+	}
+
+	c.setEnv(env...)
+}
+
+// envCarrier is a propagation.TextMapCarrier of environment variables.
+type envCarrier map[string]string
+
+var _ propagation.TextMapCarrier = (*envCarrier)(nil)
+
+// Get returns the value of the variable of the key.
+func (e envCarrier) Get(key string) string {
+	return e[envKey(key)]
+}
+
+// Set sets the value of the variable of the key.
+func (e envCarrier) Set(key, value string) {
+	e[envKey(key)] = value
+}
+
+// Keys returns the names of the variables, sorted.
+func (e envCarrier) Keys() []string {
+	keys := make([]string, 0, len(e))
+
+	for k := range e {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// envKey returns the name of the variable of the key of a propagator.
+func envKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
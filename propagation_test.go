@@ -0,0 +1,89 @@
+package exec_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithTraceParent(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	ts, err := trace.ParseTraceState("vendor=value")
+	require.NoError(t, err)
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	ctx = trace.ContextWithSpanContext(ctx, parent.SpanContext().WithTraceState(ts))
+
+	out := newSafeBuffer()
+
+	_, err = exec.RunWithContext(ctx, "sh",
+		exec.WithTracer(tracer),
+		exec.WithArgs("-c", `echo "$TRACEPARENT $TRACESTATE"`),
+		exec.WithTraceParent(),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	sc := spans[0].SpanContext()
+	expected := fmt.Sprintf("00-%s-%s-01 vendor=value", sc.TraceID(), sc.SpanID())
+
+	assert.Equal(t, expected, getOutput(out))
+	assert.Equal(t, parent.SpanContext().TraceID(), sc.TraceID())
+}
+
+func TestWithTraceParent_NoSpan(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", `echo "${TRACEPARENT-unset}"`),
+		exec.WithTraceParent(),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "unset", getOutput(out))
+}
+
+func TestWithPropagator_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	tracer := sdktrace.NewTracerProvider().Tracer("")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	defer parent.End()
+
+	out := newSafeBuffer()
+
+	_, err := exec.RunWithContext(ctx, "echo",
+		exec.WithPropagator(propagation.TraceContext{}),
+		exec.WithoutTraceEnv(),
+		exec.Pipe("sh", "-c", `cat; echo "${TRACEPARENT-unset} ${TRACE_ID-unset}"`),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	sc := parent.SpanContext()
+	expected := fmt.Sprintf("00-%s-%s-01 unset", sc.TraceID(), sc.SpanID())
+
+	assert.Equal(t, expected, getOutput(out))
+}
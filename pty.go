@@ -0,0 +1,174 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// ptyEOF is the end-of-file character of a terminal in canonical mode, it is sent when the standard input is exhausted.
+const ptyEOF = "\x04"
+
+// WithPTY runs the command in a new session with a pseudo-terminal as its controlling terminal, its standard input and
+// its standard output, so the tools that check isatty behave like in an interactive shell. The standard input of the
+// command is written to the terminal, followed by an end-of-file character, and the output of the terminal is copied to
+// the standard output, with the echo of the input and the \r\n line endings of a terminal. The standard error is not
+// attached to the terminal, it is still captured and reported in the errors.
+//
+// The size of the terminal is set with Cmd.ResizePTY, before the command starts or whenever the size of the parent
+// terminal changes. It is only supported on Linux, the command fails with ErrOptionConflict on other platforms, where
+// the standard output can not be copied from a terminal and is reported as set by both WithPTY and WithStdout.
+func WithPTY() Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("controlling terminal", "WithPTY")
+
+		if !ptySupported {
+			c.claim("standard output", "WithPTY")
+			c.invalid("WithPTY is not supported on %s", runtime.GOOS)
+
+			return
+		}
+
+		c.pty = &ptyTerminal{}
+	})
+}
+
+// ResizePTY sets the size of the terminal of WithPTY, in characters. It can be called before the command starts to set
+// the initial size, and while it runs to propagate the size of the parent terminal, for example on SIGWINCH.
+func (c *Cmd) ResizePTY(rows, cols uint16) error {
+	if c.pty == nil {
+		return errors.New("exec: WithPTY is not set") //nolint: goerr113
+	}
+
+	return c.pty.resize(rows, cols)
+}
+
+// ResizePTYFrom sets the size of the terminal of WithPTY to the size of the terminal f, such as os.Stdin.
+func (c *Cmd) ResizePTYFrom(f *os.File) error {
+	rows, cols, err := getPTYSize(f)
+	if err != nil {
+		return fmt.Errorf("could not get terminal size: %w", err)
+	}
+
+	return c.ResizePTY(rows, cols)
+}
+
+// ptyTerminal is the pseudo-terminal of a command, opened when it starts and closed when it exits.
+type ptyTerminal struct {
+	mu         sync.Mutex
+	rows, cols uint16
+	master     *os.File
+	slave      *os.File
+
+	stdin  io.Reader
+	stdout io.Writer
+	copied chan struct{}
+}
+
+// applyPTY attaches the terminal to the command once the other options have set its streams.
+func applyPTY(c *Cmd) {
+	if c.pty == nil {
+		return
+	}
+
+	c.addHook(hook{
+		beforeStart: c.pty.open,
+		afterStart:  c.pty.start,
+		afterExit:   c.pty.close,
+	})
+}
+
+func (t *ptyTerminal) open(c *Cmd) error {
+	master, slave, err := openPTY()
+	if err != nil {
+		return fmt.Errorf("could not open pty: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rows > 0 && t.cols > 0 {
+		if err := setPTYSize(master, t.rows, t.cols); err != nil {
+			_ = master.Close() //nolint: errcheck
+			_ = slave.Close()  //nolint: errcheck
+
+			return fmt.Errorf("could not set pty size: %w", err)
+		}
+	}
+
+	t.master, t.slave = master, slave
+	t.stdin, t.stdout = c.Cmd.Stdin, c.Cmd.Stdout
+
+	c.Cmd.Stdin, c.Cmd.Stdout = slave, slave
+
+	setControllingTerminal(c)
+
+	return nil
+}
+
+func (t *ptyTerminal) start(*Cmd) {
+	// The terminal is held by the process, the copy of the parent must be closed so the output ends when it exits.
+	_ = t.slave.Close() //nolint: errcheck
+
+	master := t.master
+	t.copied = make(chan struct{})
+
+	go func() {
+		defer close(t.copied)
+
+		stdout := t.stdout
+		if stdout == nil {
+			stdout = io.Discard
+		}
+
+		// The reads fail with EIO once the process and its children have closed the terminal.
+		_, _ = copyBuffer(stdout, master) //nolint: errcheck
+	}()
+
+	go func() {
+		if t.stdin != nil {
+			if _, err := copyBuffer(master, t.stdin); err != nil {
+				return
+			}
+		}
+
+		_, _ = io.WriteString(master, ptyEOF) //nolint: errcheck
+	}()
+}
+
+func (t *ptyTerminal) close(_ *Cmd, err error) error {
+	_ = t.slave.Close() //nolint: errcheck
+
+	if t.copied != nil {
+		<-t.copied
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_ = t.master.Close() //nolint: errcheck
+
+	t.master = nil
+
+	return err
+}
+
+func (t *ptyTerminal) resize(rows, cols uint16) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rows, t.cols = rows, cols
+
+	if t.master == nil {
+		return nil
+	}
+
+	if err := setPTYSize(t.master, rows, cols); err != nil {
+		return fmt.Errorf("could not set pty size: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package exec
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+const ptySupported = true
+
+func openPTY() (*os.File, *os.File, error) {
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err //nolint: wrapcheck
+	}
+
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		_ = master.Close() //nolint: errcheck
+
+		return nil, nil, err //nolint: wrapcheck
+	}
+
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		_ = master.Close() //nolint: errcheck
+
+		return nil, nil, err //nolint: wrapcheck
+	}
+
+	slave, err := os.OpenFile("/dev/pts/"+strconv.FormatUint(uint64(n), 10), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close() //nolint: errcheck
+
+		return nil, nil, err //nolint: wrapcheck
+	}
+
+	return master, slave, nil
+}
+
+func setPTYSize(f *os.File, rows, cols uint16) error {
+	return unix.IoctlSetWinsize(int(f.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Row: rows, Col: cols}) //nolint: wrapcheck
+}
+
+func getPTYSize(f *os.File) (uint16, uint16, error) {
+	ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err //nolint: wrapcheck
+	}
+
+	return ws.Row, ws.Col, nil
+}
+
+// setControllingTerminal makes the terminal, which is the standard input of the process, its controlling terminal.
+func setControllingTerminal(c *Cmd) {
+	sysProcAttr(c).Setsid = true
+	sysProcAttr(c).Setctty = true
+	sysProcAttr(c).Ctty = 0
+}
@@ -0,0 +1,89 @@
+package exec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithPTY(t *testing.T) {
+	t.Parallel()
+
+	out := new(bytes.Buffer)
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "test -t 0 && test -t 1 && ! test -t 2 && echo tty"),
+		exec.WithStdout(out),
+		exec.WithPTY(),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tty\r\n", out.String())
+}
+
+func TestWithPTY_Stdin(t *testing.T) {
+	t.Parallel()
+
+	out := new(bytes.Buffer)
+
+	_, err := exec.Run("cat",
+		exec.WithStdin(strings.NewReader("hello\n")),
+		exec.WithStdout(out),
+		exec.WithPTY(),
+	)
+	require.NoError(t, err)
+
+	// The input is echoed by the terminal, then printed by cat.
+	assert.Equal(t, "hello\r\nhello\r\n", out.String())
+}
+
+func TestWithPTY_Stderr(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo oops >&2; exit 1"),
+		exec.WithErrorStderr(100),
+		exec.WithPTY(),
+	)
+
+	assert.ErrorContains(t, err, "oops")
+}
+
+func TestWithPTY_Resize(t *testing.T) {
+	t.Parallel()
+
+	out := new(bytes.Buffer)
+
+	cmd := exec.Command("stty",
+		exec.WithArgs("size"),
+		exec.WithStdout(out),
+		exec.WithPTY(),
+	)
+
+	require.NoError(t, cmd.ResizePTY(24, 100))
+	require.NoError(t, cmd.Run())
+
+	assert.Equal(t, "24 100\r\n", out.String())
+}
+
+func TestWithPTY_Conflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.WithControllingTerminal(), exec.WithPTY())
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+	assert.ErrorContains(t, err, "WithControllingTerminal and WithPTY both set the controlling terminal")
+}
+
+func TestResizePTY_NoPTY(t *testing.T) {
+	t.Parallel()
+
+	err := exec.Command("echo").ResizePTY(24, 80)
+
+	assert.EqualError(t, err, "exec: WithPTY is not set")
+}
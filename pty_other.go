@@ -0,0 +1,26 @@
+//go:build !linux
+
+package exec
+
+import (
+	"errors"
+	"os"
+)
+
+const ptySupported = false
+
+var errPTYNotSupported = errors.New("exec: pty is not supported")
+
+func openPTY() (*os.File, *os.File, error) {
+	return nil, nil, errPTYNotSupported
+}
+
+func setPTYSize(*os.File, uint16, uint16) error {
+	return errPTYNotSupported
+}
+
+func getPTYSize(*os.File) (uint16, uint16, error) {
+	return 0, 0, errPTYNotSupported
+}
+
+func setControllingTerminal(*Cmd) {}
@@ -0,0 +1,389 @@
+package exec
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueClosed indicates that the queue does not accept new submissions.
+var ErrQueueClosed = errors.New("exec: queue closed")
+
+// Preemption is the strategy used by a Queue when a submission has a higher priority than a running command and the
+// concurrency limit is reached.
+type Preemption int
+
+const (
+	// PreemptNone never preempts the running commands, the submission waits for a free slot.
+	PreemptNone Preemption = iota
+	// PreemptPause suspends the running command of the lowest priority and resumes it when a slot is free again.
+	PreemptPause
+	// PreemptRequeue kills the running command of the lowest priority and runs it again from the start when a slot is
+	// free again.
+	PreemptRequeue
+)
+
+type jobState int
+
+const (
+	jobPending jobState = iota
+	jobRunning
+	jobPaused
+	jobDone
+)
+
+// Queue runs the submitted commands with a concurrency limit, the submissions of higher priority run first.
+type Queue struct {
+	mu         sync.Mutex
+	limit      int
+	preemption Preemption
+	seq        uint64
+	closed     bool
+
+	pending jobHeap
+	running map[*Job]struct{}
+	wg      sync.WaitGroup
+
+	// work is what is done once the lock is released, the commands to start and the signals of the preemptions, in
+	// order. draining is set while a goroutine does it.
+	work     []func()
+	draining bool
+}
+
+// Job is a command submitted to a Queue.
+type Job struct {
+	ctx      context.Context //nolint: containedctx
+	spec     Spec
+	priority int
+	seq      uint64
+	index    int
+
+	state     jobState
+	cmd       *Cmd
+	preempted bool
+	attempts  int
+
+	done   chan struct{}
+	result Result
+}
+
+// NewQueue creates a new Queue that runs at most limit commands at the same time.
+func NewQueue(limit int, opts ...QueueOption) *Queue {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	q := &Queue{
+		limit:   limit,
+		running: make(map[*Job]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt.applyQueueOption(q)
+	}
+
+	return q
+}
+
+// Submit enqueues the command described by the spec. The greater the priority is, the sooner the command runs.
+func (q *Queue) Submit(ctx context.Context, priority int, spec Spec) *Job {
+	j := &Job{
+		ctx:      ctx,
+		spec:     spec,
+		priority: priority,
+		done:     make(chan struct{}),
+	}
+
+	q.mu.Lock()
+	defer q.unlock()
+
+	if q.closed {
+		j.finish(Result{ExitCode: -1, Err: ErrQueueClosed})
+
+		return j
+	}
+
+	q.seq++
+	j.seq = q.seq
+
+	q.wg.Add(1)
+	heap.Push(&q.pending, j)
+
+	q.schedule()
+
+	return j
+}
+
+// Close stops accepting new submissions and waits for the submitted commands to finish.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+// unlock releases the lock, then does the work collected while it was held, so no process is started or signalled
+// while the queue is locked. The work is done in order by one goroutine at a time, the work added meanwhile, by a hook
+// that submits another command for example, is picked up by the same goroutine.
+func (q *Queue) unlock() {
+	if q.draining {
+		q.mu.Unlock()
+
+		return
+	}
+
+	q.draining = true
+
+	for len(q.work) > 0 {
+		work := q.work
+		q.work = nil
+
+		q.mu.Unlock()
+
+		for _, fn := range work {
+			fn()
+		}
+
+		q.mu.Lock()
+	}
+
+	q.draining = false
+
+	q.mu.Unlock()
+}
+
+// signal sends the signal to the command once the lock is released, if the command has been started.
+func (q *Queue) signal(cmd *Cmd, fn func(cmd *Cmd)) {
+	q.work = append(q.work, func() {
+		if cmd.Process != nil {
+			fn(cmd)
+		}
+	})
+}
+
+// schedule starts or resumes the pending jobs while there are free slots, and preempts the running jobs of lower
+// priority when there are not. It must be called with the lock held, and the lock must be released with unlock, which
+// starts and signals the commands.
+func (q *Queue) schedule() {
+	for q.pending.Len() > 0 {
+		next := q.pending[0]
+
+		if len(q.running) >= q.limit {
+			victim := q.lowestRunning()
+
+			if q.preemption == PreemptNone || victim == nil || victim.priority >= next.priority {
+				return
+			}
+
+			q.preempt(victim)
+		}
+
+		heap.Pop(&q.pending)
+		q.run(next)
+	}
+}
+
+func (q *Queue) lowestRunning() *Job {
+	var victim *Job
+
+	for j := range q.running {
+		if victim == nil || j.priority < victim.priority || (j.priority == victim.priority && j.seq > victim.seq) {
+			victim = j
+		}
+	}
+
+	return victim
+}
+
+// preempt gives the slot of the job to the next one. With PreemptPause, every stage of its pipeline is suspended, like
+// Pause does, if that fails, the job is killed so it does not keep running beside the next one.
+func (q *Queue) preempt(j *Job) {
+	switch q.preemption {
+	case PreemptPause:
+		j.state = jobPaused
+
+		q.signal(j.cmd, func(cmd *Cmd) {
+			if err := cmd.Pause(); err != nil {
+				_ = cmd.Resume() //nolint: errcheck
+				_ = cmd.kill()   //nolint: errcheck
+			}
+		})
+
+	case PreemptRequeue:
+		j.preempted = true
+		j.state = jobPending
+
+		q.signal(j.cmd, func(cmd *Cmd) {
+			_ = cmd.kill() //nolint: errcheck
+		})
+
+	case PreemptNone:
+		return
+	}
+
+	delete(q.running, j)
+	heap.Push(&q.pending, j)
+}
+
+func (q *Queue) run(j *Job) {
+	if j.state == jobPaused {
+		j.state = jobRunning
+		q.running[j] = struct{}{}
+
+		q.signal(j.cmd, func(cmd *Cmd) {
+			if err := cmd.Resume(); err != nil {
+				_ = cmd.kill() //nolint: errcheck
+			}
+		})
+
+		return
+	}
+
+	if err := j.ctx.Err(); err != nil {
+		q.complete(j, Result{ExitCode: -1, Err: err})
+
+		return
+	}
+
+	j.attempts++
+	j.cmd = j.spec.Command(j.ctx)
+	j.preempted = false
+
+	if j.cmd.Err != nil {
+		q.complete(j, newResult(j.cmd, j.cmd.Err))
+
+		return
+	}
+
+	// The slot is taken right away, the command is started once the lock is released.
+	j.state = jobRunning
+	q.running[j] = struct{}{}
+
+	cmd := j.cmd
+
+	q.work = append(q.work, func() {
+		if err := cmd.Start(); err != nil {
+			q.exited(j, cmd, err)
+
+			return
+		}
+
+		go q.wait(j, cmd)
+	})
+}
+
+func (q *Queue) wait(j *Job, cmd *Cmd) {
+	q.exited(j, cmd, cmd.Wait())
+}
+
+// exited completes the job when its command has exited or could not be started, and gives its slot to the next job.
+func (q *Queue) exited(j *Job, cmd *Cmd, err error) {
+	q.mu.Lock()
+	defer q.unlock()
+
+	// The job has been killed to give its slot to a job of higher priority, it is already back in the queue.
+	if j.cmd != cmd || j.preempted {
+		q.schedule()
+
+		return
+	}
+
+	delete(q.running, j)
+
+	if j.state == jobPaused {
+		heap.Remove(&q.pending, j.index)
+	}
+
+	q.complete(j, newResult(cmd, err))
+	q.schedule()
+}
+
+func (q *Queue) complete(j *Job, r Result) {
+	j.state = jobDone
+	j.finish(r)
+
+	q.wg.Done()
+}
+
+func (j *Job) finish(r Result) {
+	j.result = r
+
+	close(j.done)
+}
+
+// Wait waits for the job to finish.
+func (j *Job) Wait() (Result, error) {
+	<-j.done
+
+	return j.result, j.result.Err
+}
+
+// Done returns a channel that is closed when the job finishes.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Attempts returns the number of times the command has been started, it is greater than one when the job has been
+// preempted with PreemptRequeue.
+func (j *Job) Attempts() int {
+	<-j.done
+
+	return j.attempts
+}
+
+// jobHeap orders the jobs by priority, then by submission order.
+type jobHeap []*Job
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, k int) bool {
+	if h[i].priority != h[k].priority {
+		return h[i].priority > h[k].priority
+	}
+
+	return h[i].seq < h[k].seq
+}
+
+func (h jobHeap) Swap(i, k int) {
+	h[i], h[k] = h[k], h[i]
+	h[i].index = i
+	h[k].index = k
+}
+
+func (h *jobHeap) Push(x any) {
+	j := x.(*Job) //nolint: errcheck
+	j.index = len(*h)
+
+	*h = append(*h, j)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	j := old[n-1]
+
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return j
+}
+
+// QueueOption is an option to configure a Queue.
+type QueueOption interface {
+	applyQueueOption(q *Queue)
+}
+
+type queueOptionFunc func(q *Queue)
+
+func (f queueOptionFunc) applyQueueOption(q *Queue) {
+	f(q)
+}
+
+// WithPreemption sets the preemption strategy of the queue.
+func WithPreemption(p Preemption) QueueOption {
+	return queueOptionFunc(func(q *Queue) {
+		q.preemption = p
+	})
+}
@@ -0,0 +1,205 @@
+package exec_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+type orderRecorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *orderRecorder) wait(t *testing.T, name string, j *exec.Job) {
+	t.Helper()
+
+	_, err := j.Wait()
+	assert.NoError(t, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.names = append(r.names, name)
+}
+
+func TestQueue_Priority(t *testing.T) {
+	t.Parallel()
+
+	q := exec.NewQueue(1)
+
+	blocker := q.Submit(context.Background(), 0, exec.Spec{Name: "sleep", Options: []exec.Option{exec.WithArgs("0.2")}})
+	low := q.Submit(context.Background(), 1, exec.Spec{Name: "true"})
+	high := q.Submit(context.Background(), 10, exec.Spec{Name: "true"})
+
+	var (
+		rec orderRecorder
+		wg  sync.WaitGroup
+	)
+
+	for name, j := range map[string]*exec.Job{"blocker": blocker, "low": low, "high": high} {
+		wg.Add(1)
+
+		go func(name string, j *exec.Job) {
+			defer wg.Done()
+
+			rec.wait(t, name, j)
+		}(name, j)
+	}
+
+	wg.Wait()
+	q.Close()
+
+	assert.Equal(t, []string{"blocker", "high", "low"}, rec.names)
+}
+
+func TestQueue_PreemptPause(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	q := exec.NewQueue(1, exec.WithPreemption(exec.PreemptPause))
+
+	low := q.Submit(context.Background(), 0, exec.Spec{
+		Name:    "sh",
+		Options: []exec.Option{exec.WithArgs("-c", "sleep 0.3; echo low"), exec.WithStdout(out)},
+	})
+	high := q.Submit(context.Background(), 10, exec.Spec{
+		Name:    "echo",
+		Options: []exec.Option{exec.WithArgs("high"), exec.WithStdout(out)},
+	})
+
+	_, err := high.Wait()
+	require.NoError(t, err)
+
+	_, err = low.Wait()
+	require.NoError(t, err)
+
+	q.Close()
+
+	assert.Equal(t, "high\nlow", getOutput(out))
+	assert.Equal(t, 1, low.Attempts())
+}
+
+func TestQueue_PreemptPause_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	q := exec.NewQueue(1, exec.WithPreemption(exec.PreemptPause))
+
+	// The first stage exits right away, the one that is still running must be suspended too.
+	low := q.Submit(context.Background(), 0, exec.Spec{
+		Name: "true",
+		Options: []exec.Option{
+			exec.PipeWith("sh", exec.WithArgs("-c", "sleep 0.3; echo low")),
+			exec.WithStdout(out),
+		},
+	})
+	high := q.Submit(context.Background(), 10, exec.Spec{
+		Name:    "echo",
+		Options: []exec.Option{exec.WithArgs("high"), exec.WithStdout(out)},
+	})
+
+	_, err := high.Wait()
+	require.NoError(t, err)
+
+	_, err = low.Wait()
+	require.NoError(t, err)
+
+	q.Close()
+
+	assert.Equal(t, "high\nlow", getOutput(out))
+	assert.Equal(t, 1, low.Attempts())
+}
+
+func TestQueue_Submit_FromHook(t *testing.T) {
+	t.Parallel()
+
+	q := exec.NewQueue(1)
+	inner := make(chan *exec.Job, 1)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		// The command is started once the queue is unlocked, so its hooks can submit to the same queue.
+		outer := q.Submit(context.Background(), 0, exec.Spec{
+			Name: "true",
+			Options: []exec.Option{exec.WithBeforeStart(func(ctx context.Context, _ *exec.Cmd) error {
+				inner <- q.Submit(ctx, 0, exec.Spec{Name: "true"})
+
+				return nil
+			})},
+		})
+
+		_, err := outer.Wait()
+		assert.NoError(t, err)
+
+		_, err = (<-inner).Wait()
+		assert.NoError(t, err)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the queue is deadlocked")
+	}
+
+	q.Close()
+}
+
+func TestQueue_PreemptRequeue(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	q := exec.NewQueue(1, exec.WithPreemption(exec.PreemptRequeue))
+
+	low := q.Submit(context.Background(), 0, exec.Spec{
+		Name:    "sh",
+		Options: []exec.Option{exec.WithArgs("-c", "sleep 0.3; echo low"), exec.WithStdout(out)},
+	})
+	high := q.Submit(context.Background(), 10, exec.Spec{
+		Name:    "echo",
+		Options: []exec.Option{exec.WithArgs("high"), exec.WithStdout(out)},
+	})
+
+	_, err := high.Wait()
+	require.NoError(t, err)
+
+	_, err = low.Wait()
+	require.NoError(t, err)
+
+	q.Close()
+
+	assert.Equal(t, "high\nlow", getOutput(out))
+	assert.Equal(t, 2, low.Attempts())
+}
+
+func TestQueue_Closed(t *testing.T) {
+	t.Parallel()
+
+	q := exec.NewQueue(1)
+	q.Close()
+
+	_, err := q.Submit(context.Background(), 0, exec.Spec{Name: "true"}).Wait()
+
+	assert.ErrorIs(t, err, exec.ErrQueueClosed)
+}
+
+func TestQueue_LookupError(t *testing.T) {
+	t.Parallel()
+
+	q := exec.NewQueue(2)
+
+	_, err := q.Submit(context.Background(), 0, exec.Spec{Name: "not_found"}).Wait()
+
+	q.Close()
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+}
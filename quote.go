@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Quote quotes the argument so that it is read back as a single argument by the shell of the current platform: a POSIX
+// shell on Unix, the CommandLineToArgvW rules on Windows.
+func Quote(arg string) string {
+	if runtime.GOOS == "windows" {
+		return QuoteWindows(arg)
+	}
+
+	return QuotePOSIX(arg)
+}
+
+// QuoteCommand quotes the arguments with Quote and joins them with spaces, so the result can be pasted to a terminal or
+// persisted and run later.
+func QuoteCommand(args []string) string {
+	quoted := make([]string, len(args))
+
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// QuotePOSIX quotes the argument for a POSIX shell. The argument is returned as is when it does not contain any special
+// character, otherwise it is wrapped in single quotes.
+func QuotePOSIX(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+
+	if strings.IndexFunc(arg, isPOSIXSpecial) < 0 {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func isPOSIXSpecial(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+
+	return !strings.ContainsRune("_-+=@%:,./", r)
+}
+
+// QuoteWindows quotes the argument for the command line of a Windows process, following the rules of
+// CommandLineToArgvW like syscall.EscapeArg does.
+func QuoteWindows(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+
+	if !strings.ContainsAny(arg, " \t\n\v\"") {
+		return arg
+	}
+
+	b := new(strings.Builder)
+	b.WriteByte('"')
+
+	slashes := 0
+
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			slashes++
+
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+
+			slashes = 0
+
+		default:
+			slashes = 0
+		}
+
+		b.WriteByte(arg[i])
+	}
+
+	// The backslashes before the closing quote must be escaped too.
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package exec_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestQuotePOSIX(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		arg      string
+		expected string
+	}{
+		{arg: "", expected: "''"},
+		{arg: "plain", expected: "plain"},
+		{arg: "path/to/file-1.txt", expected: "path/to/file-1.txt"},
+		{arg: "--name=a,b:c@d", expected: "--name=a,b:c@d"},
+		{arg: "a b", expected: "'a b'"},
+		{arg: "it's", expected: `'it'\''s'`},
+		{arg: "$(rm -rf /)", expected: "'$(rm -rf /)'"},
+		{arg: "a\nb", expected: "'a\nb'"},
+		{arg: "*", expected: "'*'"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.arg, func(t *testing.T) {
+			t.Parallel()
+
+			actual := exec.QuotePOSIX(tc.arg)
+
+			assert.Equal(t, tc.expected, actual)
+
+			words, err := shellquote.Split(actual)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.arg}, words)
+		})
+	}
+}
+
+func TestQuoteWindows(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		arg      string
+		expected string
+	}{
+		{arg: "", expected: `""`},
+		{arg: `C:\path\file.txt`, expected: `C:\path\file.txt`},
+		{arg: "a b", expected: `"a b"`},
+		{arg: `say "hi"`, expected: `"say \"hi\""`},
+		{arg: `C:\dir with space\`, expected: `"C:\dir with space\\"`},
+		{arg: `a\"b c`, expected: `"a\\\"b c"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.arg, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, exec.QuoteWindows(tc.arg))
+		})
+	}
+}
+
+func TestQuoteCommand(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("posix only")
+	}
+
+	args := []string{"git", "commit", "-m", "it's done", ""}
+
+	actual := exec.QuoteCommand(args)
+
+	assert.Equal(t, `git commit -m 'it'\''s done' ''`, actual)
+
+	out, err := exec.RunOutput(context.Background(), "sh", exec.WithArgs("-c", `printf '%s|' `+exec.QuoteCommand(args[1:])))
+	require.NoError(t, err)
+
+	assert.Equal(t, "commit|-m|it's done||", out)
+}
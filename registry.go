@@ -0,0 +1,109 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRegistry is the registry used by ShutdownAll.
+var DefaultRegistry = NewRegistry()
+
+var registerAll atomic.Bool
+
+// Registry keeps track of the running commands.
+type Registry struct {
+	mu   sync.Mutex
+	cmds map[*Cmd]struct{}
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		cmds: make(map[*Cmd]struct{}),
+	}
+}
+
+func (r *Registry) add(c *Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cmds[c] = struct{}{}
+}
+
+func (r *Registry) remove(c *Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.cmds, c)
+}
+
+// Running returns the commands that are running.
+func (r *Registry) Running() []*Cmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmds := make([]*Cmd, 0, len(r.cmds))
+
+	for c := range r.cmds {
+		cmds = append(cmds, c)
+	}
+
+	return cmds
+}
+
+// Shutdown asks all the running commands to terminate and kills the ones that are still running when the context is
+// done. If the context has no deadline, the commands are given the default grace period of 5 seconds.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	gracePeriod := defaultGracePeriod
+
+	if deadline, ok := ctx.Deadline(); ok {
+		gracePeriod = time.Until(deadline)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs MultiError
+	)
+
+	for _, c := range r.Running() {
+		wg.Add(1)
+
+		go func(c *Cmd) {
+			defer wg.Done()
+
+			if err := c.Stop(ctx, gracePeriod); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+
+				errs = append(errs, fmt.Errorf("could not stop %s: %w", c.Path, err))
+			}
+		}(c)
+	}
+
+	wg.Wait()
+
+	return errs.errorOrNil()
+}
+
+// RegisterAll makes every command created afterward tracked by the DefaultRegistry while it is running.
+func RegisterAll(enabled bool) {
+	registerAll.Store(enabled)
+}
+
+// ShutdownAll gracefully stops all the running commands tracked by the DefaultRegistry.
+//
+// See Registry.Shutdown for more information.
+func ShutdownAll(ctx context.Context) error {
+	return DefaultRegistry.Shutdown(ctx)
+}
+
+// WithRegistry tracks the command in the given registry while it is running.
+func WithRegistry(r *Registry) Option {
+	return optionFunc(func(c *Cmd) {
+		c.registry = r
+	})
+}
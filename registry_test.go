@@ -0,0 +1,82 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestRegistry_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	r := exec.NewRegistry()
+
+	cmd1 := exec.Command("sleep", exec.WithArgs("10"), exec.WithRegistry(r))
+	cmd2 := exec.Command("sleep", exec.WithArgs("10"), exec.WithRegistry(r))
+	cmd3 := exec.Command("sleep", exec.WithArgs("10"))
+
+	for _, c := range []*exec.Cmd{cmd1, cmd2, cmd3} {
+		require.NoError(t, c.Start())
+	}
+
+	defer cmd3.Process.Kill() //nolint: errcheck
+
+	errs := make(chan error, 2)
+
+	for _, c := range []*exec.Cmd{cmd1, cmd2} {
+		go func(c *exec.Cmd) {
+			errs <- c.Wait()
+		}(c)
+	}
+
+	assert.Len(t, r.Running(), 2)
+	assert.True(t, isRunning(r, cmd1))
+	assert.True(t, isRunning(r, cmd2))
+	assert.False(t, isRunning(r, cmd3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, r.Shutdown(ctx))
+
+	assert.EqualError(t, <-errs, "signal: terminated")
+	assert.EqualError(t, <-errs, "signal: terminated")
+	assert.Empty(t, r.Running())
+}
+
+func TestShutdownAll(t *testing.T) { //nolint: paralleltest
+	exec.RegisterAll(true)
+	defer exec.RegisterAll(false)
+
+	cmd := exec.Command("sleep", exec.WithArgs("10"))
+
+	require.NoError(t, cmd.Start())
+
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- cmd.Wait()
+	}()
+
+	assert.True(t, isRunning(exec.DefaultRegistry, cmd))
+
+	require.NoError(t, exec.ShutdownAll(context.Background()))
+
+	assert.EqualError(t, <-errs, "signal: terminated")
+	assert.False(t, isRunning(exec.DefaultRegistry, cmd))
+}
+
+func isRunning(r *exec.Registry, cmd *exec.Cmd) bool {
+	for _, c := range r.Running() {
+		if c == cmd {
+			return true
+		}
+	}
+
+	return false
+}
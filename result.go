@@ -0,0 +1,83 @@
+package exec
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of an execution.
+type Result struct {
+	// Cmd is the executed command.
+	Cmd *Cmd
+	// ExitCode is the exit code of the process, or -1 if the process has not exited or was terminated by a signal.
+	ExitCode int
+	// Stderr is the captured standard error.
+	Stderr string
+	// Duration is the time elapsed between the start and the exit of the process.
+	Duration time.Duration
+	// PID is the process id, 0 if the process has not started.
+	PID int
+	// Signaled reports whether the process was terminated by a signal.
+	Signaled bool
+	// Err is the error returned by the execution.
+	Err error
+	// Outputs are the files collected by WithOutputFiles, it is nil without the option.
+	Outputs fs.FS
+	// Checksum is the digest of the standard output computed by WithOutputChecksum, it is nil without the option.
+	Checksum []byte
+}
+
+// Result returns the outcome of the command once it has been waited, or could not be started, with the error returned
+// by Run or Wait. With WithRetry, it is the outcome of the last attempt, with the error of every attempt.
+func (c *Cmd) Result() Result {
+	err := c.waitErr
+	if err == nil && c.ProcessState == nil {
+		err = c.Err
+	}
+
+	return newResult(c, err)
+}
+
+func newResult(c *Cmd, err error) Result {
+	r := Result{
+		Cmd:      c,
+		ExitCode: -1,
+		Err:      err,
+	}
+
+	if c == nil {
+		return r
+	}
+
+	c = c.lastAttempt()
+	r.Cmd = c
+
+	if c.Process != nil {
+		r.PID = c.Process.Pid
+	}
+
+	if c.ProcessState != nil {
+		r.ExitCode = c.ProcessState.ExitCode()
+		r.Signaled = isSignaled(c.ProcessState)
+	}
+
+	r.Stderr = strings.Trim(c.CapturedStderr(), "\r\n ")
+	r.Duration = c.duration
+	r.Outputs = c.OutputFiles()
+	r.Checksum = c.OutputChecksum()
+
+	return r
+}
+
+// Spec describes a command to be created.
+type Spec struct {
+	Name    string
+	Options []Option
+}
+
+// Command creates the command described by the spec.
+func (s Spec) Command(ctx context.Context) *Cmd {
+	return CommandContext(ctx, s.Name, append(s.Options[:len(s.Options):len(s.Options)], WithStderrCapture())...)
+}
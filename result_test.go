@@ -0,0 +1,76 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_Result(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", "echo oops >&2; exit 3"),
+		exec.WithErrorStderr(100),
+	)
+	require.Error(t, err)
+
+	r := cmd.Result()
+
+	assert.Equal(t, cmd, r.Cmd)
+	assert.Equal(t, 3, r.ExitCode)
+	assert.Equal(t, "oops", r.Stderr)
+	assert.Equal(t, cmd.Process.Pid, r.PID)
+	assert.False(t, r.Signaled)
+	assert.Positive(t, r.Duration)
+	assert.Equal(t, err, r.Err)
+}
+
+func TestCmd_Result_Signaled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	cmd, err := exec.RunWithContext(ctx, "sleep", exec.WithArgs("10"))
+	require.Error(t, err)
+
+	r := cmd.Result()
+
+	assert.Equal(t, -1, r.ExitCode)
+	assert.True(t, r.Signaled)
+	assert.Equal(t, err, r.Err)
+}
+
+func TestCmd_Result_NotFound(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("random-name-that-does-not-exist")
+	require.Error(t, err)
+
+	r := cmd.Result()
+
+	assert.Equal(t, -1, r.ExitCode)
+	assert.Zero(t, r.PID)
+	assert.ErrorIs(t, r.Err, exec.ErrNotFound)
+}
+
+func TestCmd_Result_Retry(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", "exit 1"),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+	require.Error(t, err)
+
+	r := cmd.Result()
+
+	assert.Equal(t, cmd.Attempts()[1], r.Cmd)
+	assert.Equal(t, err, r.Err)
+}
@@ -0,0 +1,167 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ResultRecord is the persisted form of the Result of an execution. The arguments are redacted.
+type ResultRecord struct {
+	ID        uint64        `json:"id"`
+	Name      string        `json:"name"`
+	Path      string        `json:"path"`
+	Args      []string      `json:"args"`
+	ExitCode  int           `json:"exit_code"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+func newResultRecord(c *Cmd, err error) ResultRecord {
+	rec := ResultRecord{
+		Name:      c.name,
+		Path:      c.Path,
+		Args:      c.redact(c.Args...),
+		ExitCode:  -1,
+		StartedAt: c.startedAt,
+		Duration:  c.duration,
+	}
+
+	if c.ProcessState != nil {
+		rec.ExitCode = c.ProcessState.ExitCode()
+	}
+
+	if err != nil {
+		rec.Error = c.redactString(err.Error())
+	}
+
+	return rec
+}
+
+// ResultQuery filters the records of a ResultStore. The zero value matches every record.
+type ResultQuery struct {
+	// Name matches the name of the command as it was given to Command, such as "git".
+	Name string
+	// Since matches the executions started at or after the time.
+	Since time.Time
+	// Until matches the executions started before the time.
+	Until time.Time
+	// ExitCodes matches the executions that exited with one of the codes.
+	ExitCodes []int
+	// Limit is the maximum number of records to return, 0 means no limit.
+	Limit int
+}
+
+// Match reports whether the record matches the query, regardless of the limit.
+func (q ResultQuery) Match(r ResultRecord) bool {
+	if q.Name != "" && q.Name != r.Name {
+		return false
+	}
+
+	if !q.Since.IsZero() && r.StartedAt.Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && !r.StartedAt.Before(q.Until) {
+		return false
+	}
+
+	if len(q.ExitCodes) == 0 {
+		return true
+	}
+
+	for _, code := range q.ExitCodes {
+		if code == r.ExitCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResultStore persists the results of the executions.
+type ResultStore interface {
+	// Save persists the record, the store assigns the ID.
+	Save(ctx context.Context, r ResultRecord) error
+	// Query returns the records matching the query, the most recent first.
+	Query(ctx context.Context, q ResultQuery) ([]ResultRecord, error)
+}
+
+// MemoryResultStore keeps the records in memory. The records are never evicted, the store grows with every execution,
+// so it is meant for the tests and the short-lived processes. The long-running ones should use a persistent store, like
+// the ones of the boltstore and sqlitestore packages.
+type MemoryResultStore struct {
+	mu      sync.Mutex
+	records []ResultRecord
+}
+
+var _ ResultStore = (*MemoryResultStore)(nil)
+
+// NewMemoryResultStore creates a new MemoryResultStore.
+func NewMemoryResultStore() *MemoryResultStore {
+	return &MemoryResultStore{}
+}
+
+// Save appends the record.
+func (s *MemoryResultStore) Save(_ context.Context, r ResultRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = uint64(len(s.records) + 1)
+	s.records = append(s.records, r)
+
+	return nil
+}
+
+// Query returns the records matching the query, the most recent first.
+func (s *MemoryResultStore) Query(_ context.Context, q ResultQuery) ([]ResultRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []ResultRecord
+
+	for _, r := range s.records {
+		if q.Match(r) {
+			result = append(result, r)
+		}
+	}
+
+	SortResultRecords(result)
+
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+
+	return result, nil
+}
+
+// SortResultRecords sorts the records from the most recent to the oldest.
+func SortResultRecords(records []ResultRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].StartedAt.Equal(records[j].StartedAt) {
+			return records[i].ID > records[j].ID
+		}
+
+		return records[i].StartedAt.After(records[j].StartedAt)
+	})
+}
+
+// WithResultStore persists the result of the command in the store once it exits.
+func WithResultStore(s ResultStore) Option {
+	return optionFunc(func(c *Cmd) {
+		c.resultStore = s
+	})
+}
+
+func (c *Cmd) saveResult(err error) {
+	if c.resultStore == nil {
+		return
+	}
+
+	if err := c.resultStore.Save(c.ctx, newResultRecord(c, err)); err != nil {
+		c.logger.Debug(c.ctx, fmt.Sprintf("failed to save result of `%s`", c.name), "error", err)
+	}
+}
@@ -0,0 +1,44 @@
+package exec_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+	exectest "go.nhat.io/exec/test"
+)
+
+func TestMemoryResultStore(t *testing.T) {
+	t.Parallel()
+
+	exectest.ResultStore(t, exec.NewMemoryResultStore())
+}
+
+func TestWithResultStore(t *testing.T) {
+	t.Parallel()
+
+	store := exec.NewMemoryResultStore()
+
+	_, err := exec.Run("sh", exec.WithArgs("-c", "echo secret >&2; exit 3"),
+		exec.WithStderr(io.Discard),
+		exec.RedactArgs("secret"),
+		exec.WithResultStore(store),
+	)
+	require.Error(t, err)
+
+	_, err = exec.Run("echo", exec.WithResultStore(store), exec.WithStdout(io.Discard))
+	require.NoError(t, err)
+
+	records, err := store.Query(context.Background(), exec.ResultQuery{Name: "sh"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	assert.Equal(t, []string{"-c", "echo ****** >&2; exit 3"}, records[0].Args[1:])
+	assert.Equal(t, 3, records[0].ExitCode)
+	assert.Equal(t, "exit status 3", records[0].Error)
+	assert.False(t, records[0].StartedAt.IsZero())
+}
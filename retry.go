@@ -0,0 +1,286 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultRetryMultiplier = 2
+)
+
+// RetryPolicy configures how a failed command is run again.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. The command is not retried if it is not
+	// greater than 1.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt, 100ms if it is not positive.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, there is no cap if it is not positive.
+	MaxBackoff time.Duration
+	// Multiplier is the factor applied to the delay after every attempt, 2 if it is not greater than 1.
+	Multiplier float64
+	// Jitter is the fraction of the delay, between 0 and 1, that is randomly removed, so the commands failing at the same
+	// time are not retried at the same time.
+	Jitter float64
+	// Retryable reports whether the error of an attempt is worth retrying. By default, every error is retried except
+	// the commands that can not be found or configured.
+	Retryable func(err error) bool
+}
+
+// RetryOnExitCodes returns a Retryable predicate that retries the commands that exited with one of the codes.
+func RetryOnExitCodes(codes ...int) func(err error) bool {
+	return func(err error) bool {
+		code, ok := ExitCode(err)
+
+		return ok && containsInt(codes, code)
+	}
+}
+
+func containsInt(values []int, v int) bool {
+	for _, i := range values {
+		if i == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrOptionConflict)
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d, multiplier := p.InitialBackoff, p.Multiplier
+
+	if d <= 0 {
+		d = defaultRetryBackoff
+	}
+
+	if multiplier <= 1 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	backoff := float64(d) * math.Pow(multiplier, float64(attempt-1))
+
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	// Without a maximum, the backoff of the late attempts overflows a time.Duration, or even a float64.
+	backoff = math.Min(backoff, math.MaxInt64)
+
+	if p.Jitter > 0 {
+		backoff -= backoff * math.Min(p.Jitter, 1) * rand.Float64() //nolint: gosec
+	}
+
+	// math.MaxInt64 is rounded up to 1<<63 as a float64, it does not fit in a time.Duration.
+	if backoff >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return time.Duration(backoff)
+}
+
+// WithRetry runs the command again when it fails, according to the policy. The whole pipeline is built again from the
+// options and run from the start for every attempt, each attempt has its own span with the exec.attempt attribute.
+//
+// Only Run and the functions using it retry the command, Start and Wait run one attempt. The streams, the environment
+// and the directory of the command, even when they are set after it is created, are shared by the attempts: the
+// standard input must be readable again, for example with WithStdinFS, and the standard output receives the output of
+// every attempt, except for RunOutput, Output and CombinedOutput which only keep the last one.
+//
+// The command can not be adopted by FromCmd.
+func WithRetry(policy RetryPolicy) Option {
+	return optionFunc(func(c *Cmd) {
+		c.retry = &policy
+	})
+}
+
+// RetryError is the error of a command that failed after several attempts. It matches the errors of every attempt with
+// errors.Is, and the most recent one first with errors.As.
+type RetryError struct {
+	// Errors are the errors of the attempts, in order.
+	Errors []error
+}
+
+// Error returns the number of attempts and the error of the last one.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("exec: failed after %d attempts: %s", len(e.Errors), e.Errors[len(e.Errors)-1])
+}
+
+// Unwrap returns the errors of the attempts.
+func (e *RetryError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether the error of any attempt matches the target.
+func (e *RetryError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the most recent error of the attempts that matches the target.
+func (e *RetryError) As(target any) bool {
+	for i := len(e.Errors) - 1; i >= 0; i-- {
+		if errors.As(e.Errors[i], target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retrySpec is what the command is built from, so it can be built again for every attempt.
+type retrySpec struct {
+	ctx  context.Context //nolint: containedctx
+	name string
+	opts []Option
+}
+
+// Attempts returns the commands that have been run by WithRetry, in order, starting with the command itself. Without
+// the option, it only contains the command itself.
+func (c *Cmd) Attempts() []*Cmd {
+	if len(c.attempts) == 0 {
+		return []*Cmd{c}
+	}
+
+	return c.attempts
+}
+
+func (c *Cmd) lastAttempt() *Cmd {
+	if len(c.attempts) == 0 {
+		return c
+	}
+
+	return c.attempts[len(c.attempts)-1]
+}
+
+func (c *Cmd) runOnce() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	return c.Wait()
+}
+
+// attemptState is what is set on a stage after the command is created, by Output or by the caller, every attempt
+// is given the same, like cloneCmd does for FromCmd.
+type attemptState struct {
+	stdin         io.Reader
+	stdout        io.Writer
+	stderr        io.Writer
+	env           []string
+	dir           string
+	captureStderr bool
+}
+
+func attemptStates(c *Cmd) []attemptState {
+	stages := c.Pipeline()
+	states := make([]attemptState, len(stages))
+
+	for i, s := range stages {
+		states[i] = attemptState{
+			stdin:         s.Stdin,
+			stdout:        s.Stdout,
+			stderr:        s.Stderr,
+			env:           s.Env,
+			dir:           s.Dir,
+			captureStderr: s.captureStderr,
+		}
+	}
+
+	return states
+}
+
+func (c *Cmd) restoreAttemptStates(states []attemptState) {
+	for i, s := range c.Pipeline() {
+		if i >= len(states) {
+			return
+		}
+
+		s.Stdin = states[i].stdin
+		s.Stdout = resetOutput(states[i].stdout)
+		s.Stderr = resetOutput(states[i].stderr)
+		s.Env = states[i].env
+		s.Dir = states[i].dir
+		s.captureStderr = states[i].captureStderr
+	}
+}
+
+// resetOutput discards the output of the previous attempt when the stream is the buffer of Output or CombinedOutput.
+func resetOutput(w io.Writer) io.Writer {
+	if b, ok := w.(*lockedBuffer); ok {
+		b.Reset()
+	}
+
+	return w
+}
+
+func (c *Cmd) runWithRetry() error {
+	var errs []error
+
+	// The streams are wrapped when the command starts, they are taken before.
+	states := attemptStates(c)
+	attempt := c
+
+	for n := 1; ; n++ {
+		attempt.attempt = n
+		c.attempts = append(c.attempts, attempt)
+
+		err := attempt.runOnce()
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+
+		if n >= c.retry.MaxAttempts || !c.retry.retryable(err) {
+			break
+		}
+
+		timer := time.NewTimer(c.retry.backoff(n))
+
+		select {
+		case <-c.retrySpec.ctx.Done():
+			timer.Stop()
+
+			return retryError(errs)
+
+		case <-timer.C:
+		}
+
+		attempt = newCmd(c.retrySpec.ctx, newStdCmd(c.retrySpec.ctx, filepath.Clean(c.retrySpec.name)), c.retrySpec.name,
+			c.retrySpec.opts...)
+		attempt.retry = nil
+
+		attempt.restoreAttemptStates(states)
+	}
+
+	return retryError(errs)
+}
+
+func retryError(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	return &RetryError{Errors: errs}
+}
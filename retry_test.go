@@ -0,0 +1,243 @@
+package exec_test
+
+import (
+	"context"
+	"math"
+	osexec "os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+// flakyScript fails until it has been run for the given number of times, it prints the number of the attempt.
+const flakyScript = `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; echo $n; [ $n -ge "$1" ]`
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	out, err := exec.RunOutput(context.Background(), "sh",
+		exec.WithArgs("-c", flakyScript, "sh", "3"),
+		exec.WithDir(t.TempDir()),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}),
+		exec.WithTracer(tracer),
+	)
+	require.NoError(t, err)
+
+	// Only the output of the last attempt is kept.
+	assert.Equal(t, "3", out)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	for i, s := range spans {
+		assert.Contains(t, s.Attributes(), attribute.Int("exec.attempt", i+1))
+	}
+}
+
+func TestWithRetry_Attempts(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", flakyScript, "sh", "2"),
+		exec.WithDir(t.TempDir()),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	attempts := cmd.Attempts()
+	require.Len(t, attempts, 2)
+
+	assert.Same(t, cmd, attempts[0])
+	assert.Equal(t, 1, attempts[0].ProcessState.ExitCode())
+	assert.Equal(t, 0, attempts[1].ProcessState.ExitCode())
+}
+
+func TestWithRetry_Output(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh",
+		exec.WithArgs("-c", flakyScript, "sh", "2"),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+
+	// The directory is set after the command is created, every attempt runs in it.
+	cmd.Dir = t.TempDir()
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+
+	// Only the output of the last attempt is kept.
+	assert.Equal(t, "2\n", string(out))
+	assert.Len(t, cmd.Attempts(), 2)
+}
+
+func TestWithRetry_AssignedFields(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	cmd := exec.Command("sh",
+		exec.WithArgs("-c", flakyScript+` && echo "$GREETING"`, "sh", "2"),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+
+	cmd.Dir = t.TempDir()
+	cmd.Env = []string{"GREETING=hello"}
+	cmd.Stdout = out
+
+	require.NoError(t, cmd.Run())
+
+	assert.Equal(t, "1\n2\nhello", getOutput(out))
+}
+
+func TestWithRetry_Exhausted(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("sh",
+		exec.WithArgs("-c", "exit 2"),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: 0.5}),
+	)
+
+	var retryErr *exec.RetryError
+
+	require.ErrorAs(t, err, &retryErr)
+
+	assert.Len(t, retryErr.Errors, 3)
+	assert.EqualError(t, err, "exec: failed after 3 attempts: exit status 2")
+	assert.ErrorIs(t, err, exec.ExitCodeError(2))
+
+	code, ok := exec.ExitCode(err)
+
+	assert.True(t, ok)
+	assert.Equal(t, 2, code)
+}
+
+func TestWithRetry_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := exec.Run("sh",
+		exec.WithArgs("-c", "exit 2"),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Retryable: exec.RetryOnExitCodes(1)}),
+	)
+
+	assert.EqualError(t, err, "exit status 2")
+	assert.Len(t, cmd.Attempts(), 1)
+}
+
+func TestWithRetry_ContextDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	cmd, err := exec.RunWithContext(ctx, "sh",
+		exec.WithArgs("-c", "exit 1"),
+		exec.WithRetry(exec.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}),
+	)
+
+	assert.ErrorIs(t, err, exec.ExitCodeError(1))
+	assert.Len(t, cmd.Attempts(), 1)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWithRetry_Result(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	results, err := exec.Map(context.Background(), []string{"2"}, func(input string) exec.Spec {
+		return exec.Spec{Name: "sh", Options: []exec.Option{
+			exec.WithArgs("-c", flakyScript, "sh", input),
+			exec.WithDir(dir),
+			exec.WithRetry(exec.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		}}
+	}, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, results[0].ExitCode)
+	assert.Equal(t, 0, results[0].Cmd.ProcessState.ExitCode())
+}
+
+func TestWithRetry_FromCmd(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.FromCmd(osexec.Command("true"), exec.WithRetry(exec.RetryPolicy{MaxAttempts: 2}))
+
+	assert.ErrorIs(t, cmd.Err, exec.ErrOptionConflict)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		policy   exec.RetryPolicy
+		attempt  int
+		expected time.Duration
+	}{
+		{
+			scenario: "first attempt",
+			policy:   exec.RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			attempt:  1,
+			expected: time.Second,
+		},
+		{
+			scenario: "later attempt",
+			policy:   exec.RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			attempt:  4,
+			expected: 8 * time.Second,
+		},
+		{
+			scenario: "max backoff",
+			policy:   exec.RetryPolicy{InitialBackoff: time.Second, Multiplier: 2, MaxBackoff: 5 * time.Second},
+			attempt:  4,
+			expected: 5 * time.Second,
+		},
+		{
+			scenario: "overflow",
+			policy:   exec.RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			attempt:  100,
+			expected: math.MaxInt64,
+		},
+		{
+			scenario: "infinite",
+			policy:   exec.RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			attempt:  2000,
+			expected: math.MaxInt64,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, tc.policy.Backoff(tc.attempt))
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff_OverflowWithJitter(t *testing.T) {
+	t.Parallel()
+
+	policy := exec.RetryPolicy{InitialBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+
+	for _, attempt := range []int{100, 2000} {
+		backoff := policy.Backoff(attempt)
+
+		assert.GreaterOrEqual(t, backoff, time.Duration(math.MaxInt64/2))
+	}
+}
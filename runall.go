@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSkipped indicates that a command of a batch has not been run because a previous one has failed.
+var ErrSkipped = errors.New("exec: skipped after a previous failure")
+
+// RunAll runs the commands one after another, in order. By default, every command is executed and the returned error
+// aggregates all the failures in a MultiError. With FailFast, the batch stops at the first failure, which is returned,
+// and the result of every command that has not been run has ErrSkipped.
+//
+// When the context is done, the commands that have not been run are not started, their result has the error of the
+// context.
+func RunAll(ctx context.Context, cmds []*Cmd, opts ...BatchOption) ([]Result, error) {
+	cfg := newBatchConfig(opts...)
+	results := make([]Result, len(cmds))
+
+	for i, cmd := range cmds {
+		if err := ctx.Err(); err != nil {
+			skipAll(results[i:], cmds[i:], err)
+
+			if cfg.failFast {
+				return results, err
+			}
+
+			break
+		}
+
+		if cmd.Err != nil {
+			results[i] = newResult(cmd, cmd.Err)
+		} else {
+			results[i] = newResult(cmd, cmd.Run())
+		}
+
+		if results[i].Err != nil && cfg.failFast {
+			skipAll(results[i+1:], cmds[i+1:], ErrSkipped)
+
+			return results, results[i].Err
+		}
+	}
+
+	return results, collectErrors(results)
+}
+
+func skipAll(results []Result, cmds []*Cmd, err error) {
+	for i, cmd := range cmds {
+		results[i] = Result{Cmd: cmd, ExitCode: -1, Err: err}
+	}
+}
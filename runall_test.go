@@ -0,0 +1,86 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestRunAll_Success(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	results, err := exec.RunAll(context.Background(), []*exec.Cmd{
+		exec.Command("echo", exec.WithArgs("a"), exec.WithStdout(out)),
+		exec.Command("echo", exec.WithArgs("b"), exec.WithStdout(out)),
+		exec.Command("echo", exec.WithArgs("c"), exec.WithStdout(out)),
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "a\nb\nc", getOutput(out))
+
+	for _, r := range results {
+		assert.Equal(t, 0, r.ExitCode)
+	}
+}
+
+func TestRunAll_ContinueOnError(t *testing.T) {
+	t.Parallel()
+
+	results, err := exec.RunAll(context.Background(), []*exec.Cmd{
+		exec.Command("sh", exec.WithArgs("-c", "exit 1")),
+		exec.Command("not_found"),
+		exec.Command("true"),
+		exec.Command("sh", exec.WithArgs("-c", "exit 2")),
+	})
+
+	require.ErrorIs(t, err, exec.ExitCodeError(1))
+	require.ErrorIs(t, err, exec.ErrNotFound)
+	require.ErrorIs(t, err, exec.ExitCodeError(2))
+
+	expected := []int{1, -1, 0, 2}
+
+	for i, code := range expected {
+		assert.Equal(t, code, results[i].ExitCode)
+	}
+}
+
+func TestRunAll_FailFast(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	results, err := exec.RunAll(context.Background(), []*exec.Cmd{
+		exec.Command("echo", exec.WithArgs("a"), exec.WithStdout(out)),
+		exec.Command("sh", exec.WithArgs("-c", "exit 3")),
+		exec.Command("echo", exec.WithArgs("c"), exec.WithStdout(out)),
+	}, exec.FailFast())
+
+	require.EqualError(t, err, "exit status 3")
+
+	assert.Equal(t, "a", getOutput(out))
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[2].Err, exec.ErrSkipped)
+	assert.Nil(t, results[2].Cmd.ProcessState)
+}
+
+func TestRunAll_ContextDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := exec.RunAll(ctx, []*exec.Cmd{exec.Command("true"), exec.Command("true")})
+
+	require.ErrorIs(t, err, context.Canceled)
+
+	for _, r := range results {
+		assert.ErrorIs(t, r.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,55 @@
+package exec
+
+import (
+	"fmt"
+
+	"go.nhat.io/redact"
+)
+
+// WithSecretEnv sets the environment variable, like WithEnv, and masks its value wherever the package emits it: in the
+// arguments, the errors, the captured standard error and the logged output, in the logs, the spans and the records.
+func WithSecretEnv(key, value string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.setEnv(fmt.Sprintf("%s=%s", key, value))
+
+		if value != "" {
+			c.secrets = append(c.secrets, value)
+		}
+	})
+}
+
+// WithEnvRedaction masks the values of the environment variables, wherever the package emits them like WithSecretEnv,
+// for the variables that are not set by WithSecretEnv, such as the ones inherited from the current process or set by
+// WithEnvFile. The values are looked up once all the options are applied.
+func WithEnvRedaction(keys ...string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.secretEnvKeys = append(c.secretEnvKeys, keys...)
+	})
+}
+
+// redactSecrets adds the secret values of the environment to the redactor of the command, once its options are applied.
+func (c *Cmd) redactSecrets() {
+	secrets := c.secrets
+
+	if len(c.secretEnvKeys) > 0 {
+		env := c.EnvMap()
+
+		for _, key := range c.secretEnvKeys {
+			if v := env[key]; v != "" {
+				secrets = append(secrets, v)
+			}
+		}
+	}
+
+	c.secrets, c.secretEnvKeys = nil, nil
+
+	if len(secrets) == 0 {
+		return
+	}
+
+	prev, values := c.redact, redact.Values(secrets...)
+
+	c.redact = func(args ...string) []string {
+		return values.Redact(prev(args...)...)
+	}
+}
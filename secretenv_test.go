@@ -0,0 +1,101 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/bool64/ctxd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithSecretEnv(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+	logger := &ctxd.LoggerMock{}
+	out := newSafeBuffer()
+
+	_, err := exec.Run("sh",
+		exec.WithSecretEnv("TOKEN", "s3cr3t"),
+		exec.WithExpandEnv(),
+		exec.WithArgs("-c", `echo "$TOKEN"; echo "token $TOKEN" >&2; exit 1`, "$TOKEN"),
+		exec.WithTracer(tracer),
+		exec.WithLogger(logger),
+		exec.WithStdout(out),
+	)
+	require.Error(t, err)
+
+	// The process gets the value.
+	assert.Equal(t, "s3cr3t", getOutput(out))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	for _, attr := range spans[0].Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "s3cr3t", attr.Key)
+	}
+
+	require.Len(t, logger.LoggedEntries, 1)
+
+	entry := logger.LoggedEntries[0]
+
+	assert.Equal(t, "token ******", entry.Data["exec.output"])
+	assert.NotContains(t, entry.Data["exec.command"], "s3cr3t")
+}
+
+func TestWithSecretEnv_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("echo",
+		exec.WithSecretEnv("TOKEN", "s3cr3t"),
+		exec.WithArgs("hello"),
+		exec.Pipe("sh", "-c", `echo "$TOKEN" >&2; exit 1`),
+		exec.WithLogger(logger),
+	)
+	require.Error(t, err)
+
+	outputs := make([]string, 0, len(logger.LoggedEntries))
+
+	for _, e := range logger.LoggedEntries {
+		outputs = append(outputs, e.Data["exec.output"].(string)) //nolint: forcetypeassert
+	}
+
+	assert.Contains(t, outputs, "******")
+}
+
+func TestWithSecretEnv_Describe(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("curl",
+		exec.WithSecretEnv("TOKEN", "s3cr3t"),
+		exec.WithExpandEnv(),
+		exec.WithArgs("-H", "Authorization: Bearer s3cr3t"),
+	)
+
+	assert.Equal(t, []string{"-H", "Authorization: Bearer ******"}, cmd.Describe().Stages[0].Args)
+	assert.NotContains(t, cmd.String(), "s3cr3t")
+}
+
+func TestWithEnvRedaction(t *testing.T) {
+	t.Parallel()
+
+	logger := &ctxd.LoggerMock{}
+
+	_, err := exec.Run("sh",
+		exec.WithEnvRedaction("PASSWORD"),
+		exec.WithEnvs(map[string]string{"PASSWORD": "hunter2", "USER": "admin"}),
+		exec.WithArgs("-c", `echo "$USER:$PASSWORD" >&2; exit 1`),
+		exec.WithLogger(logger),
+	)
+	require.Error(t, err)
+
+	require.Len(t, logger.LoggedEntries, 1)
+	assert.Equal(t, "admin:******", logger.LoggedEntries[0].Data["exec.output"])
+}
@@ -0,0 +1,163 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Shell parses a shell-like command line into a pipeline of commands, without running a shell. The words are split
+// like a POSIX shell would, with the single quotes, the double quotes and the backslashes, and an unquoted | starts a
+// new stage of the pipeline.
+//
+//	cmd, err := exec.Shell(ctx, `cat access.log | grep " 500 " | wc -l`, exec.WithStdout(os.Stdout))
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = cmd.Run()
+//
+// Nothing is expanded nor redirected: the other operators of the shell, such as ; & < > ( ), the variables and the
+// command substitutions are an ErrInvalidFormat error unless they are quoted or escaped, and so is a trailing backslash
+// that escapes nothing. The options are applied to
+// the first command, like for Command, so they can add more stages with Pipe.
+func Shell(ctx context.Context, line string, opts ...Option) (*Cmd, error) {
+	stages, err := splitPipeline(line)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := make([]Option, 0, len(stages)+len(opts))
+	pipeline = append(pipeline, WithArgs(stages[0][1:]...))
+
+	for _, words := range stages[1:] {
+		pipeline = append(pipeline, Pipe(words[0], words[1:]...))
+	}
+
+	return CommandContext(ctx, stages[0][0], append(pipeline, opts...)...), nil
+}
+
+// splitPipeline splits the command line into the words of every stage of the pipeline.
+func splitPipeline(line string) ([][]string, error) {
+	var (
+		stages [][]string
+		words  []string
+		word   strings.Builder
+		inWord bool
+	)
+
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+		}
+
+		word.Reset()
+
+		inWord = false
+	}
+
+	endStage := func(pos int) error {
+		endWord()
+
+		if len(words) == 0 {
+			return fmt.Errorf("%w: empty command at %d", ErrInvalidFormat, pos)
+		}
+
+		stages = append(stages, words)
+		words = nil
+
+		return nil
+	}
+
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; ch {
+		case ' ', '\t', '\n':
+			endWord()
+
+		case '|':
+			if err := endStage(i); err != nil {
+				return nil, err
+			}
+
+		case '\\':
+			// A backslash-newline is removed, like a line continuation, it does not start a word.
+			if i++; i >= len(line) {
+				return nil, fmt.Errorf("%w: trailing backslash at %d", ErrInvalidFormat, i-1)
+			}
+
+			if line[i] != '\n' {
+				inWord = true
+
+				word.WriteByte(line[i])
+			}
+
+		case '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated single quote at %d", ErrInvalidFormat, i)
+			}
+
+			inWord = true
+
+			word.WriteString(line[i+1 : i+1+end])
+
+			i += end + 1
+
+		case '"':
+			end, err := readDoubleQuoted(line, i, &word)
+			if err != nil {
+				return nil, err
+			}
+
+			inWord = true
+			i = end
+
+		default:
+			if strings.IndexByte(";&<>()$`", ch) >= 0 {
+				return nil, fmt.Errorf("%w: unsupported %q at %d", ErrInvalidFormat, ch, i)
+			}
+
+			inWord = true
+
+			word.WriteByte(ch)
+		}
+	}
+
+	if err := endStage(len(line)); err != nil {
+		return nil, err
+	}
+
+	return stages, nil
+}
+
+// readDoubleQuoted writes the content of the double-quoted string that starts at the position to the word and returns
+// the position of the closing quote. A backslash only escapes the characters that are special in double quotes.
+func readDoubleQuoted(line string, start int, word *strings.Builder) (int, error) {
+	for i := start + 1; i < len(line); i++ {
+		switch ch := line[i]; ch {
+		case '"':
+			return i, nil
+
+		case '\\':
+			if i+1 < len(line) && strings.IndexByte("$`\"\\\n", line[i+1]) >= 0 {
+				i++
+
+				if line[i] != '\n' {
+					word.WriteByte(line[i])
+				}
+
+				continue
+			}
+
+			word.WriteByte(ch)
+
+		case '$', '`':
+			return 0, fmt.Errorf("%w: unsupported %q at %d", ErrInvalidFormat, ch, i)
+
+		default:
+			word.WriteByte(ch)
+		}
+	}
+
+	return 0, fmt.Errorf("%w: unterminated double quote at %d", ErrInvalidFormat, start)
+}
@@ -0,0 +1,134 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestShell(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		line          string
+		expected      [][]string
+		expectedError string
+	}{
+		{
+			scenario: "single command",
+			line:     "echo hello  world",
+			expected: [][]string{{"echo", "hello", "world"}},
+		},
+		{
+			scenario: "pipeline",
+			line:     "cat access.log | grep 500|wc -l",
+			expected: [][]string{{"cat", "access.log"}, {"grep", "500"}, {"wc", "-l"}},
+		},
+		{
+			scenario: "quotes and escapes",
+			line:     `echo 'a | b' "it's \"\$\" \n" c\ d '' | cat`,
+			expected: [][]string{{"echo", "a | b", `it's "$" \n`, "c d", ""}, {"cat"}},
+		},
+		{
+			scenario: "escaped operators",
+			line:     `echo \; \& \$HOME \|`,
+			expected: [][]string{{"echo", ";", "&", "$HOME", "|"}},
+		},
+		{
+			scenario: "line continuation",
+			line:     "echo foo \\\n bar fo\\\no",
+			expected: [][]string{{"echo", "foo", "bar", "foo"}},
+		},
+		{
+			scenario:      "trailing backslash",
+			line:          `echo foo \`,
+			expectedError: "exec: invalid command format: trailing backslash at 9",
+		},
+		{
+			scenario:      "empty line",
+			line:          "  ",
+			expectedError: "exec: invalid command format: empty command at 2",
+		},
+		{
+			scenario:      "empty stage",
+			line:          "echo || cat",
+			expectedError: "exec: invalid command format: empty command at 6",
+		},
+		{
+			scenario:      "trailing pipe",
+			line:          "echo |",
+			expectedError: "exec: invalid command format: empty command at 6",
+		},
+		{
+			scenario:      "redirection",
+			line:          "echo hello > file",
+			expectedError: "exec: invalid command format: unsupported '>' at 11",
+		},
+		{
+			scenario:      "variable",
+			line:          `echo "$HOME"`,
+			expectedError: "exec: invalid command format: unsupported '$' at 6",
+		},
+		{
+			scenario:      "unterminated single quote",
+			line:          "echo 'hello",
+			expectedError: "exec: invalid command format: unterminated single quote at 5",
+		},
+		{
+			scenario:      "unterminated double quote",
+			line:          `echo "hello`,
+			expectedError: "exec: invalid command format: unterminated double quote at 5",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			cmd, err := exec.Shell(context.Background(), tc.line)
+
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				assert.ErrorIs(t, err, exec.ErrInvalidFormat)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			actual := make([][]string, 0, len(tc.expected))
+
+			for _, stage := range cmd.Pipeline() {
+				actual = append(actual, append([]string{filepath.Base(stage.Args[0])}, stage.Args[1:]...))
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestShell_Run(t *testing.T) {
+	t.Parallel()
+
+	out := new(bytes.Buffer)
+
+	cmd, err := exec.Shell(context.Background(), `printf 'a\nb 500\nc 500\n' | grep " 500"`,
+		exec.WithStdout(out),
+		exec.Pipe("wc", "-l"),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, cmd.Run())
+
+	assert.Equal(t, "2", strings.TrimSpace(out.String()))
+}
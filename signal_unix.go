@@ -0,0 +1,30 @@
+//go:build !windows
+
+package exec
+
+import (
+	"os"
+	"syscall"
+)
+
+var stopSignal = syscall.SIGTERM
+
+func suspendProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGSTOP) //nolint: wrapcheck
+}
+
+func resumeProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGCONT) //nolint: wrapcheck
+}
+
+func isBrokenPipeSignal(state *os.ProcessState) bool {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+
+	return ok && ws.Signaled() && ws.Signal() == syscall.SIGPIPE
+}
+
+func isSignaled(state *os.ProcessState) bool {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+
+	return ok && ws.Signaled()
+}
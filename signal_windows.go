@@ -0,0 +1,51 @@
+package exec
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+var stopSignal = os.Kill
+
+var (
+	ntdll            = windows.NewLazySystemDLL("ntdll.dll")
+	ntSuspendProcess = ntdll.NewProc("NtSuspendProcess")
+	ntResumeProcess  = ntdll.NewProc("NtResumeProcess")
+)
+
+// suspendProcess suspends every thread of the process with NtSuspendProcess, there is no SIGSTOP on Windows.
+func suspendProcess(p *os.Process) error {
+	return callProcess(ntSuspendProcess, p)
+}
+
+func resumeProcess(p *os.Process) error {
+	return callProcess(ntResumeProcess, p)
+}
+
+func callProcess(proc *windows.LazyProc, p *os.Process) error {
+	if err := proc.Find(); err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	h, err := windows.OpenProcess(windows.PROCESS_SUSPEND_RESUME, false, uint32(p.Pid))
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	defer windows.CloseHandle(h) //nolint: errcheck
+
+	if status, _, _ := proc.Call(uintptr(h)); status != 0 {
+		return windows.NTStatus(status)
+	}
+
+	return nil
+}
+
+func isBrokenPipeSignal(*os.ProcessState) bool {
+	return false
+}
+
+func isSignaled(*os.ProcessState) bool {
+	return false
+}
@@ -0,0 +1,56 @@
+//go:build go1.21
+
+package exec
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/bool64/ctxd"
+)
+
+// WithSlog logs with the structured logger of the standard library, instead of a ctxd.Logger. The fields of the
+// context that are set with ctxd.AddFields are logged as well. Every next stage of the pipeline inherits the logger.
+func WithSlog(logger *slog.Logger) Option {
+	return WithLogger(slogLogger{logger: logger})
+}
+
+// slogLogger is a ctxd.Logger that logs with a slog.Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+var _ ctxd.Logger = (*slogLogger)(nil)
+
+func (l slogLogger) log(ctx context.Context, level slog.Level, msg string, keysAndValues []interface{}) {
+	if fields := ctxd.Fields(ctx); len(fields) > 0 {
+		keysAndValues = append(fields[:len(fields):len(fields)], keysAndValues...)
+	}
+
+	l.logger.Log(ctx, level, msg, keysAndValues...)
+}
+
+// Debug logs a message at the debug level.
+func (l slogLogger) Debug(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, slog.LevelDebug, msg, keysAndValues)
+}
+
+// Info logs a message at the info level.
+func (l slogLogger) Info(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, slog.LevelInfo, msg, keysAndValues)
+}
+
+// Important logs a message at the info level, slog has no level that is always logged.
+func (l slogLogger) Important(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, slog.LevelInfo, msg, keysAndValues)
+}
+
+// Warn logs a message at the warn level.
+func (l slogLogger) Warn(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, slog.LevelWarn, msg, keysAndValues)
+}
+
+// Error logs a message at the error level.
+func (l slogLogger) Error(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.log(ctx, slog.LevelError, msg, keysAndValues)
+}
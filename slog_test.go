@@ -0,0 +1,59 @@
+//go:build go1.21
+
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bool64/ctxd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithSlog(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := ctxd.AddFields(context.Background(), "request_id", "42")
+
+	_, err := exec.RunWithContext(ctx, "sh",
+		exec.WithArgs("-c", "echo oops >&2; exit 3"),
+		exec.WithSlog(logger),
+	)
+	require.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var entry map[string]interface{}
+
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+
+	assert.Equal(t, "DEBUG", entry["level"])
+	assert.Equal(t, "failed to execute `sh`", entry["msg"])
+	assert.Equal(t, "42", entry["request_id"])
+	assert.Equal(t, float64(3), entry["exec.exit_code"])
+	assert.Equal(t, "oops", entry["exec.output"])
+}
+
+func TestWithSlog_Level(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	_, err := exec.Run("not_found", exec.WithSlog(logger))
+	require.Error(t, err)
+
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,161 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithSpanName sets the name of the span of the execution, exec:run by default, so the traces are easy to scan:
+//
+//	exec.Run("git", exec.WithArgs("clone", url), exec.WithSpanName("exec:git-clone"))
+//
+// The name is not inherited by the next stages of the pipeline, a stage can set its own with PipeWith.
+func WithSpanName(name string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.spanName = name
+	})
+}
+
+// WithSpanAttributes adds the attributes to the span of the execution. Every next stage of the pipeline inherits them.
+func WithSpanAttributes(attrs ...attribute.KeyValue) Option {
+	return optionFunc(func(c *Cmd) {
+		c.spanAttrs = append(c.spanAttrs[:len(c.spanAttrs):len(c.spanAttrs)], attrs...)
+	})
+}
+
+// spanNameOf returns the name of the span of the execution.
+func (c *Cmd) spanNameOf() string {
+	if c.spanName != "" {
+		return c.spanName
+	}
+
+	if c.stageName != "" {
+		return "exec:run:" + c.stageName
+	}
+
+	return "exec:run"
+}
+
+// inPipeline returns whether the command is a stage of a pipeline.
+func (c *Cmd) inPipeline() bool {
+	return c.Next != nil || c.prev != nil
+}
+
+// startPipelineSpan starts the exec:pipeline span when the command is the head of a pipeline. The spans of the stages
+// are its children, so each of them has its own timing, error and exit code.
+func (c *Cmd) startPipelineSpan() {
+	if c.tracer == nil || c.traceSampling != nil || c.Next == nil || c.prev != nil {
+		return
+	}
+
+	var stages int
+
+	for s := c; s != nil; s = s.Next {
+		stages++
+	}
+
+	c.ctx, c.pipelineSpan = c.tracer.Start(c.ctx, "exec:pipeline",
+		trace.WithAttributes(attribute.Int("exec.pipeline.stages", stages)),
+	)
+}
+
+// stageContext returns the context of the next stage, its span is a sibling of the one of the command, under the span
+// of the pipeline.
+func (c *Cmd) stageContext(ctx context.Context) context.Context {
+	head := c
+	for head.prev != nil {
+		head = head.prev
+	}
+
+	if head.pipelineSpan == nil {
+		return ctx
+	}
+
+	return trace.ContextWithSpan(ctx, head.pipelineSpan)
+}
+
+// stageIndex returns the position of the command in its pipeline, from 0.
+func (c *Cmd) stageIndex() int {
+	var i int
+
+	for p := c.prev; p != nil; p = p.prev {
+		i++
+	}
+
+	return i
+}
+
+// endSpan ends the span with the exit code and the error of the execution.
+func (c *Cmd) endSpan(span trace.Span, err error) {
+	span.SetAttributes(
+		attribute.Int("exec.exit_code", c.ProcessState.ExitCode()),
+	)
+
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+	} else {
+		c.recordCancelCause(span)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// endPipelineSpan ends the span of the pipeline with its error, if the command is its head.
+func (c *Cmd) endPipelineSpan(err error) {
+	span := c.pipelineSpan
+	if span == nil {
+		return
+	}
+
+	c.pipelineSpan = nil
+
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+	} else {
+		c.recordCancelCause(span)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// processAttributes returns the attributes of the semantic conventions of OpenTelemetry for the process of the
+// execution, the process.pid is added once it has started.
+func (c *Cmd) processAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.ProcessExecutableName(filepath.Base(c.Path)),
+		semconv.ProcessExecutablePath(c.Path),
+		semconv.ProcessCommandArgs(c.redact(c.Args...)...),
+		semconv.ProcessParentPID(os.Getpid()),
+	}
+
+	if host := hostname(); host != "" {
+		attrs = append(attrs, semconv.HostName(host))
+	}
+
+	return attrs
+}
+
+var (
+	hostnameOnce sync.Once
+	hostnameName string
+)
+
+// hostname returns the name of the host, it is looked up once.
+func hostname() string {
+	hostnameOnce.Do(func() {
+		hostnameName, _ = os.Hostname() //nolint: errcheck
+	})
+
+	return hostnameName
+}
@@ -0,0 +1,209 @@
+package exec_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithSpanName(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("echo",
+		exec.WithTracer(tracer),
+		exec.WithSpanName("exec:echo"),
+		exec.WithArgs("hello"),
+		exec.PipeWith("cat", exec.WithStageName("copy")),
+		exec.PipeWith("cat", exec.WithSpanName("exec:cat")),
+	)
+	require.NoError(t, err)
+
+	names := make([]string, 0, 4)
+
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"exec:pipeline", "exec:echo", "exec:run:copy", "exec:cat"}, names)
+}
+
+func TestWithSpanAttributes(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("echo",
+		exec.WithTracer(tracer),
+		exec.WithSpanAttributes(attribute.String("repo", "go-exec")),
+		exec.WithSpanAttributes(attribute.Int("shard", 1)),
+		exec.WithArgs("hello"),
+		exec.PipeWith("cat", exec.WithSpanAttributes(attribute.Bool("filter", true))),
+	)
+	require.NoError(t, err)
+
+	spans := stageSpans(recorder.Ended())
+	require.Len(t, spans, 2)
+
+	attrs := make([][]attribute.KeyValue, 0, len(spans))
+
+	for _, s := range spans {
+		var custom []attribute.KeyValue
+
+		for _, kv := range s.Attributes() {
+			switch kv.Key {
+			case "repo", "shard", "filter":
+				custom = append(custom, kv)
+			}
+		}
+
+		attrs = append(attrs, custom)
+	}
+
+	expected := [][]attribute.KeyValue{
+		{attribute.String("repo", "go-exec"), attribute.Int("shard", 1)},
+		{attribute.String("repo", "go-exec"), attribute.Int("shard", 1), attribute.Bool("filter", true)},
+	}
+
+	assert.ElementsMatch(t, expected, attrs)
+}
+
+func TestRun_Pipe_StageSpans(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	_, err := exec.RunWithContext(ctx, "echo",
+		exec.WithTracer(tracer),
+		exec.WithArgs("hello"),
+		exec.Pipe("sh", "-c", "sleep 0.2; cat"),
+		exec.Pipe("sh", "-c", "cat; exit 2"),
+	)
+	require.EqualError(t, err, "exit status 2")
+
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 5)
+
+	var pipeline sdktrace.ReadOnlySpan
+
+	stages := make(map[int64]sdktrace.ReadOnlySpan, 3)
+
+	for _, s := range spans {
+		switch s.Name() {
+		case "exec:pipeline":
+			pipeline = s
+
+		case "exec:run":
+			for _, attr := range s.Attributes() {
+				if attr.Key == "exec.stage.index" {
+					stages[attr.Value.AsInt64()] = s
+				}
+			}
+		}
+	}
+
+	require.NotNil(t, pipeline)
+	require.Len(t, stages, 3)
+
+	assert.Equal(t, parent.SpanContext().SpanID(), pipeline.Parent().SpanID())
+	assert.Contains(t, pipeline.Attributes(), attribute.Int("exec.pipeline.stages", 3))
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: "exit status 2"}, pipeline.Status())
+
+	for i, s := range stages {
+		assert.Equal(t, pipeline.SpanContext().SpanID(), s.Parent().SpanID(), "stage %d", i)
+	}
+
+	// Each stage has its own timing, error and exit code.
+	assert.Less(t, stages[0].EndTime().Sub(stages[0].StartTime()), 150*time.Millisecond)
+	assert.GreaterOrEqual(t, stages[1].EndTime().Sub(stages[1].StartTime()), 200*time.Millisecond)
+
+	assert.Equal(t, codes.Ok, stages[0].Status().Code)
+	assert.Equal(t, codes.Ok, stages[1].Status().Code)
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: "exit status 2"}, stages[2].Status())
+
+	assert.Contains(t, stages[0].Attributes(), attribute.Int("exec.exit_code", 0))
+	assert.Contains(t, stages[2].Attributes(), attribute.Int("exec.exit_code", 2))
+}
+
+func TestRun_Pipe_StageSpans_StartFailure(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("echo",
+		exec.WithTracer(tracer),
+		exec.WithDir("/does/not/exist"),
+		exec.Pipe("cat"),
+	)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	names := make([]string, 0, len(spans))
+
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+
+	assert.Contains(t, names, "exec:pipeline")
+}
+
+// stageSpans returns the spans of the stages, without the one of the pipeline.
+func stageSpans(spans []sdktrace.ReadOnlySpan) []sdktrace.ReadOnlySpan {
+	stages := make([]sdktrace.ReadOnlySpan, 0, len(spans))
+
+	for _, s := range spans {
+		if s.Name() != "exec:pipeline" {
+			stages = append(stages, s)
+		}
+	}
+
+	return stages
+}
+
+func TestRun_SpanProcessAttributes(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	cmd, err := exec.Run("echo",
+		exec.WithTracer(tracer),
+		exec.WithArgs("hello", "secret"),
+		exec.RedactArgs("secret"),
+	)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	attrs := spans[0].Attributes()
+
+	assert.Contains(t, attrs, attribute.Int("process.pid", cmd.Process.Pid))
+	assert.Contains(t, attrs, attribute.Int("process.parent_pid", os.Getpid()))
+	assert.Contains(t, attrs, attribute.String("process.executable.name", "echo"))
+	assert.Contains(t, attrs, attribute.String("process.executable.path", cmd.Path))
+	assert.Contains(t, attrs, attribute.StringSlice("process.command_args", []string{cmd.Path, "hello", "******"}))
+	assert.Contains(t, attrs, attribute.String("host.name", host))
+}
@@ -0,0 +1,93 @@
+package exec
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordProcessStarted sets the process.pid attribute of the span and adds the "process started" event, with the pid.
+func (c *Cmd) recordProcessStarted() {
+	c.span.SetAttributes(semconv.ProcessPID(c.Process.Pid))
+	c.span.AddEvent("process started", trace.WithAttributes(attribute.Int("exec.pid", c.Process.Pid)))
+}
+
+// recordProcessExited adds the "process exited" event to the span, with the exit code of the process, and the sizes of
+// the outputs as the exec.stdout_bytes and exec.stderr_bytes attributes.
+func (c *Cmd) recordProcessExited() {
+	c.span.AddEvent("process exited", trace.WithAttributes(attribute.Int("exec.exit_code", c.ProcessState.ExitCode())))
+
+	if c.outCounters == nil {
+		return
+	}
+
+	if w := c.outCounters.stdout; w != nil {
+		c.span.SetAttributes(attribute.Int64("exec.stdout_bytes", w.n))
+	}
+
+	if w := c.outCounters.stderr; w != nil {
+		c.span.SetAttributes(attribute.Int64("exec.stderr_bytes", w.n))
+	}
+}
+
+// outputCounters are the counters of the outputs of the process.
+type outputCounters struct {
+	stdout *outputCounter
+	stderr *outputCounter
+}
+
+// watchOutput counts the bytes that the process writes to its standard output and its standard error, and adds the
+// "first byte of output" event to the span when it writes for the first time. The outputs that are files, like the
+// pipes between the stages, are written by the process itself and are not watched.
+func (c *Cmd) watchOutput() {
+	if !c.span.IsRecording() {
+		return
+	}
+
+	var once sync.Once
+
+	watch := func(stream string, w *io.Writer) *outputCounter {
+		switch (*w).(type) {
+		case nil, *os.File:
+			return nil
+		}
+
+		oc := &outputCounter{Writer: *w, first: func() {
+			once.Do(func() {
+				c.span.AddEvent("first byte of output", trace.WithAttributes(attribute.String("exec.stream", stream)))
+			})
+		}}
+
+		*w = oc
+
+		return oc
+	}
+
+	c.outCounters = &outputCounters{
+		stdout: watch("stdout", &c.Cmd.Stdout),
+		stderr: watch("stderr", &c.Cmd.Stderr),
+	}
+}
+
+// outputCounter counts the bytes that are written, and calls first before the first write that is not empty.
+type outputCounter struct {
+	io.Writer
+
+	first func()
+	n     int64
+}
+
+func (w *outputCounter) Write(p []byte) (int, error) {
+	if w.n == 0 && len(p) > 0 {
+		w.first()
+	}
+
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+
+	return n, err //nolint: wrapcheck
+}
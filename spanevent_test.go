@@ -0,0 +1,167 @@
+package exec_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestRun_SpanEvents(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	out := newSafeBuffer()
+
+	cmd, err := exec.Run("sh",
+		exec.WithTracer(tracer),
+		exec.WithArgs("-c", "sleep 0.1; echo hello; exit 3"),
+		exec.WithStdout(out),
+	)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	// The last one is the exception of the error.
+	events := spans[0].Events()
+	require.Len(t, events, 4)
+
+	assert.Equal(t, "process started", events[0].Name)
+	assert.Equal(t, []attribute.KeyValue{attribute.Int("exec.pid", cmd.Process.Pid)}, events[0].Attributes)
+
+	assert.Equal(t, "first byte of output", events[1].Name)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("exec.stream", "stdout")}, events[1].Attributes)
+
+	assert.Equal(t, "process exited", events[2].Name)
+	assert.Equal(t, []attribute.KeyValue{attribute.Int("exec.exit_code", 3)}, events[2].Attributes)
+
+	assert.True(t, events[1].Time.After(events[0].Time))
+	assert.True(t, events[2].Time.After(events[1].Time))
+}
+
+func TestRun_SpanEvents_FirstByteOnce(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("sh",
+		exec.WithTracer(tracer),
+		exec.WithArgs("-c", "echo oops >&2; sleep 0.1; echo hello; echo world"),
+		exec.WithStdout(newSafeBuffer()),
+		exec.WithStderr(newSafeBuffer()),
+	)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	var firstBytes []attribute.KeyValue
+
+	for _, e := range spans[0].Events() {
+		if e.Name == "first byte of output" {
+			firstBytes = append(firstBytes, e.Attributes...)
+		}
+	}
+
+	assert.Equal(t, []attribute.KeyValue{attribute.String("exec.stream", "stderr")}, firstBytes)
+}
+
+func TestRun_SpanEvents_NoOutput(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("true", exec.WithTracer(tracer), exec.WithStdout(newSafeBuffer()))
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	names := make([]string, 0, 2)
+
+	for _, e := range spans[0].Events() {
+		names = append(names, e.Name)
+	}
+
+	assert.Equal(t, []string{"process started", "process exited"}, names)
+}
+
+func TestRun_SpanOutputSizes(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("sh",
+		exec.WithTracer(tracer),
+		exec.WithArgs("-c", "printf hello; printf oops >&2"),
+		exec.WithStdout(newSafeBuffer()),
+		exec.WithStderr(newSafeBuffer()),
+	)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("exec.stdout_bytes", 5))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("exec.stderr_bytes", 4))
+}
+
+func TestRun_SpanOutputSizes_Empty(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("true", exec.WithTracer(tracer), exec.WithStdout(newSafeBuffer()))
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("exec.stdout_bytes", 0))
+
+	for _, attr := range spans[0].Attributes() {
+		assert.NotEqual(t, attribute.Key("exec.stderr_bytes"), attr.Key)
+	}
+}
+
+func TestRun_Pipe_SpanOutputSizes(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("printf",
+		exec.WithTracer(tracer),
+		exec.WithArgs("hello world"),
+		exec.Pipe("wc", "-c"),
+		exec.WithStdout(newSafeBuffer()),
+	)
+	require.NoError(t, err)
+
+	sizes := make(map[string]int64)
+
+	for _, s := range stageSpans(recorder.Ended()) {
+		for _, attr := range s.Attributes() {
+			if attr.Key == "exec.stdout_bytes" {
+				sizes[filepath.Base(s.Attributes()[0].Value.AsStringSlice()[0])] = attr.Value.AsInt64()
+			}
+		}
+	}
+
+	// The output of the first stage goes through an OS pipe, the process writes it directly.
+	assert.Len(t, sizes, 1)
+	assert.Positive(t, sizes["wc"])
+}
@@ -0,0 +1,65 @@
+package exec
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const (
+	spawnAttempts = 6
+	spawnBackoff  = time.Millisecond
+)
+
+// spawn starts the process and retries when the failure is transient, such as ETXTBSY when the executable has just
+// been written and is still open by another process, or EAGAIN when the system is temporarily out of processes.
+//
+// An exec.Cmd can not be started twice, a retry starts a copy of it. A command adopted by FromCmd is not retried, the
+// copy would lose the context of its exec.Cmd.
+func (c *Cmd) spawn() error {
+	backoff := spawnBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := c.Cmd.Start()
+		if err == nil || c.adopted || attempt == spawnAttempts || !isTransientSpawnError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-c.ctx.Done():
+			timer.Stop()
+
+			return err
+
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		c.Cmd = cloneCmd(c)
+	}
+}
+
+func isTransientSpawnError(err error) bool {
+	return errors.Is(err, syscall.ETXTBSY) || errors.Is(err, syscall.EAGAIN)
+}
+
+// cloneCmd copies the configuration of the exec.Cmd of c into a new one that has not been started.
+func cloneCmd(c *Cmd) *exec.Cmd {
+	std := exec.CommandContext(c.ctx, c.Path) //nolint: gosec
+
+	std.Args = c.Args
+	std.Env = c.Env
+	std.Dir = c.Dir
+	std.Stdin = c.Stdin
+	std.Stdout = c.Stdout
+	std.Stderr = c.Stderr
+	std.ExtraFiles = c.ExtraFiles
+	std.SysProcAttr = c.SysProcAttr
+
+	cloneWaitDelay(std, c.Cmd)
+
+	return std
+}
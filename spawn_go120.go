@@ -0,0 +1,10 @@
+//go:build go1.20
+
+package exec
+
+import "os/exec"
+
+// cloneWaitDelay copies the wait delay. Cancel is not copied because it usually refers to the process of src.
+func cloneWaitDelay(dst, src *exec.Cmd) {
+	dst.WaitDelay = src.WaitDelay
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package exec_test
+
+import (
+	"context"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_Start_RetryTextFileBusy(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "script.sh")
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o755) //nolint: gosec
+	require.NoError(t, err)
+
+	_, err = f.WriteString("#!/bin/sh\necho hello world\n")
+	require.NoError(t, err)
+
+	// The script is still open for writing when the command is started, it is closed shortly after.
+	time.AfterFunc(5*time.Millisecond, func() {
+		_ = f.Close() //nolint: errcheck
+	})
+
+	out, err := exec.RunOutput(context.Background(), path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", out)
+}
+
+func TestCmd_Start_RetryTextFileBusy_GiveUp(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "script.sh")
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o755) //nolint: gosec
+	require.NoError(t, err)
+
+	defer f.Close() //nolint: errcheck
+
+	_, err = f.WriteString("#!/bin/sh\necho hello world\n")
+	require.NoError(t, err)
+
+	_, err = exec.Run(path)
+
+	assert.ErrorIs(t, err, syscall.ETXTBSY)
+}
+
+func TestFromCmd_Start_TextFileBusy(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "script.sh")
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o755) //nolint: gosec
+	require.NoError(t, err)
+
+	_, err = f.WriteString("#!/bin/sh\necho hello world\n")
+	require.NoError(t, err)
+
+	// The script would be closed in time for a retry, the adopted command is not retried.
+	time.AfterFunc(20*time.Millisecond, func() {
+		_ = f.Close() //nolint: errcheck
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = exec.FromCmd(osexec.CommandContext(ctx, path)).Run()
+
+	assert.ErrorIs(t, err, syscall.ETXTBSY)
+}
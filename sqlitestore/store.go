@@ -0,0 +1,186 @@
+// Package sqlitestore provides a SQLite implementation of exec.ResultStore on top of database/sql. The driver is not
+// imported, the application is free to use any SQLite driver.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.nhat.io/exec"
+)
+
+// DefaultTable is the table that stores the records.
+const DefaultTable = "exec_results"
+
+// ErrInvalidTable indicates that the name of the table is not a plain SQL identifier.
+var ErrInvalidTable = errors.New("sqlitestore: invalid table name")
+
+// Store persists the results of the executions in a SQLite database.
+type Store struct {
+	db    *sql.DB
+	table string
+}
+
+var _ exec.ResultStore = (*Store)(nil)
+
+// New creates a new Store that keeps the records in the given table, the table is created if it does not exist. The name
+// of the table is put in the queries as is, so it may only contain letters, digits and underscores, and must not start
+// with a digit.
+func New(ctx context.Context, db *sql.DB, table string) (*Store, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+
+	if !isIdentifier(table) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTable, table)
+	}
+
+	s := &Store{db: db, table: table}
+
+	schema := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	path TEXT NOT NULL,
+	args TEXT NOT NULL,
+	exit_code INTEGER NOT NULL,
+	started_at INTEGER NOT NULL,
+	duration INTEGER NOT NULL,
+	error TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS %[1]s_name_started_at ON %[1]s (name, started_at);
+CREATE INDEX IF NOT EXISTS %[1]s_started_at ON %[1]s (started_at);`, table)
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("could not create table: %w", err)
+	}
+
+	return s, nil
+}
+
+// isIdentifier reports whether the name is a plain SQL identifier that does not need to be quoted.
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+// Save inserts the record. The start time of a command that has not started is stored as 0.
+func (s *Store) Save(ctx context.Context, r exec.ResultRecord) error {
+	args, err := json.Marshal(r.Args)
+	if err != nil {
+		return fmt.Errorf("could not encode args: %w", err)
+	}
+
+	// The Unix time of the zero time does not fit in an int64.
+	var startedAt int64
+
+	if !r.StartedAt.IsZero() {
+		startedAt = r.StartedAt.UnixNano()
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (name, path, args, exit_code, started_at, duration, error) VALUES (?, ?, ?, ?, ?, ?, ?)`, s.table) //nolint: gosec
+
+	if _, err := s.db.ExecContext(ctx, query,
+		r.Name, r.Path, string(args), r.ExitCode, startedAt, int64(r.Duration), r.Error,
+	); err != nil {
+		return fmt.Errorf("could not insert record: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns the records matching the query, the most recent first.
+func (s *Store) Query(ctx context.Context, q exec.ResultQuery) ([]exec.ResultRecord, error) {
+	var (
+		where []string
+		args  []any
+	)
+
+	if q.Name != "" {
+		where = append(where, "name = ?")
+		args = append(args, q.Name)
+	}
+
+	if !q.Since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, q.Since.UnixNano())
+	}
+
+	if !q.Until.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, q.Until.UnixNano())
+	}
+
+	if len(q.ExitCodes) > 0 {
+		where = append(where, fmt.Sprintf("exit_code IN (?%s)", strings.Repeat(", ?", len(q.ExitCodes)-1)))
+
+		for _, code := range q.ExitCodes {
+			args = append(args, code)
+		}
+	}
+
+	query := fmt.Sprintf(`SELECT id, name, path, args, exit_code, started_at, duration, error FROM %s`, s.table) //nolint: gosec
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	query += " ORDER BY started_at DESC, id DESC"
+
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query records: %w", err)
+	}
+
+	defer rows.Close() //nolint: errcheck
+
+	var result []exec.ResultRecord
+
+	for rows.Next() {
+		var (
+			r         exec.ResultRecord
+			rawArgs   string
+			startedAt int64
+			duration  int64
+		)
+
+		if err := rows.Scan(&r.ID, &r.Name, &r.Path, &rawArgs, &r.ExitCode, &startedAt, &duration, &r.Error); err != nil {
+			return nil, fmt.Errorf("could not scan record: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(rawArgs), &r.Args); err != nil {
+			return nil, fmt.Errorf("could not decode args: %w", err)
+		}
+
+		if startedAt != 0 {
+			r.StartedAt = time.Unix(0, startedAt)
+		}
+
+		r.Duration = time.Duration(duration)
+
+		result = append(result, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read records: %w", err)
+	}
+
+	return result, nil
+}
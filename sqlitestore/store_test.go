@@ -0,0 +1,95 @@
+package sqlitestore_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+	"go.nhat.io/exec/sqlitestore"
+	exectest "go.nhat.io/exec/test"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "results.db"))
+	require.NoError(t, err)
+
+	defer db.Close() //nolint: errcheck
+
+	s, err := sqlitestore.New(context.Background(), db, "")
+	require.NoError(t, err)
+
+	exectest.ResultStore(t, s)
+}
+
+func TestNew_InvalidTable(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		table    string
+	}{
+		{
+			scenario: "injection",
+			table:    "results; DROP TABLE users",
+		},
+		{
+			scenario: "quoted",
+			table:    `"results"`,
+		},
+		{
+			scenario: "leading digit",
+			table:    "1results",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "results.db"))
+			require.NoError(t, err)
+
+			defer db.Close() //nolint: errcheck
+
+			s, err := sqlitestore.New(context.Background(), db, tc.table)
+
+			assert.Nil(t, s)
+			assert.ErrorIs(t, err, sqlitestore.ErrInvalidTable)
+		})
+	}
+}
+
+func TestStore_ZeroStartedAt(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "results.db"))
+	require.NoError(t, err)
+
+	defer db.Close() //nolint: errcheck
+
+	s, err := sqlitestore.New(context.Background(), db, "results_2")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Save(context.Background(), exec.ResultRecord{Name: "not_found", Args: []string{"not_found"}, ExitCode: -1}))
+
+	var startedAt int64
+
+	require.NoError(t, db.QueryRow("SELECT started_at FROM results_2").Scan(&startedAt))
+	assert.Zero(t, startedAt)
+
+	records, err := s.Query(context.Background(), exec.ResultQuery{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	assert.True(t, records[0].StartedAt.IsZero())
+}
@@ -0,0 +1,167 @@
+package exec
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// StageState is the lifecycle state of a stage of a pipeline.
+type StageState int32
+
+const (
+	// StagePending is the state of a stage that has not been started yet.
+	StagePending StageState = iota
+	// StageRunning is the state of a stage whose process has been started and not waited yet.
+	StageRunning
+	// StageExited is the state of a stage that has exited successfully.
+	StageExited
+	// StageFailed is the state of a stage that could not be started or that has failed.
+	StageFailed
+	// StageSkipped is the state of a stage that has not been run because it has already run once.
+	StageSkipped
+)
+
+// String returns the name of the state.
+func (s StageState) String() string {
+	switch s {
+	case StagePending:
+		return "pending"
+
+	case StageRunning:
+		return "running"
+
+	case StageExited:
+		return "exited"
+
+	case StageFailed:
+		return "failed"
+
+	case StageSkipped:
+		return "skipped"
+	}
+
+	return "unknown"
+}
+
+// stageState is the state of a command, it is read by other goroutines while the command runs.
+type stageState struct {
+	state atomic.Int32
+	err   atomic.Pointer[error]
+}
+
+// WithStageName names the command as a stage of its pipeline. The name is used in the errors, the log fields and the
+// span name of the stage, so a failure of a long pipeline says which stage failed:
+//
+//	_, err := exec.Run("cat",
+//		exec.WithArgs("access.log"),
+//		exec.PipeWith("grep", exec.WithArgs(" 500 "), exec.WithStageName("filter")),
+//		exec.Pipe("wc", "-l"),
+//	)
+//	// err: exec: stage filter failed: exit status 1
+//
+// The name is not inherited by the next stages.
+func WithStageName(name string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.stageName = name
+	})
+}
+
+// StageName returns the name of the stage set by WithStageName, empty if the stage is not named.
+func (c *Cmd) StageName() string {
+	return c.stageName
+}
+
+// StageError is the error of a stage named with WithStageName.
+type StageError struct {
+	// Name is the name of the stage.
+	Name string
+	// Err is the error of the stage.
+	Err error
+}
+
+// Error returns the name of the stage and its error.
+func (e *StageError) Error() string {
+	return fmt.Sprintf("exec: stage %s failed: %s", e.Name, e.Err)
+}
+
+// Unwrap returns the error of the stage.
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// stageError wraps the error with the name of the stage, if any.
+func (c *Cmd) stageError(err error) error {
+	if err == nil || c.stageName == "" {
+		return err
+	}
+
+	return &StageError{Name: c.stageName, Err: err}
+}
+
+// logFields returns the fields of the logs of the command, with the name of the stage, if any.
+func (c *Cmd) logFields(keysAndValues ...any) []any {
+	if c.stageName == "" {
+		return keysAndValues
+	}
+
+	return append(keysAndValues, "exec.stage", c.stageName)
+}
+
+// Pipeline returns the stages of the pipeline of the command, from the first one to the last one, whichever stage it
+// is called on.
+func (c *Cmd) Pipeline() []*Cmd {
+	head := c
+
+	for head.prev != nil {
+		head = head.prev
+	}
+
+	var stages []*Cmd
+
+	for s := head; s != nil; s = s.Next {
+		stages = append(stages, s)
+	}
+
+	return stages
+}
+
+// State returns the lifecycle state of the command. It is safe to call it while the pipeline runs.
+func (c *Cmd) State() StageState {
+	return StageState(c.stage.state.Load())
+}
+
+// StageErr returns the error of the command alone, without the errors of the next stages of the pipeline. It is nil
+// until the command has failed.
+func (c *Cmd) StageErr() error {
+	if err := c.stage.err.Load(); err != nil {
+		return *err
+	}
+
+	return nil
+}
+
+// ownErr returns the error of the command alone, or the error that prevented it from being run.
+func (c *Cmd) ownErr() error {
+	if err := c.StageErr(); err != nil || c.ProcessState != nil {
+		return err
+	}
+
+	return c.Err
+}
+
+func (c *Cmd) setState(state StageState, err error) {
+	if err != nil {
+		c.stage.err.Store(&err)
+	}
+
+	c.stage.state.Store(int32(state))
+}
+
+// finish sets the final state of the command.
+func (c *Cmd) finish(err error) {
+	if err != nil {
+		c.setState(StageFailed, err)
+	} else {
+		c.setState(StageExited, nil)
+	}
+}
@@ -0,0 +1,145 @@
+package exec_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("echo",
+		exec.WithArgs("hello"),
+		exec.Pipe("grep", "hello"),
+		exec.Pipe("wc", "-l"),
+	)
+
+	names := func(stages []*exec.Cmd) []string {
+		result := make([]string, 0, len(stages))
+
+		for _, s := range stages {
+			result = append(result, filepath.Base(s.Path))
+		}
+
+		return result
+	}
+
+	expected := []string{"echo", "grep", "wc"}
+
+	assert.Equal(t, expected, names(cmd.Pipeline()))
+	assert.Equal(t, expected, names(cmd.Next.Next.Pipeline()))
+}
+
+func TestCmd_State(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh",
+		exec.WithArgs("-c", "cat; exit 1"),
+		exec.WithStdin(newSafeBuffer()),
+		exec.Pipe("cat"),
+	)
+
+	stages := cmd.Pipeline()
+	require.Len(t, stages, 2)
+
+	for _, s := range stages {
+		assert.Equal(t, exec.StagePending, s.State())
+		assert.NoError(t, s.StageErr())
+	}
+
+	require.NoError(t, cmd.Start())
+
+	assert.Equal(t, exec.StageRunning, stages[0].State())
+	assert.Equal(t, exec.StageRunning, stages[1].State())
+
+	err := cmd.Wait()
+	require.Error(t, err)
+
+	assert.Equal(t, exec.StageFailed, stages[0].State())
+	assert.Equal(t, exec.StageExited, stages[1].State())
+
+	assert.ErrorIs(t, stages[0].StageErr(), exec.ExitCodeError(1))
+	assert.NoError(t, stages[1].StageErr())
+}
+
+func TestCmd_State_NotFound(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("not_found")
+
+	require.Error(t, cmd.Run())
+
+	assert.Equal(t, exec.StageFailed, cmd.State())
+	assert.ErrorIs(t, cmd.StageErr(), exec.ErrNotFound)
+}
+
+func TestWithStageName(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	cmd, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithStdout(newSafeBuffer()),
+		exec.WithTracer(tracer),
+		exec.PipeWith("grep", exec.WithArgs("world"), exec.WithStageName("filter")),
+		exec.Pipe("wc", "-l"),
+	)
+
+	require.EqualError(t, err, "exec: stage filter failed: exit status 1")
+	require.ErrorIs(t, err, exec.ExitCodeError(1))
+
+	var stageErr *exec.StageError
+
+	require.ErrorAs(t, err, &stageErr)
+	assert.Equal(t, "filter", stageErr.Name)
+
+	stages := cmd.Pipeline()
+
+	assert.Empty(t, stages[0].StageName())
+	assert.Equal(t, "filter", stages[1].StageName())
+	assert.Empty(t, stages[2].StageName())
+	assert.Equal(t, "filter", cmd.Describe().Stages[1].Stage)
+
+	names := make([]string, 0, 4)
+
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"exec:pipeline", "exec:run", "exec:run:filter", "exec:run"}, names)
+}
+
+func TestWithStageName_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.PipeWith("not_found", exec.WithStageName("filter")))
+
+	require.EqualError(t, err, "exec: stage filter failed: exec: \"not_found\": executable file not found in $PATH")
+	require.ErrorIs(t, err, exec.ErrNotFound)
+}
+
+func TestStageState_String(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[exec.StageState]string{
+		exec.StagePending:    "pending",
+		exec.StageRunning:    "running",
+		exec.StageExited:     "exited",
+		exec.StageFailed:     "failed",
+		exec.StageSkipped:    "skipped",
+		exec.StageState(100): "unknown",
+	}
+
+	for state, expected := range testCases {
+		assert.Equal(t, expected, state.String())
+	}
+}
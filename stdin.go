@@ -0,0 +1,213 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultStdinBufferSize is the size of the buffer between a stdin producer and the pipe to the process.
+const defaultStdinBufferSize = 32 * 1024
+
+// StdinProducer writes the standard input of a process. The context is cancelled when the process exits or times out,
+// and the writes fail once the process has closed its standard input.
+type StdinProducer func(ctx context.Context, w io.Writer) error
+
+// WithStdinProducer feeds the standard input of the command with the producer through an OS pipe. The producer runs in
+// its own goroutine and blocks when the process does not read fast enough, so the memory used is bounded by the pipe
+// and a buffer of bufferSize bytes, 32 KiB if bufferSize is not positive.
+//
+// The standard input is closed when the producer returns. If the producer fails, the error is returned by Wait unless the
+// command fails by itself. Write errors caused by the process closing its standard input early are ignored, like a
+// shell does.
+func WithStdinProducer(produce StdinProducer, bufferSize int) Option {
+	if bufferSize <= 0 {
+		bufferSize = defaultStdinBufferSize
+	}
+
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard input", "WithStdinProducer")
+
+		if produce == nil {
+			c.invalid("WithStdinProducer requires a producer")
+
+			return
+		}
+
+		var (
+			r, w   *os.File
+			cancel context.CancelFunc
+			result chan error
+		)
+
+		c.addHook(hook{
+			beforeStart: func(c *Cmd) error {
+				var err error
+
+				if r, w, err = os.Pipe(); err != nil {
+					return fmt.Errorf("could not create stdin pipe: %w", err)
+				}
+
+				c.Stdin = r
+
+				return nil
+			},
+			afterStart: func(c *Cmd) {
+				var ctx context.Context
+
+				ctx, cancel = context.WithCancel(c.runContext())
+				result = make(chan error, 1)
+
+				_ = r.Close() //nolint: errcheck
+
+				go func() {
+					defer w.Close() //nolint: errcheck
+
+					bw := bufio.NewWriterSize(w, bufferSize)
+
+					err := produce(ctx, bw)
+					if flushErr := bw.Flush(); err == nil {
+						err = flushErr
+					}
+
+					result <- err
+				}()
+			},
+			afterExit: func(c *Cmd, err error) error {
+				if result == nil {
+					_ = r.Close() //nolint: errcheck
+					_ = w.Close() //nolint: errcheck
+
+					return err
+				}
+
+				cancel()
+
+				produceErr := <-result
+
+				if err != nil || isClosedStdin(produceErr) || errors.Is(produceErr, context.Canceled) {
+					return err
+				}
+
+				if produceErr != nil {
+					return fmt.Errorf("could not write stdin: %w", produceErr)
+				}
+
+				return nil
+			},
+		})
+	})
+}
+
+// WithStdinChannel feeds the standard input of the command with the values received from the channel, until the
+// channel is closed. Each value is written with encode, or as a line formatted by fmt.Fprintln if encode is nil.
+//
+// See WithStdinProducer for the flow control and the error handling.
+func WithStdinChannel[T any](ch <-chan T, encode func(w io.Writer, v T) error) Option {
+	if encode == nil {
+		encode = func(w io.Writer, v T) error {
+			_, err := fmt.Fprintln(w, v)
+
+			return err //nolint: wrapcheck
+		}
+	}
+
+	return WithStdinProducer(func(ctx context.Context, w io.Writer) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case v, ok := <-ch:
+				if !ok {
+					return nil
+				}
+
+				if err := encode(w, v); err != nil {
+					return err
+				}
+			}
+		}
+	}, 0)
+}
+
+// WithStdinString feeds the standard input of the command with the string, like a here-string in a shell. The size of
+// the input is recorded on the span, and every attempt of WithRetry reads the whole string.
+func WithStdinString(s string) Option {
+	return withStdinLiteral("WithStdinString", s)
+}
+
+// WithStdinBytes feeds the standard input of the command with the bytes, like a here-doc in a shell. The bytes must not
+// be modified until the command exits. See WithStdinString.
+func WithStdinBytes(b []byte) Option {
+	return withStdinLiteral("WithStdinBytes", b)
+}
+
+func withStdinLiteral[T string | []byte](option string, in T) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard input", option)
+
+		c.addHook(hook{
+			beforeStart: func(c *Cmd) error {
+				// A new reader is created for every start, a retry reads the input from the beginning.
+				c.Stdin = bytes.NewReader([]byte(in))
+
+				c.span.SetAttributes(attribute.Int("exec.stdin.size", len(in)))
+
+				return nil
+			},
+		})
+	})
+}
+
+// WithStdinFile reads the standard input of the command from the file at the path, like `< path` in a shell. A relative
+// path is relative to the working directory of the command. The file is opened when the command starts and is passed
+// to the process as is, so no goroutine copies it, and it is closed when the command exits. The path is recorded on the
+// span.
+func WithStdinFile(path string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.claim("standard input", "WithStdinFile")
+
+		var f *os.File
+
+		c.addHook(hook{
+			beforeStart: func(c *Cmd) error {
+				name := path
+				if !filepath.IsAbs(name) && c.Dir != "" {
+					name = filepath.Join(c.Dir, name)
+				}
+
+				var err error
+
+				if f, err = os.Open(filepath.Clean(name)); err != nil {
+					return fmt.Errorf("could not open stdin: %w", err)
+				}
+
+				c.Stdin = f
+
+				c.span.SetAttributes(attribute.String("exec.stdin.file", name))
+
+				return nil
+			},
+			afterExit: func(_ *Cmd, err error) error {
+				if f != nil {
+					_ = f.Close() //nolint: errcheck
+				}
+
+				return err
+			},
+		})
+	})
+}
+
+func isClosedStdin(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) || errors.Is(err, io.ErrClosedPipe)
+}
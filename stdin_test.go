@@ -0,0 +1,192 @@
+package exec_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithStdinChannel(t *testing.T) {
+	t.Parallel()
+
+	type record struct {
+		ID int `json:"id"`
+	}
+
+	ch := make(chan record)
+
+	go func() {
+		defer close(ch)
+
+		for i := 1; i <= 3; i++ {
+			ch <- record{ID: i}
+		}
+	}()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("cat",
+		exec.WithStdout(out),
+		exec.WithStdinChannel(ch, func(w io.Writer, v record) error {
+			return json.NewEncoder(w).Encode(v)
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}", getOutput(out))
+}
+
+func TestWithStdinChannel_DefaultEncoder(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+
+	close(ch)
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("wc", exec.WithArgs("-l"), exec.WithStdout(out), exec.WithStdinChannel(ch, nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, "3", strings.TrimSpace(getOutput(out)))
+}
+
+func TestWithStdinProducer_Error(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	_, err := exec.Run("cat",
+		exec.WithStdout(out),
+		exec.WithStdinProducer(func(_ context.Context, w io.Writer) error {
+			_, _ = fmt.Fprintln(w, "partial") //nolint: errcheck
+
+			return errors.New("database is gone")
+		}, 0),
+	)
+
+	assert.EqualError(t, err, "could not write stdin: database is gone")
+	assert.Equal(t, "partial", getOutput(out))
+}
+
+func TestWithStdinProducer_ProcessExitsEarly(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	_, err := exec.Run("head", exec.WithArgs("-n", "1"),
+		exec.WithStdout(io.Discard),
+		exec.WithStdinProducer(func(_ context.Context, w io.Writer) error {
+			for {
+				if _, err := fmt.Fprintln(w, "yes"); err != nil {
+					return err
+				}
+			}
+		}, 16),
+	)
+
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWithStdinChannel_ProducerNeverCloses(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan string)
+
+	_, err := exec.Run("true", exec.WithStdinChannel(ch, nil))
+
+	assert.NoError(t, err)
+}
+
+func TestWithStdinString(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	out, err := exec.RunOutput(context.Background(), "tr",
+		exec.WithArgs("a-z", "A-Z"),
+		exec.WithStdinString("hello"),
+		exec.WithTracer(tracer),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "HELLO", out)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("exec.stdin.size", 5))
+}
+
+func TestWithStdinBytes(t *testing.T) {
+	t.Parallel()
+
+	out, err := exec.RunOutput(context.Background(), "cat", exec.WithStdinBytes([]byte("line 1\nline 2\n")))
+	require.NoError(t, err)
+
+	assert.Equal(t, "line 1\nline 2", out)
+}
+
+func TestWithStdinString_Conflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("cat", exec.WithStdin(newSafeBuffer()), exec.WithStdinString("hello"))
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+}
+
+func TestWithStdinFile(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+	dir := t.TempDir()
+	out := newSafeBuffer()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "input.txt"), []byte("hello\n"), 0o600))
+
+	cmd, err := exec.Run("tr",
+		exec.WithArgs("a-z", "A-Z"),
+		exec.WithDir(dir),
+		exec.WithStdinFile("input.txt"),
+		exec.WithStdout(out),
+		exec.WithTracer(tracer),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "HELLO", getOutput(out))
+	assert.IsType(t, &os.File{}, cmd.Stdin)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	assert.Contains(t, spans[0].Attributes(), attribute.String("exec.stdin.file", filepath.Join(dir, "input.txt")))
+}
+
+func TestWithStdinFile_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("cat", exec.WithStdinFile(filepath.Join(t.TempDir(), "missing")))
+
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,58 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// Stop asks the running process to terminate gracefully and kills it if it does not exit within the grace period or
+// before the context is done.
+//
+// On Unix systems, the process receives SIGTERM first. On Windows, the process is killed immediately because there is
+// no portable way to ask it to terminate.
+//
+// Stop does not release the resources associated with the Cmd, Wait must still be called, possibly in another
+// goroutine, for Stop to observe the exit.
+func (c *Cmd) Stop(ctx context.Context, gracePeriod time.Duration) error {
+	if c.Process == nil {
+		return errors.New("exec: not started") //nolint: goerr113
+	}
+
+	select {
+	case <-c.done:
+		return nil
+
+	default:
+	}
+
+	if err := c.Process.Signal(stopSignal); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil
+		}
+
+		return c.kill()
+	}
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-c.done:
+		return nil
+
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	return c.kill()
+}
+
+func (c *Cmd) kill() error {
+	if err := c.killProcess(c.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err //nolint: wrapcheck
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestCmd_Stop_NotStarted(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep", exec.WithArgs("10"))
+
+	err := cmd.Stop(context.Background(), time.Second)
+
+	assert.EqualError(t, err, `exec: not started`)
+}
+
+func TestCmd_Stop_KillAfterGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	cmd := exec.Command("sh", exec.WithArgs("-c", `trap "" TERM; echo ready; exec sleep 10`),
+		exec.WithStdout(out),
+	)
+
+	require.NoError(t, cmd.Start())
+
+	assert.Eventually(t, func() bool {
+		return getOutput(out) == "ready"
+	}, time.Second, 10*time.Millisecond)
+
+	waitErr := make(chan error, 1)
+
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+
+	start := time.Now()
+	err := cmd.Stop(context.Background(), 100*time.Millisecond)
+
+	require.NoError(t, err)
+	assert.EqualError(t, <-waitErr, `signal: killed`)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,124 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+)
+
+// Tee copies the standard output of the command to the standard input of the branches, like `tee >(...) >(...)` in a
+// shell, while it still goes to the standard output or to the next stage of the pipeline. The branches are started
+// with the command and run concurrently, Wait waits for all of them and returns the errors of the command and of the
+// branches in a MultiError, or the only one if there is only one.
+//
+// The standard input of the branches is replaced. A branch that exits early does not block the command, the rest of
+// the output is discarded for it. The branches are started once, they can not be used with WithRetry.
+func Tee(branches ...*Cmd) Option {
+	return optionFunc(func(c *Cmd) {
+		if len(branches) == 0 {
+			return
+		}
+
+		t := &tee{branches: branches}
+
+		c.addHook(hook{
+			beforeStart: t.start,
+			afterExit: func(_ *Cmd, err error) error {
+				t.close()
+
+				return err
+			},
+			afterPipeline: func(_ *Cmd, err error) error {
+				return t.wait(err)
+			},
+		})
+	})
+}
+
+type tee struct {
+	branches []*Cmd
+	writers  []*teeBranch
+}
+
+// start starts the branches and plugs them into the standard output of the command.
+func (t *tee) start(c *Cmd) error {
+	writers := make([]io.Writer, 0, len(t.branches))
+
+	for _, b := range t.branches {
+		r, w := io.Pipe()
+
+		b.Stdin = r
+
+		if err := b.Start(); err != nil {
+			_ = r.Close() //nolint: errcheck
+
+			t.close()
+
+			return fmt.Errorf("could not start tee branch: %w", err)
+		}
+
+		bw := &teeBranch{w: w, done: make(chan error, 1)}
+
+		go func(b *Cmd) {
+			err := b.Wait()
+
+			// Nothing reads the pipe anymore, the writes must not block.
+			_ = r.CloseWithError(io.ErrClosedPipe) //nolint: errcheck
+
+			bw.done <- err
+		}(b)
+
+		t.writers = append(t.writers, bw)
+		writers = append(writers, bw)
+	}
+
+	c.Stdout = teeWriter(c.Stdout, io.MultiWriter(writers...))
+
+	return nil
+}
+
+// close closes the standard input of the branches, once the command has exited.
+func (t *tee) close() {
+	for _, w := range t.writers {
+		_ = w.w.Close() //nolint: errcheck
+	}
+}
+
+// wait waits for the branches that have started and adds their errors to the one of the command.
+func (t *tee) wait(err error) error {
+	var errs MultiError
+
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, w := range t.writers {
+		if bErr := <-w.done; bErr != nil {
+			errs = append(errs, bErr)
+		}
+	}
+
+	t.writers = nil
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	return errs.errorOrNil()
+}
+
+// teeBranch is the standard input of a branch, the output is discarded once the branch stops reading.
+type teeBranch struct {
+	w      *io.PipeWriter
+	done   chan error
+	closed bool
+}
+
+func (w *teeBranch) Write(p []byte) (int, error) {
+	if !w.closed {
+		if _, err := w.w.Write(p); err != nil {
+			w.closed = true
+		}
+	}
+
+	return len(p), nil
+}
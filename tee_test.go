@@ -0,0 +1,105 @@
+package exec_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestTee(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	upper := newSafeBuffer()
+	count := newSafeBuffer()
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithStdout(out),
+		exec.Tee(
+			exec.Command("tr", exec.WithArgs("a-z", "A-Z"), exec.WithStdout(upper)),
+			exec.Command("wc", exec.WithArgs("-c"), exec.WithStdout(count)),
+		),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", getOutput(out))
+	assert.Equal(t, "HELLO", getOutput(upper))
+	assert.Equal(t, "6", getOutput(count))
+}
+
+func TestTee_Pipe(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	branch := newSafeBuffer()
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.WithStdout(out),
+		exec.Tee(exec.Command("cat", exec.WithStdout(branch))),
+		exec.Pipe("tr", "a-z", "A-Z"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "HELLO", getOutput(out))
+	assert.Equal(t, "hello", getOutput(branch))
+}
+
+func TestTee_BranchFails(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo",
+		exec.WithArgs("hello"),
+		exec.Tee(
+			exec.Command("sh", exec.WithArgs("-c", "cat >/dev/null; exit 2")),
+			exec.Command("sh", exec.WithArgs("-c", "cat >/dev/null; exit 3")),
+		),
+	)
+
+	var errs exec.MultiError
+
+	require.ErrorAs(t, err, &errs)
+
+	assert.Len(t, errs, 2)
+	assert.ErrorIs(t, err, exec.ExitCodeError(2))
+	assert.ErrorIs(t, err, exec.ExitCodeError(3))
+}
+
+func TestTee_BranchExitsEarly(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	branch := newSafeBuffer()
+
+	_, err := exec.Run("seq",
+		exec.WithArgs("1", "200000"),
+		exec.WithStdout(out),
+		exec.Tee(exec.Command("head", exec.WithArgs("-n", "1"), exec.WithStdout(branch))),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", getOutput(branch))
+	assert.Equal(t, 200000, strings.Count(out.String(), "\n"))
+}
+
+func TestTee_BranchNotFound(t *testing.T) {
+	t.Parallel()
+
+	started := exec.Command("cat")
+
+	cmd := exec.Command("echo",
+		exec.WithArgs("hello"),
+		exec.Tee(started, exec.Command("not_found")),
+	)
+
+	err := cmd.Start()
+
+	require.ErrorIs(t, err, exec.ErrNotFound)
+	assert.ErrorContains(t, err, "could not start tee branch")
+	assert.NotNil(t, started.ProcessState)
+}
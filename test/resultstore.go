@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+// ResultStore runs the behavior tests that every exec.ResultStore implementation must pass. The store must be empty.
+func ResultStore(t *testing.T, s exec.ResultStore) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	records := []exec.ResultRecord{
+		{Name: "git", Path: "/usr/bin/git", Args: []string{"git", "fetch"}, ExitCode: 0, StartedAt: now, Duration: time.Second},
+		{Name: "git", Path: "/usr/bin/git", Args: []string{"git", "push"}, ExitCode: 1, StartedAt: now.Add(time.Minute), Error: "exit status 1"},
+		{Name: "make", Path: "/usr/bin/make", Args: []string{"make"}, ExitCode: 2, StartedAt: now.Add(2 * time.Minute)},
+	}
+
+	for _, r := range records {
+		require.NoError(t, s.Save(ctx, r))
+	}
+
+	all, err := s.Query(ctx, exec.ResultQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	assert.Equal(t, []string{"make"}, all[0].Args)
+	assert.Equal(t, []string{"git", "push"}, all[1].Args)
+	assert.Equal(t, "exit status 1", all[1].Error)
+	assert.Equal(t, time.Second, all[2].Duration)
+	assert.True(t, now.Equal(all[2].StartedAt))
+	assert.NotEqual(t, all[0].ID, all[1].ID)
+
+	byName, err := s.Query(ctx, exec.ResultQuery{Name: "git"})
+	require.NoError(t, err)
+	assert.Len(t, byName, 2)
+
+	byTime, err := s.Query(ctx, exec.ResultQuery{Since: now.Add(time.Minute), Until: now.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, byTime, 1)
+	assert.Equal(t, []string{"git", "push"}, byTime[0].Args)
+
+	byCode, err := s.Query(ctx, exec.ResultQuery{ExitCodes: []int{1, 2}})
+	require.NoError(t, err)
+	assert.Len(t, byCode, 2)
+
+	limited, err := s.Query(ctx, exec.ResultQuery{Name: "git", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, []string{"git", "push"}, limited[0].Args)
+}
@@ -0,0 +1,98 @@
+package exec
+
+import (
+	"io"
+	"time"
+)
+
+// throttleSlices is the number of writes per second of a throttled pipe, so the throughput is smooth and a write
+// never sleeps for long.
+const throttleSlices = 10
+
+// WithPipeRateLimit caps the throughput of the pipe between the command and the next stage of the pipeline, in bytes
+// per second, so a large output does not overwhelm a downstream tool. The limit only applies to the output of the stage
+// it is set on, the other stages of the pipeline can set their own with PipeWith.
+//
+// The stages are connected through an in-memory pipe, see WithPipeBuffer for its size.
+func WithPipeRateLimit(bytesPerSecond int) Option {
+	return optionFunc(func(c *Cmd) {
+		if bytesPerSecond < 0 {
+			c.invalid("WithPipeRateLimit has a negative rate %d", bytesPerSecond)
+
+			return
+		}
+
+		c.pipeRateLimit = bytesPerSecond
+	})
+}
+
+// newThrottledPipe creates an in-memory pipe whose writes are throttled to the rate.
+func newThrottledPipe(size, bytesPerSecond int) (io.ReadCloser, io.WriteCloser, string) {
+	var (
+		r io.ReadCloser
+		w io.WriteCloser
+	)
+
+	if size > 0 {
+		r, w = newBufferedPipe(size)
+	} else {
+		r, w = io.Pipe()
+	}
+
+	return r, &throttledWriter{WriteCloser: w, rate: bytesPerSecond}, pipeTransportThrottled
+}
+
+// throttledWriter writes at most rate bytes per second.
+type throttledWriter struct {
+	io.WriteCloser
+
+	rate    int
+	start   time.Time
+	written int64
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	if w.start.IsZero() {
+		w.start = time.Now()
+	}
+
+	slice := w.rate / throttleSlices
+	if slice < 1 {
+		slice = 1
+	}
+
+	var n int
+
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > slice {
+			chunk = chunk[:slice]
+		}
+
+		// The chunk is written once the previous ones fit in the rate.
+		due := w.start.Add(throttleDelay(w.written, w.rate))
+		if d := time.Until(due); d > 0 {
+			time.Sleep(d)
+		}
+
+		nw, err := w.WriteCloser.Write(chunk)
+		n += nw
+		w.written += int64(nw)
+
+		if err != nil {
+			return n, err //nolint: wrapcheck
+		}
+
+		p = p[nw:]
+	}
+
+	return n, nil
+}
+
+// throttleDelay returns how long after the start the written bytes fit in the rate. The whole seconds and the remainder
+// are scaled separately, so a large output does not overflow the duration.
+func throttleDelay(written int64, rate int) time.Duration {
+	secs, rem := written/int64(rate), written%int64(rate)
+
+	return time.Duration(secs)*time.Second + time.Duration(float64(rem)*float64(time.Second)/float64(rate))
+}
@@ -0,0 +1,87 @@
+package exec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithPipeRateLimit(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+	start := time.Now()
+
+	_, err := exec.Run("head",
+		exec.WithArgs("-c", "50000", "/dev/zero"),
+		exec.WithStdout(out),
+		exec.WithPipeRateLimit(100000),
+		exec.Pipe("wc", "-c"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "50000", getOutput(out))
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestWithPipeRateLimit_Negative(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.WithPipeRateLimit(-1), exec.Pipe("cat"))
+
+	require.ErrorIs(t, err, exec.ErrOptionConflict)
+}
+
+func TestThrottleDelay(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		written  int64
+		rate     int
+		expected time.Duration
+	}{
+		{
+			scenario: "nothing written",
+			rate:     100,
+		},
+		{
+			scenario: "partial second",
+			written:  50,
+			rate:     100,
+			expected: 500 * time.Millisecond,
+		},
+		{
+			scenario: "several seconds",
+			written:  250,
+			rate:     100,
+			expected: 2500 * time.Millisecond,
+		},
+		{
+			scenario: "large output",
+			written:  20 << 30,
+			rate:     1 << 30,
+			expected: 20 * time.Second,
+		},
+		{
+			scenario: "large output at a low rate",
+			written:  1 << 40,
+			rate:     1 << 20,
+			expected: (1 << 20) * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, exec.ThrottleDelay(tc.written, tc.rate))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var defaultTimeout atomic.Int64
+
+// SetDefaultTimeout sets the timeout of the commands created from now on, 0 means no timeout. A command is killed when
+// it runs longer than its timeout. It can be overridden per command with WithTimeout.
+func SetDefaultTimeout(d time.Duration) {
+	defaultTimeout.Store(int64(d))
+}
+
+// DefaultTimeout returns the timeout set by SetDefaultTimeout.
+func DefaultTimeout() time.Duration {
+	return time.Duration(defaultTimeout.Load())
+}
+
+// WithTimeout kills the command and the stages of its pipeline when they run longer than d, 0 means no timeout. It
+// overrides the default timeout. The deadline is derived from the context of the command, and the error of a killed
+// command is a *TimeoutError.
+func WithTimeout(d time.Duration) Option {
+	return optionFunc(func(c *Cmd) {
+		c.timeout = d
+	})
+}
+
+// TimeoutError is the error of a command that has been killed because it ran longer than its timeout. It matches
+// context.DeadlineExceeded with errors.Is.
+type TimeoutError struct {
+	// Duration is the timeout of the command.
+	Duration time.Duration
+	// Err is the error of the killed command.
+	Err error
+}
+
+// Error returns the timeout and the error of the command.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("exec: timed out after %s: %s", e.Duration, e.Err)
+}
+
+// Unwrap returns the error of the command.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is context.DeadlineExceeded.
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded //nolint: errorlint
+}
+
+// Timeout reports that the error is a timeout, see os.IsTimeout.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// timeoutWatch is the deadline of a running command, it is derived from the context of the command.
+type timeoutWatch struct {
+	ctx      context.Context //nolint: containedctx
+	cancel   context.CancelFunc
+	exceeded atomic.Bool
+}
+
+func (c *Cmd) watchTimeout() {
+	if c.timeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+	w := &timeoutWatch{ctx: ctx, cancel: cancel}
+	p, parent := c.Process, c.ctx
+
+	go func() {
+		<-ctx.Done()
+
+		// The context of the command is watched by watchContext.
+		if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			w.exceeded.Store(true)
+
+			_ = c.killProcess(p) //nolint: errcheck
+		}
+	}()
+
+	c.timeoutWatch = w
+}
+
+// runContext returns the context of the running command, with its deadline.
+func (c *Cmd) runContext() context.Context {
+	if c.timeoutWatch == nil {
+		return c.ctx
+	}
+
+	return c.timeoutWatch.ctx
+}
+
+func (c *Cmd) releaseTimeout(err error) error {
+	if c.timeoutWatch == nil {
+		return err
+	}
+
+	c.timeoutWatch.cancel()
+
+	if err != nil && c.timeoutWatch.exceeded.Load() {
+		return &TimeoutError{Duration: c.timeout, Err: err}
+	}
+
+	return err
+}
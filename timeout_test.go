@@ -0,0 +1,122 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	_, err := exec.Run("sleep", exec.WithArgs("5"), exec.WithTimeout(50*time.Millisecond))
+
+	assert.EqualError(t, err, "exec: timed out after 50ms: signal: killed")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWithTimeout_TimeoutError(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("sleep", exec.WithArgs("5"), exec.WithTimeout(50*time.Millisecond))
+
+	var timeoutErr *exec.TimeoutError
+
+	require.ErrorAs(t, err, &timeoutErr)
+
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Duration)
+	assert.True(t, timeoutErr.Timeout())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	code, ok := exec.ExitCode(err)
+
+	assert.False(t, ok)
+	assert.Equal(t, -1, code)
+}
+
+func TestWithTimeout_StdinProducer(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan error, 1)
+
+	_, err := exec.Run("sleep",
+		exec.WithArgs("5"),
+		exec.WithTimeout(50*time.Millisecond),
+		exec.WithStdinProducer(func(ctx context.Context, _ io.Writer) error {
+			<-ctx.Done()
+
+			done <- ctx.Err()
+
+			return ctx.Err()
+		}, 0),
+	)
+
+	require.ErrorAs(t, err, new(*exec.TimeoutError))
+	assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+}
+
+func TestWithTimeout_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The context is done before the timeout, the error is not a timeout of the command.
+	_, err := exec.RunWithContext(ctx, "sleep", exec.WithArgs("5"), exec.WithTimeout(time.Minute))
+
+	require.Error(t, err)
+	assert.False(t, errors.As(err, new(*exec.TimeoutError)))
+}
+
+func TestWithTimeout_NotExceeded(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("true", exec.WithTimeout(time.Minute))
+
+	require.NoError(t, err)
+}
+
+func TestWithTimeout_Pipe(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Run("echo", exec.Pipe("sleep", "5"), exec.WithTimeout(50*time.Millisecond))
+
+	assert.EqualError(t, err, "exec: timed out after 50ms: signal: killed")
+}
+
+func TestSetDefaultTimeout(t *testing.T) { //nolint: paralleltest
+	exec.SetDefaultTimeout(50 * time.Millisecond)
+
+	defer exec.SetDefaultTimeout(0)
+
+	assert.Equal(t, 50*time.Millisecond, exec.DefaultTimeout())
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, err := exec.RunWithContext(context.Background(), "sleep", exec.WithArgs("5"), exec.WithTracer(tracer))
+
+	assert.EqualError(t, err, "exec: timed out after 50ms: signal: killed")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	assert.Contains(t, spans[0].Attributes(), attribute.String("exec.timeout", "50ms"))
+
+	// The default timeout can be disabled per command.
+	_, err = exec.Run("sleep", exec.WithArgs("0.2"), exec.WithTimeout(0))
+
+	require.NoError(t, err)
+}
@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// defaultTraceIDEnv is the default name of the variable of the trace id in the environment of the process.
+	defaultTraceIDEnv = "TRACE_ID"
+	// defaultSpanIDEnv is the default name of the variable of the span id in the environment of the process.
+	defaultSpanIDEnv = "SPAN_ID"
+)
+
+// traceEnv is the names of the variables of the span context in the environment of the process.
+type traceEnv struct {
+	traceID string
+	spanID  string
+}
+
+// WithTraceEnv renames the variables of the trace id and the span id of the execution, TRACE_ID and SPAN_ID by
+// default, when they clash with the variables of the process. An empty name leaves the variable out. Every next stage
+// of the pipeline inherits the names.
+func WithTraceEnv(traceID, spanID string) Option {
+	return optionFunc(func(c *Cmd) {
+		c.traceEnv = &traceEnv{traceID: traceID, spanID: spanID}
+	})
+}
+
+// WithoutTraceEnv does not set the trace id and the span id of the execution in the environment of the process. Without
+// them, and without any other option that changes the environment, the environment of the current process is not
+// copied when the command starts.
+func WithoutTraceEnv() Option {
+	return WithTraceEnv("", "")
+}
+
+// injectTraceEnv sets the trace id and the span id in the environment of the process. They are always set, even
+// without a tracer or a trace to propagate, in which case they are zeros, so the process can rely on them.
+func (c *Cmd) injectTraceEnv(sc trace.SpanContext) {
+	names := traceEnv{traceID: defaultTraceIDEnv, spanID: defaultSpanIDEnv}
+	if c.traceEnv != nil {
+		names = *c.traceEnv
+	}
+
+	env := make([]string, 0, 2)
+
+	if names.traceID != "" {
+		env = append(env, fmt.Sprintf("%s=%s", names.traceID, sc.TraceID().String()))
+	}
+
+	if names.spanID != "" {
+		env = append(env, fmt.Sprintf("%s=%s", names.spanID, sc.SpanID().String()))
+	}
+
+	if len(env) > 0 {
+		c.setEnv(env...)
+	}
+}
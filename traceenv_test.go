@@ -0,0 +1,81 @@
+package exec_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"go.nhat.io/exec"
+)
+
+const traceEnvScript = `echo "${TRACE_ID-unset} ${SPAN_ID-unset} ${OTEL_TRACE-unset} ${OTEL_SPAN-unset}"`
+
+func TestWithTraceEnv(t *testing.T) {
+	t.Parallel()
+
+	tracer := sdktrace.NewTracerProvider().Tracer("")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	defer parent.End()
+
+	out := newSafeBuffer()
+
+	_, err := exec.RunWithContext(ctx, "sh",
+		exec.WithArgs("-c", traceEnvScript),
+		exec.WithTraceEnv("OTEL_TRACE", "OTEL_SPAN"),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	sc := parent.SpanContext()
+	expected := fmt.Sprintf("unset unset %s %s", sc.TraceID(), sc.SpanID())
+
+	assert.Equal(t, expected, getOutput(out))
+}
+
+func TestWithTraceEnv_TraceIDOnly(t *testing.T) {
+	t.Parallel()
+
+	tracer := sdktrace.NewTracerProvider().Tracer("")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	defer parent.End()
+
+	out := newSafeBuffer()
+
+	_, err := exec.RunWithContext(ctx, "sh",
+		exec.WithArgs("-c", traceEnvScript),
+		exec.WithTraceEnv("TRACE_ID", ""),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	expected := fmt.Sprintf("%s unset unset unset", parent.SpanContext().TraceID())
+
+	assert.Equal(t, expected, getOutput(out))
+}
+
+func TestWithoutTraceEnv(t *testing.T) {
+	t.Parallel()
+
+	tracer := sdktrace.NewTracerProvider().Tracer("")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	defer parent.End()
+
+	out := newSafeBuffer()
+
+	_, err := exec.RunWithContext(ctx, "echo",
+		exec.WithTracer(tracer),
+		exec.WithoutTraceEnv(),
+		exec.Pipe("sh", "-c", "cat; "+traceEnvScript),
+		exec.WithStdout(out),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "unset unset unset unset", getOutput(out))
+}
@@ -0,0 +1,247 @@
+package exec
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceSampling decides which spans of the executions are recorded.
+type traceSampling struct {
+	onError    bool
+	slowerThan time.Duration
+}
+
+// WithTraceOnError only records the span of an execution that fails, for the commands that run often and would be
+// noisy otherwise. The span is buffered locally, and is recorded with its original timing once it ends with an error.
+//
+// As the span may never be recorded, the children of the span and the trace context that is given to the process
+// refer to the parent of the execution, and a pipeline has no exec:pipeline span: the spans of its stages are recorded
+// on their own. Every next stage of the pipeline inherits the option.
+func WithTraceOnError() Option {
+	return optionFunc(func(c *Cmd) {
+		c.sampling().onError = true
+	})
+}
+
+// WithTraceOnSlow only records the span of an execution that lasts at least the threshold, like WithTraceOnError.
+// With both options, the span of an execution that fails or that is slow is recorded.
+func WithTraceOnSlow(threshold time.Duration) Option {
+	return optionFunc(func(c *Cmd) {
+		c.sampling().slowerThan = threshold
+	})
+}
+
+// sampling returns a copy of the sampling of the command to change, a stage does not change the one it inherits.
+func (c *Cmd) sampling() *traceSampling {
+	var s traceSampling
+
+	if c.traceSampling != nil {
+		s = *c.traceSampling
+	}
+
+	c.traceSampling = &s
+
+	return c.traceSampling
+}
+
+// startTracerSpan starts a span with the tracer, or a buffered span with WithTraceOnError or WithTraceOnSlow.
+func (c *Cmd) startTracerSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if c.traceSampling == nil {
+		return c.tracer.Start(ctx, name, opts...)
+	}
+
+	span := newBufferedSpan(ctx, c.tracer, *c.traceSampling, name, opts)
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// bufferedSpan is a span whose data is kept in memory until it ends. It is recorded with the tracer if it has failed or
+// lasted long enough.
+type bufferedSpan struct {
+	parent   context.Context //nolint: containedctx
+	tracer   trace.Tracer
+	sampling traceSampling
+
+	mu     sync.Mutex
+	name   string
+	start  time.Time
+	kind   trace.SpanKind
+	links  []trace.Link
+	attrs  []attribute.KeyValue
+	events []bufferedEvent
+	code   codes.Code
+	desc   string
+	ended  bool
+}
+
+var _ trace.Span = (*bufferedSpan)(nil)
+
+// bufferedEvent is an event or an error of a bufferedSpan.
+type bufferedEvent struct {
+	name  string
+	err   error
+	attrs []attribute.KeyValue
+	time  time.Time
+}
+
+func newBufferedSpan(
+	parent context.Context,
+	tracer trace.Tracer,
+	sampling traceSampling,
+	name string,
+	opts []trace.SpanStartOption,
+) *bufferedSpan {
+	cfg := trace.NewSpanStartConfig(opts...)
+
+	start := cfg.Timestamp()
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	return &bufferedSpan{
+		parent:   parent,
+		tracer:   tracer,
+		sampling: sampling,
+		name:     name,
+		start:    start,
+		kind:     cfg.SpanKind(),
+		links:    cfg.Links(),
+		attrs:    cfg.Attributes(),
+	}
+}
+
+// End records the span with the tracer if it has failed or lasted long enough, it is dropped otherwise.
+func (s *bufferedSpan) End(options ...trace.SpanEndOption) {
+	cfg := trace.NewSpanEndConfig(options...)
+
+	end := cfg.Timestamp()
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.ended = true
+
+	if !s.sampled(end) {
+		return
+	}
+
+	_, span := s.tracer.Start(s.parent, s.name,
+		trace.WithTimestamp(s.start),
+		trace.WithSpanKind(s.kind),
+		trace.WithLinks(s.links...),
+		trace.WithAttributes(s.attrs...),
+	)
+
+	for _, e := range s.events {
+		if e.err != nil {
+			span.RecordError(e.err, trace.WithTimestamp(e.time), trace.WithAttributes(e.attrs...))
+		} else {
+			span.AddEvent(e.name, trace.WithTimestamp(e.time), trace.WithAttributes(e.attrs...))
+		}
+	}
+
+	span.SetStatus(s.code, s.desc)
+	span.End(trace.WithTimestamp(end))
+}
+
+func (s *bufferedSpan) sampled(end time.Time) bool {
+	if s.sampling.onError && s.code == codes.Error {
+		return true
+	}
+
+	return s.sampling.slowerThan > 0 && end.Sub(s.start) >= s.sampling.slowerThan
+}
+
+// AddEvent buffers the event.
+func (s *bufferedSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.addEvent(name, nil, options)
+}
+
+// RecordError buffers the error.
+func (s *bufferedSpan) RecordError(err error, options ...trace.EventOption) {
+	if err == nil {
+		return
+	}
+
+	s.addEvent("", err, options)
+}
+
+func (s *bufferedSpan) addEvent(name string, err error, options []trace.EventOption) {
+	cfg := trace.NewEventConfig(options...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.events = append(s.events, bufferedEvent{name: name, err: err, attrs: cfg.Attributes(), time: cfg.Timestamp()})
+}
+
+// IsRecording returns true until the span ends, the data is buffered.
+func (s *bufferedSpan) IsRecording() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.ended
+}
+
+// SpanContext returns the span context of the parent, the span may never be recorded.
+func (s *bufferedSpan) SpanContext() trace.SpanContext {
+	return trace.SpanContextFromContext(s.parent)
+}
+
+// SetStatus buffers the status, an error status can not be overridden by an ok one.
+func (s *bufferedSpan) SetStatus(code codes.Code, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended || code < s.code {
+		return
+	}
+
+	s.code = code
+	s.desc = ""
+
+	if code == codes.Error {
+		s.desc = description
+	}
+}
+
+// SetName buffers the name.
+func (s *bufferedSpan) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ended {
+		s.name = name
+	}
+}
+
+// SetAttributes buffers the attributes.
+func (s *bufferedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ended {
+		s.attrs = append(s.attrs, kv...)
+	}
+}
+
+// TracerProvider returns the provider of the span of the parent.
+func (s *bufferedSpan) TracerProvider() trace.TracerProvider {
+	return trace.SpanFromContext(s.parent).TracerProvider()
+}
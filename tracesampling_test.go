@@ -0,0 +1,144 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithTraceOnError(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("echo", exec.WithTracer(tracer), exec.WithTraceOnError(), exec.WithArgs("hello"))
+	require.NoError(t, err)
+
+	assert.Empty(t, recorder.Ended())
+
+	start := time.Now()
+
+	_, err = exec.Run("sh",
+		exec.WithTracer(tracer),
+		exec.WithTraceOnError(),
+		exec.WithArgs("-c", "sleep 0.1; exit 3"),
+	)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	s := spans[0]
+
+	assert.Equal(t, "exec:run", s.Name())
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: "exit status 3"}, s.Status())
+	assert.Contains(t, s.Attributes(), attribute.Int("exec.exit_code", 3))
+
+	// The span has the timing of the execution, not of the time it is recorded.
+	assert.False(t, s.StartTime().Before(start))
+	assert.GreaterOrEqual(t, s.EndTime().Sub(s.StartTime()), 100*time.Millisecond)
+
+	names := make([]string, 0, len(s.Events()))
+
+	for _, e := range s.Events() {
+		names = append(names, e.Name)
+
+		assert.False(t, e.Time.Before(s.StartTime()), e.Name)
+		assert.False(t, e.Time.After(s.EndTime()), e.Name)
+	}
+
+	assert.Equal(t, []string{"process started", "process exited", "exception"}, names)
+}
+
+func TestWithTraceOnError_Parent(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	out := newSafeBuffer()
+
+	_, err := exec.RunWithContext(ctx, "sh",
+		exec.WithTracer(tracer),
+		exec.WithTraceOnError(),
+		exec.WithArgs("-c", `echo "$SPAN_ID"; exit 1`),
+		exec.WithStdout(out),
+	)
+	require.Error(t, err)
+
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	// The process gets the span of the parent, the one of the execution may not be recorded.
+	assert.Equal(t, parent.SpanContext().SpanID().String(), getOutput(out))
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestWithTraceOnSlow(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("true", exec.WithTracer(tracer), exec.WithTraceOnSlow(100*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = exec.Run("false", exec.WithTracer(tracer), exec.WithTraceOnSlow(100*time.Millisecond))
+	require.Error(t, err)
+
+	assert.Empty(t, recorder.Ended())
+
+	_, err = exec.Run("sleep", exec.WithTracer(tracer), exec.WithTraceOnSlow(100*time.Millisecond), exec.WithArgs("0.15"))
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	assert.Equal(t, codes.Ok, spans[0].Status().Code)
+}
+
+func TestWithTraceOnError_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("")
+
+	_, err := exec.Run("echo",
+		exec.WithTracer(tracer),
+		exec.WithTraceOnError(),
+		exec.WithArgs("hello"),
+		exec.PipeWith("sh", exec.WithArgs("-c", "cat; sleep 0.1"), exec.WithTraceOnSlow(50*time.Millisecond)),
+		exec.Pipe("sh", "-c", "cat; exit 2"),
+	)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	indexes := make([]int64, 0, len(spans))
+
+	for _, s := range spans {
+		assert.Equal(t, "exec:run", s.Name())
+
+		for _, attr := range s.Attributes() {
+			if attr.Key == "exec.stage.index" {
+				indexes = append(indexes, attr.Value.AsInt64())
+			}
+		}
+	}
+
+	// The slow stage and the failing one, the first stage has succeeded and is fast.
+	assert.ElementsMatch(t, []int64{1, 2}, indexes)
+}
@@ -0,0 +1,52 @@
+package exec
+
+import "os"
+
+// WithKillProcessTree kills the whole process tree of the command, not only its process, when it is killed because the
+// context is done, the timeout or the budget is exceeded, or Stop gives up. The stages of a pipeline inherit the option.
+//
+// On Unix systems, the process is started in its own process group, unless it starts its own session, and the group
+// is killed. On Windows, the process is assigned to a job object that is terminated, the processes it spawns before
+// being assigned are not part of the job.
+func WithKillProcessTree() Option {
+	return optionFunc(func(c *Cmd) {
+		c.killProcessTree = true
+	})
+}
+
+func (c *Cmd) prepareProcessTree() {
+	if !c.killProcessTree {
+		return
+	}
+
+	c.processTree = &processTree{}
+	c.processTree.prepare(c)
+}
+
+func (c *Cmd) attachProcessTree() {
+	if c.processTree != nil {
+		c.processTree.attach(c.Process)
+	}
+}
+
+func (c *Cmd) releaseProcessTree() {
+	if c.processTree == nil {
+		return
+	}
+
+	// os/exec kills the process alone when the context is done, it may be waited before watchContext kills the tree.
+	if c.Process != nil && c.ctx.Err() != nil {
+		_ = c.processTree.kill(c.Process) //nolint: errcheck
+	}
+
+	c.processTree.release()
+}
+
+// killProcess kills the process, or its process tree with WithKillProcessTree.
+func (c *Cmd) killProcess(p *os.Process) error {
+	if c.processTree != nil {
+		return c.processTree.kill(p)
+	}
+
+	return p.Kill() //nolint: wrapcheck
+}
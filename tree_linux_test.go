@@ -0,0 +1,122 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestWithKillProcessTree(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		opts     []exec.Option
+	}{
+		{
+			scenario: "context",
+		},
+		{
+			scenario: "timeout",
+			opts:     []exec.Option{exec.WithTimeout(200 * time.Millisecond)},
+		},
+		{
+			scenario: "session",
+			opts: []exec.Option{
+				exec.WithTimeout(200 * time.Millisecond),
+				exec.WithSysProcAttr(&syscall.SysProcAttr{Setsid: true}),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			if tc.opts != nil {
+				ctx = context.Background()
+			}
+
+			out := new(bytes.Buffer)
+
+			_, err := exec.RunWithContext(ctx, "sh", append(tc.opts,
+				exec.WithArgs("-c", "sleep 30 & echo $!; wait"),
+				exec.WithStdout(out),
+				exec.WithKillProcessTree(),
+			)...)
+			require.Error(t, err)
+
+			pid, err := strconv.Atoi(strings.TrimSpace(out.String()))
+			require.NoError(t, err)
+
+			assert.Eventually(t, func() bool {
+				return !processAlive(pid)
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestWithKillProcessTree_Pipe(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	out := new(bytes.Buffer)
+
+	_, err := exec.RunWithContext(ctx, "echo",
+		exec.Pipe("sh", "-c", "sleep 30 >/dev/null & echo $!; wait"),
+		exec.WithStdout(out),
+		exec.WithKillProcessTree(),
+	)
+	require.Error(t, err)
+
+	pid, err := strconv.Atoi(strings.TrimSpace(out.String()))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return !processAlive(pid)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// processAlive reports whether the process exists and is not a zombie waiting to be reaped by its new parent.
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return false
+	}
+
+	status := processStatus(pid)
+
+	return status != "" && status != "Z"
+}
+
+// processStatus returns the state of the process in /proc, such as R, S, T or Z, empty if it does not exist.
+func processStatus(pid int) string {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return ""
+	}
+
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[0]
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// processTree is the process group of a command.
+type processTree struct {
+	mu       sync.Mutex
+	released bool
+}
+
+// prepare makes the process the leader of a new process group. A process that starts its own session already leads
+// a group, and can not be moved to another one.
+func (t *processTree) prepare(c *Cmd) {
+	if attr := sysProcAttr(c); !attr.Setsid {
+		attr.Setpgid = true
+		attr.Pgid = 0
+	}
+}
+
+func (t *processTree) attach(*os.Process) {}
+
+// release stops killing the group once the process is waited: its id may be reused by another group.
+func (t *processTree) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.released = true
+}
+
+func (t *processTree) kill(p *os.Process) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.released {
+		return p.Kill() //nolint: wrapcheck
+	}
+
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+
+		return err //nolint: wrapcheck
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package exec
+
+import (
+	"os"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// processTree is the job object of a command.
+type processTree struct {
+	mu  sync.Mutex
+	job windows.Handle
+}
+
+func (t *processTree) prepare(*Cmd) {}
+
+// attach assigns the process to a new job object. The process is killed alone if it can not be assigned.
+func (t *processTree) attach(p *os.Process) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return
+	}
+
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(p.Pid))
+	if err != nil {
+		_ = windows.CloseHandle(job) //nolint: errcheck
+
+		return
+	}
+
+	defer windows.CloseHandle(h) //nolint: errcheck
+
+	if err := windows.AssignProcessToJobObject(job, h); err != nil {
+		_ = windows.CloseHandle(job) //nolint: errcheck
+
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.job = job
+}
+
+func (t *processTree) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.job != 0 {
+		_ = windows.CloseHandle(t.job) //nolint: errcheck
+
+		t.job = 0
+	}
+}
+
+func (t *processTree) kill(p *os.Process) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.job == 0 {
+		return p.Kill() //nolint: wrapcheck
+	}
+
+	return windows.TerminateJobObject(t.job, 1) //nolint: wrapcheck
+}
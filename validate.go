@@ -0,0 +1,61 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrOptionConflict indicates that the options of a command can not be used together.
+var ErrOptionConflict = errors.New("exec: conflicting options")
+
+// claim records that the option sets the resource of the command, such as its standard input. A resource can only be
+// set by one option, the conflicts are reported by validate. An option that is applied again replaces its previous
+// value, the last one wins.
+func (c *Cmd) claim(resource, option string) {
+	if c.claims == nil {
+		c.claims = make(map[string]string)
+	}
+
+	if prev, ok := c.claims[resource]; ok && prev != option {
+		c.conflicts = append(c.conflicts, fmt.Sprintf("%s and %s both set the %s", prev, option, resource))
+
+		return
+	}
+
+	c.claims[resource] = option
+}
+
+// invalid records an option that can not be applied, it is reported by validate.
+func (c *Cmd) invalid(format string, args ...any) {
+	c.conflicts = append(c.conflicts, fmt.Sprintf(format, args...))
+}
+
+// invalidErr records an option that can not be applied because of the error, such as a file that can not be read. It
+// is reported as is by validate, the first one wins.
+func (c *Cmd) invalidErr(err error) {
+	if c.optionErr == nil {
+		c.optionErr = err
+	}
+}
+
+// validate reports the conflicts between the options once they are all applied.
+func (c *Cmd) validate() error {
+	if c.optionErr != nil {
+		return c.optionErr
+	}
+
+	if c.removeDirOnFailure && c.dirCreate == nil {
+		c.invalid("RemoveDirOnFailure requires WithDirCreate")
+	}
+
+	if c.fileRotation != nil && len(c.outputFiles) == 0 {
+		c.invalid("WithFileRotation requires WithStdoutFile or WithStderrFile")
+	}
+
+	if len(c.conflicts) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrOptionConflict, strings.Join(c.conflicts, "; "))
+}
@@ -0,0 +1,121 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestCommand_OptionConflict(t *testing.T) {
+	t.Parallel()
+
+	mux := exec.NewMultiWriterMux(io.Discard)
+	producer := func(context.Context, io.Writer) error { return nil }
+
+	testCases := []struct {
+		scenario      string
+		options       []exec.Option
+		expectedError string
+	}{
+		{
+			scenario: "duplicate stdin sources",
+			options: []exec.Option{
+				exec.WithStdin(strings.NewReader("hello")),
+				exec.WithStdinProducer(producer, 0),
+			},
+			expectedError: "exec: conflicting options: WithStdin and WithStdinProducer both set the standard input",
+		},
+		{
+			scenario: "stdout and mux",
+			options: []exec.Option{
+				exec.WithOutputMux(mux, "echo"),
+				exec.WithStdout(new(bytes.Buffer)),
+				exec.WithStderr(new(bytes.Buffer)),
+			},
+			expectedError: "exec: conflicting options: WithOutputMux and WithStdout both set the standard output; " +
+				"WithOutputMux and WithStderr both set the standard error",
+		},
+		{
+			scenario: "nil producer",
+			options: []exec.Option{
+				exec.WithStdinProducer(nil, 0),
+			},
+			expectedError: "exec: conflicting options: WithStdinProducer requires a producer",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := exec.Command("echo", tc.options...)
+
+			assert.ErrorIs(t, cmd.Err, exec.ErrOptionConflict)
+			assert.EqualError(t, cmd.Err, tc.expectedError)
+			assert.Error(t, cmd.Run())
+		})
+	}
+}
+
+func TestCommand_NoOptionConflict(t *testing.T) {
+	t.Parallel()
+
+	out := new(bytes.Buffer)
+
+	cmd := exec.Command("cat",
+		exec.WithStdin(strings.NewReader("hello")),
+		exec.WithStdout(out),
+		exec.WithStderr(io.Discard),
+	)
+
+	require.NoError(t, cmd.Err)
+	require.NoError(t, cmd.Run())
+
+	assert.Equal(t, "hello", out.String())
+}
+
+func TestCommand_RepeatedOption(t *testing.T) {
+	t.Parallel()
+
+	first := new(bytes.Buffer)
+	last := new(bytes.Buffer)
+
+	cmd := exec.Command("pwd",
+		exec.WithStdout(first),
+		exec.WithStdout(last),
+		exec.WithDir("/"),
+		exec.WithDir("/tmp"),
+	)
+
+	require.NoError(t, cmd.Err)
+	require.NoError(t, cmd.Run())
+
+	assert.Empty(t, first.String())
+	assert.Equal(t, "/tmp\n", last.String())
+}
+
+func TestCommand_PTYAndStdout(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("tty", exec.WithPTY(), exec.WithStdout(new(bytes.Buffer)))
+
+	if runtime.GOOS == "linux" {
+		// The output of the terminal is copied to the standard output.
+		require.NoError(t, cmd.Err)
+
+		return
+	}
+
+	assert.ErrorIs(t, cmd.Err, exec.ErrOptionConflict)
+	assert.ErrorContains(t, cmd.Err, "WithPTY and WithStdout both set the standard output")
+}
@@ -0,0 +1,139 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// defaultArgMax is the default size limit of the arguments and the environment of a command, the same as the default
+// buffer size of GNU xargs.
+const defaultArgMax = 128 * 1024
+
+// argMaxHeadroom is the space kept free for the things the kernel adds to the process, like the auxiliary vector.
+const argMaxHeadroom = 2048
+
+// ErrArgumentTooLong indicates that an item does not fit in the argument list of a command.
+var ErrArgumentTooLong = errors.New("exec: argument list too long")
+
+// Xargs reads the items from the reader, new line delimited by default, and appends them in batches to the arguments of
+// the command described by the spec, like xargs does. The batches are executed concurrently with at most the given
+// number of workers.
+//
+// The size of a batch is limited by WithMaxArgs and by WithArgMax, taking the arguments and the environment of the
+// command into account. When there is no item, nothing is run and no result is returned, like `xargs -r`, unless
+// WithRunIfEmpty is set.
+func Xargs(ctx context.Context, r io.Reader, spec Spec, workers int, opts ...BatchOption) ([]Result, error) {
+	cfg := newBatchConfig(opts...)
+
+	items, err := readItems(r, cfg.delimiter, cfg.argMax)
+	if err != nil {
+		return nil, err
+	}
+
+	// The command is only configured to be measured, it is never started.
+	base, err := configureCmd(ctx, newStdCmd(ctx, filepath.Clean(spec.Name)), spec.Name, spec.Options...)
+	if err != nil {
+		base.Err = err
+
+		return []Result{newResult(base, err)}, err
+	}
+
+	applyBackend(base)
+
+	batches, err := splitBatches(items, cfg.argMax-argMaxHeadroom-commandSize(base), cfg.maxArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(batches) == 0 && cfg.runIfEmpty {
+		batches = [][]string{nil}
+	}
+
+	return runPool(ctx, len(batches), func(i int) Spec {
+		return Spec{
+			Name:    spec.Name,
+			Options: append(spec.Options[:len(spec.Options):len(spec.Options)], AppendArgs(batches[i]...)),
+		}
+	}, workers, cfg)
+}
+
+func readItems(r io.Reader, delimiter byte, argMax int) ([]string, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), argMax)
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, delimiter); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+
+		return 0, nil, nil
+	})
+
+	var items []string
+
+	for s.Scan() {
+		if item := s.Text(); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	if err := s.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return nil, fmt.Errorf("%w: an item is longer than %d bytes", ErrArgumentTooLong, argMax)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read items: %w", err)
+	}
+
+	return items, nil
+}
+
+func commandSize(c *Cmd) int {
+	size := 0
+
+	for _, arg := range c.Args {
+		size += len(arg) + 1
+	}
+
+	for _, env := range c.environ() {
+		size += len(env) + 1
+	}
+
+	return size
+}
+
+func splitBatches(items []string, limit, maxArgs int) ([][]string, error) {
+	var (
+		batches [][]string
+		current []string
+		size    int
+	)
+
+	for _, item := range items {
+		itemSize := len(item) + 1
+
+		if itemSize > limit {
+			return nil, fmt.Errorf("%w: %.32q", ErrArgumentTooLong, item)
+		}
+
+		if len(current) > 0 && (size+itemSize > limit || (maxArgs > 0 && len(current) >= maxArgs)) {
+			batches = append(batches, current)
+			current, size = nil, 0
+		}
+
+		current = append(current, item)
+		size += itemSize
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches, nil
+}
@@ -0,0 +1,149 @@
+package exec_test
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.nhat.io/exec"
+)
+
+func TestXargs_MaxArgs(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	results, err := exec.Xargs(context.Background(), strings.NewReader("a\nb\n\nc\nd\ne"),
+		exec.Spec{Name: "echo", Options: []exec.Option{exec.WithArgs("-n", "[batch]"), exec.WithStdout(out)}},
+		1,
+		exec.WithMaxArgs(2),
+	)
+	require.NoError(t, err)
+
+	assert.Len(t, results, 3)
+	assert.Equal(t, "[batch] a b[batch] c d[batch] e", getOutput(out))
+}
+
+func TestXargs_NullDelimiter(t *testing.T) {
+	t.Parallel()
+
+	out := newSafeBuffer()
+
+	results, err := exec.Xargs(context.Background(), strings.NewReader("hello world\x00foo\nbar\x00"),
+		exec.Spec{Name: "printf", Options: []exec.Option{exec.WithArgs("<%s>"), exec.WithStdout(out)}},
+		1,
+		exec.WithNullDelimiter(),
+	)
+	require.NoError(t, err)
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "<hello world><foo\nbar>", getOutput(out))
+}
+
+func TestXargs_ArgMax(t *testing.T) {
+	t.Parallel()
+
+	results, err := exec.Xargs(context.Background(), strings.NewReader(strings.Repeat("0123456789\n", 100)),
+		exec.Spec{Name: "true", Options: []exec.Option{exec.WithEnvs(nil)}},
+		4,
+		exec.WithArgMax(64*1024),
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+
+	total := 0
+
+	for _, r := range results {
+		total += len(r.Cmd.Args) - 1
+	}
+
+	assert.Equal(t, 100, total)
+}
+
+func TestXargs_ArgumentTooLong(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.Xargs(context.Background(), strings.NewReader(strings.Repeat("x", 1024)),
+		exec.Spec{Name: "true"},
+		1,
+		exec.WithArgMax(512),
+	)
+
+	assert.ErrorIs(t, err, exec.ErrArgumentTooLong)
+}
+
+func TestXargs_ArgMax_LongItem(t *testing.T) {
+	t.Parallel()
+
+	item := strings.Repeat("x", 200*1024)
+
+	// The item is longer than the default arg max, but fits in the configured one.
+	results, err := exec.Xargs(context.Background(), strings.NewReader(item),
+		exec.Spec{Name: "true"},
+		1,
+		exec.WithArgMax(1<<20),
+	)
+
+	assert.NotErrorIs(t, err, bufio.ErrTooLong)
+	assert.NotErrorIs(t, err, exec.ErrArgumentTooLong)
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{item}, results[0].Cmd.Args[1:])
+}
+
+func TestXargs_EmptyInput(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		options         []exec.BatchOption
+		expectedResults int
+		expectedOutput  string
+	}{
+		{
+			scenario: "nothing is run",
+		},
+		{
+			scenario:        "run if empty",
+			options:         []exec.BatchOption{exec.WithRunIfEmpty()},
+			expectedResults: 1,
+			expectedOutput:  "[batch]",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			out := newSafeBuffer()
+
+			results, err := exec.Xargs(context.Background(), strings.NewReader("\n\n"),
+				exec.Spec{Name: "echo", Options: []exec.Option{exec.WithArgs("[batch]"), exec.WithStdout(out)}},
+				1,
+				tc.options...,
+			)
+			require.NoError(t, err)
+
+			assert.Len(t, results, tc.expectedResults)
+			assert.Equal(t, tc.expectedOutput, getOutput(out))
+		})
+	}
+}
+
+func TestXargs_Error(t *testing.T) {
+	t.Parallel()
+
+	results, err := exec.Xargs(context.Background(), strings.NewReader("1\n2\n3"),
+		exec.Spec{Name: "sh", Options: []exec.Option{exec.WithArgs("-c", `exit "$1"`, "sh")}},
+		2,
+		exec.WithMaxArgs(1),
+	)
+
+	assert.EqualError(t, err, "exit status 1\nexit status 2\nexit status 3")
+	assert.Len(t, results, 3)
+}